@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// inline buttons to confirm deleting everything stored about the user.
+var (
+	btnDeleteMe       = tb.InlineButton{Unique: "deleteme"}
+	btnDeleteMeCancel = tb.InlineButton{Unique: "deleteme_cancel"}
+)
+
+// handleExport replies with everything stored about the user as a JSON document.
+func (b *Bot) handleExport(m *message) {
+	archive, err := b.AccountService.Export(m.SenderUser.ID, time.Now())
+	if err != nil {
+		log.Warnf("error exporting user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyExportFailed))
+		return
+	}
+	data, err := marshalArchive(archive)
+	if err != nil {
+		log.Warnf("error marshaling archive of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyExportFailed))
+		return
+	}
+	doc := &tb.Document{
+		File:     tb.FromReader(bytes.NewReader(data)),
+		FileName: archiveFileName(archive),
+		MIME:     "application/json",
+		Caption:  m.T(replyExportCaption),
+	}
+	if _, err := b.Send(m.Sender, doc); err != nil {
+		log.Warnf("error sending archive to user[%d]: %s", m.SenderUser.ID, err)
+	}
+}
+
+// marshalArchive encodes archive as indented JSON.
+func marshalArchive(archive *model.Archive) ([]byte, error) {
+	return json.MarshalIndent(archive, "", "  ")
+}
+
+// archiveFileName returns the name of the document of archive.
+func archiveFileName(archive *model.Archive) string {
+	return fmt.Sprintf("memobird-bot-%d-%s.json", archive.User.TelegramID, archive.ExportedAt.Format("20060102"))
+}
+
+// handleDeleteMe asks the user to confirm deleting everything stored about them.
+func (b *Bot) handleDeleteMe(m *message) {
+	confirm, cancel := btnDeleteMe, btnDeleteMeCancel
+	confirm.Text = m.T(replyDeleteMe)
+	cancel.Text = m.T(replyDeleteMeCancel)
+	b.Send(m.Sender, m.T(replyDeleteMeConfirm), &tb.SendOptions{
+		ReplyMarkup: &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{confirm}, {cancel}}},
+	})
+}
+
+// handleDeleteMeConfirm permanently deletes everything stored about the user.
+func (b *Bot) handleDeleteMeConfirm(c *callback) {
+	if err := b.AccountService.Delete(c.SenderUser.ID); err != nil {
+		log.Warnf("error deleting user[%d]: %s", c.SenderUser.ID, err)
+		c.Answer(b, replyDeleteMeFailed)
+		return
+	}
+	log.Infof("deleted user[%d] of telegram user[%d] on request", c.SenderUser.ID, c.Sender.ID)
+	b.Respond(c.Callback)
+	if _, err := b.Edit(c.Message, c.T(replyDeleteMeDone)); err != nil {
+		log.Warnf("error editing confirmation of deleting user[%d]: %s", c.SenderUser.ID, err)
+	}
+}
+
+func (b *Bot) handleDeleteMeCancel(c *callback) {
+	b.Respond(c.Callback)
+	if _, err := b.Edit(c.Message, c.T(replyDeleteMeCancelled)); err != nil {
+		log.Warnf("error editing confirmation of deleting user[%d]: %s", c.SenderUser.ID, err)
+	}
+}
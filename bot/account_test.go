@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestArchive(t *testing.T) {
+	deletedAt := time.Date(2020, 5, 1, 8, 0, 0, 0, time.UTC)
+	archive := &model.Archive{
+		ExportedAt: time.Date(2020, 5, 4, 12, 0, 0, 0, time.UTC),
+		User:       model.User{Model: gorm.Model{ID: 1}, TelegramID: 42, TelegramUserName: "alice"},
+		Contents:   []model.Content{{Model: gorm.Model{ID: 3, DeletedAt: &deletedAt}, UserID: 1, Text: "hello"}},
+	}
+	assert.Equal(t, "memobird-bot-42-20200504.json", archiveFileName(archive))
+
+	data, err := marshalArchive(archive)
+	if assert.NoError(t, err) {
+		var decoded model.Archive
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, "alice", decoded.User.TelegramUserName)
+		if assert.Len(t, decoded.Contents, 1) {
+			// soft-deleted rows are exported as stored
+			assert.Equal(t, "hello", decoded.Contents[0].Text)
+			assert.True(t, deletedAt.Equal(*decoded.Contents[0].DeletedAt))
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	statsDays          = 7
+	userRecentErrors   = 5
+	broadcastPerSecond = 20
+)
+
+// isAdmin returns true if the telegram user is an administrator of the bot.
+func (b *Bot) isAdmin(telegramID int) bool {
+	for _, id := range b.AdminIDs {
+		if id == telegramID {
+			return true
+		}
+	}
+	return false
+}
+
+// adminOnly wraps handler so that only administrators can use it.
+func (b *Bot) adminOnly(handler ctxHandler) ctxHandler {
+	return func(m *message) {
+		if !b.isAdmin(m.Sender.ID) {
+			b.Send(m.Sender, m.T(replyAdminOnly))
+			return
+		}
+		handler(m)
+	}
+}
+
+// userByArg returns the user of given telegram ID or @username, nil if not found.
+func (b *Bot) userByArg(arg string) (*model.User, error) {
+	if strings.HasPrefix(arg, "@") {
+		return b.userByMention(arg)
+	}
+	telegramID, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, nil
+	}
+	user, err := b.UserService.GetByTelegramID(telegramID)
+	if service.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return user, err
+}
+
+func yesNo(m *message, v bool) string {
+	if v {
+		return m.T(replyYes)
+	}
+	return m.T(replyNo)
+}
+
+func (b *Bot) handleStats(m *message) {
+	users, err := b.UserService.Count()
+	if err != nil {
+		log.Warn("error counting users:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	active, err := b.UserService.CountSeenSince(time.Now().Add(-activeUsersWindow))
+	if err != nil {
+		log.Warn("error counting active users:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	devices, err := b.DeviceService.CountVerified()
+	if err != nil {
+		log.Warn("error counting devices:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	since := time.Now().AddDate(0, 0, 1-statsDays)
+	stats, err := b.PrintAttemptService.DailyStats(since, time.Local)
+	if err != nil {
+		log.Warn("error querying print stats:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	var (
+		lines         []string
+		total, failed int
+	)
+	for _, s := range stats {
+		total += s.Total
+		failed += s.Failed
+		lines = append(lines, m.T(replyStatsDay, i18n.Params{
+			"day":    s.Day.Format("2006-01-02"),
+			"total":  s.Total,
+			"failed": s.Failed,
+		}))
+	}
+	var rate float64
+	if total > 0 {
+		rate = float64(failed) / float64(total) * 100
+	}
+	b.Send(m.Sender, m.N(replyStats, statsDays, i18n.Params{
+		"users":   users,
+		"active":  active,
+		"devices": devices,
+		"prints":  strings.Join(lines, "\n"),
+		"rate":    fmt.Sprintf("%.1f%%", rate),
+	}))
+}
+
+func (b *Bot) handleBroadcast(m *message) {
+	text := strings.TrimSpace(m.Payload)
+	if text == "" {
+		b.Send(m.Sender, m.T(replyBroadcastHelp))
+		return
+	}
+	users, err := b.UserService.ListNotBanned()
+	if err != nil {
+		log.Warn("error querying users:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	done, ok := b.jobs.begin(fmt.Sprintf("broadcasting to %d users", len(users)))
+	if !ok {
+		b.Send(m.Sender, m.T(replyShuttingDown))
+		return
+	}
+	b.Send(m.Sender, m.N(replyBroadcastStarted, len(users)))
+	go func() {
+		defer done()
+		b.broadcast(m, users, text)
+	}()
+}
+
+// broadcast sends text to users at a rate acceptable by telegram, then reports to the sender of m.
+func (b *Bot) broadcast(m *message, users []model.User, text string) {
+	ticker := time.NewTicker(time.Second / broadcastPerSecond)
+	defer ticker.Stop()
+
+	var sent int
+	for _, user := range users {
+		<-ticker.C
+		if _, err := b.Send(&tb.User{ID: int(user.TelegramID)}, text); err != nil {
+			log.Infof("error broadcasting to user[%d]: %s", user.ID, err)
+			continue
+		}
+		sent++
+	}
+	log.Infof("broadcast delivered to %d of %d users", sent, len(users))
+	b.Send(m.Sender, m.T(replyBroadcastDone, i18n.Params{"sent": sent, "total": len(users)}))
+}
+
+func (b *Bot) handleBan(m *message) {
+	b.setBanned(m, true)
+}
+
+func (b *Bot) handleUnban(m *message) {
+	b.setBanned(m, false)
+}
+
+func (b *Bot) setBanned(m *message, banned bool) {
+	arg := strings.TrimSpace(m.Payload)
+	if arg == "" {
+		b.Send(m.Sender, m.T(replyBanHelp))
+		return
+	}
+	user, err := b.userByArg(arg)
+	if err != nil {
+		log.Warnf("error querying user[%s]: %s", arg, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if user == nil {
+		b.Send(m.Sender, m.T(replyUserNotFound))
+		return
+	}
+	if banned && b.isAdmin(int(user.TelegramID)) {
+		b.Send(m.Sender, m.T(replyCannotBanAdmin))
+		return
+	}
+
+	if err := b.UserService.SetBanned(user.ID, banned); err != nil {
+		log.Warnf("error banning user[%d]: %s", user.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	log.Infof("user[%d] banned: %t by telegram user[%d]", user.ID, banned, m.Sender.ID)
+	if banned {
+		b.Send(m.Sender, m.T(replyUserBanned, i18n.Params{"user": arg}))
+	} else {
+		b.Send(m.Sender, m.T(replyUserUnbanned, i18n.Params{"user": arg}))
+	}
+}
+
+func (b *Bot) handleUser(m *message) {
+	arg := strings.TrimSpace(m.Payload)
+	if arg == "" {
+		b.Send(m.Sender, m.T(replyUserHelp))
+		return
+	}
+	user, err := b.userByArg(arg)
+	if err != nil {
+		log.Warnf("error querying user[%s]: %s", arg, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if user == nil {
+		b.Send(m.Sender, m.T(replyUserNotFound))
+		return
+	}
+
+	devices, err := b.DeviceService.ListByUserID(user.ID)
+	if err != nil {
+		log.Warnf("error querying devices of user[%d]: %s", user.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	failures, err := b.PrintAttemptService.ListFailedByUserID(user.ID, userRecentErrors)
+	if err != nil {
+		log.Warnf("error querying print attempts of user[%d]: %s", user.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	deviceLines := []string{}
+	for _, d := range devices {
+		deviceLines = append(deviceLines, m.T(replyUserDevice, i18n.Params{
+			"id":       d.ID,
+			"memobird": d.MemobirdID,
+			"verified": yesNo(m, d.IsVerified()),
+		}))
+	}
+	if len(deviceLines) == 0 {
+		deviceLines = append(deviceLines, m.T(replyNone))
+	}
+	errorLines := []string{}
+	for _, c := range failures {
+		errorLines = append(errorLines, fmt.Sprintf("- %s %s", c.CreatedAt.Format("2006-01-02 15:04"), c.Error))
+	}
+	if len(errorLines) == 0 {
+		errorLines = append(errorLines, m.T(replyNone))
+	}
+
+	b.Send(m.Sender, m.T(replyUserInfo, i18n.Params{
+		"name":     user.TelegramFullName,
+		"username": user.TelegramUserName,
+		"id":       user.TelegramID,
+		"joined":   user.CreatedAt.Format("2006-01-02 15:04"),
+		"banned":   yesNo(m, user.IsBanned()),
+		"devices":  strings.Join(deviceLines, "\n"),
+		"errors":   strings.Join(errorLines, "\n"),
+	}))
+}
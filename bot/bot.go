@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/tevino/log"
 
+	"github.com/awesome-memobird/the-memobird-bot/feed"
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
 	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/metrics"
 	"github.com/awesome-memobird/the-memobird-bot/model"
 	"github.com/awesome-memobird/the-memobird-bot/service"
+	"github.com/awesome-memobird/the-memobird-bot/webhook"
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
@@ -20,9 +26,19 @@ import (
 
 // UserService represents the ability of the user service.
 type UserService interface {
-	IsExistsByTelegramID(telegramID int) (bool, error)
 	GetByTelegramID(telegramID int) (*model.User, error)
-	New(*model.User) error
+	GetByTelegramUserName(userName string) (*model.User, error)
+	GetByID(id uint) (*model.User, error)
+	Upsert(profile *model.User, now time.Time) (*model.User, error)
+	SetHistoryRetentionDays(userID uint, days int) error
+	SetLanguage(userID uint, language string) error
+	SetPrintTemplate(userID uint, tmpl string) error
+	SetContactLayout(userID uint, layout string) error
+	SetStickerCaption(userID uint, on bool) error
+	Count() (int, error)
+	CountSeenSince(t time.Time) (int, error)
+	ListNotBanned() ([]model.User, error)
+	SetBanned(userID uint, banned bool) error
 }
 
 // DeviceService represents the ability of the device service.
@@ -30,70 +46,434 @@ type DeviceService interface {
 	IsFree(memobirdID string) (bool, error)
 	New(*model.Device) (*model.Device, error)
 	GetByUserID(uint) (*model.Device, error)
+	GetByID(uint) (*model.Device, error)
+	SetQuota(deviceID uint, quota model.Quota) error
+	SetNickname(deviceID uint, nickname string) error
+	SetTimezone(deviceID uint, timezone string) error
+	SetPrintTemplate(deviceID uint, tmpl string) error
+	ListByUserID(userID uint) ([]model.Device, error)
+	CountVerified() (int, error)
 	VerifyCodeByUserID(code string, userID uint) (bool, error)
 }
 
 // BirdService represents the ability of the bird service.
 type BirdService interface {
 	PrintTextToBird(birdID, text string) (*memobird.PrintResult, error)
+	PrintDocumentToBird(birdID string, doc *memobird.Document) (*memobird.PrintResult, error)
 	BindBirdWithMessage(birdID, msg string) (*memobird.PrintResult, error)
 }
 
+// ContentService represents the ability of the content service.
+type ContentService interface {
+	New(*model.Content) error
+	GetByIDAndUserID(id, userID uint) (*model.Content, error)
+	ListByUserID(userID uint, offset, limit int) ([]model.Content, int, error)
+	DeleteByIDAndUserID(id, userID uint) (bool, error)
+	PruneByUserID(userID uint, before time.Time) (int64, error)
+}
+
+// ShareService represents the ability of the share service.
+type ShareService interface {
+	New(deviceID, userID uint) (*model.Share, error)
+	Get(deviceID, userID uint) (*model.Share, error)
+	ListByDeviceID(deviceID uint) ([]model.Share, error)
+	ListByUserID(userID uint) ([]model.Share, error)
+	Delete(deviceID, userID uint) (bool, error)
+	SetQuota(deviceID, userID uint, quota model.Quota) (bool, error)
+}
+
+// UsageService represents the ability of the usage service.
+type UsageService interface {
+	New(*model.Usage) error
+	ListByDeviceID(deviceID uint, since time.Time) ([]model.Usage, error)
+}
+
+// PrintAttemptService represents the ability of the print attempt service.
+type PrintAttemptService interface {
+	New(*model.PrintAttempt) error
+	ListFailedByUserID(userID uint, limit int) ([]model.PrintAttempt, error)
+	DailyStats(since time.Time, loc *time.Location) ([]model.DailyPrintStat, error)
+}
+
+// WebhookService represents the ability of the webhook service.
+type WebhookService interface {
+	New(*model.Webhook) (*model.Webhook, error)
+	GetBySecret(secret string) (*model.Webhook, error)
+	ListByUserID(userID uint) ([]model.Webhook, error)
+	DeleteByIDAndUserID(id, userID uint) (bool, error)
+	SetTemplate(id, userID uint, tmpl string) (bool, error)
+}
+
+// ChannelService represents the ability of the channel service.
+type ChannelService interface {
+	New(*model.ChannelSubscription) (*model.ChannelSubscription, error)
+	GetByID(id uint) (*model.ChannelSubscription, error)
+	ListApprovedByChannelID(channelID int64) ([]model.ChannelSubscription, error)
+	ListByUserID(userID uint) ([]model.ChannelSubscription, error)
+	ListDigests() ([]model.ChannelSubscription, error)
+	Approve(id uint, now time.Time) error
+	Delete(id uint) (bool, error)
+	SetFilter(id uint, hashtags string, includePhotos bool) error
+	SetDigestHours(id uint, hours int) error
+	SavePost(*model.ChannelPost) error
+	ListPosts(subscriptionID uint) ([]model.ChannelPost, error)
+	FinishDigest(id uint, lastPostID uint, now time.Time) error
+}
+
+// FeedService represents the ability of the feed service.
+type FeedService interface {
+	GetOrNew(url string) (*model.Feed, error)
+	GetByID(id uint) (*model.Feed, error)
+	ListDue(before time.Time) ([]model.Feed, error)
+	SaveChecked(*model.Feed) error
+	AddItems(feedID uint, items []model.FeedItem) (int, error)
+	LastItemID(feedID uint) (uint, error)
+	ListItemsAfter(feedID, afterID uint, limit int) ([]model.FeedItem, error)
+	Subscribe(*model.FeedSubscription) (*model.FeedSubscription, error)
+	CountSubscriptionsByUserID(userID uint) (int, error)
+	ListSubscriptionsByUserID(userID uint) ([]model.FeedSubscription, error)
+	ListSubscriptionsByFeedID(feedID uint) ([]model.FeedSubscription, error)
+	ListDigestSubscriptions() ([]model.FeedSubscription, error)
+	DeleteSubscription(id, userID uint) (bool, error)
+	MarkPrinted(id, lastItemID uint, digestAt *time.Time) error
+}
+
+// FeedFetcher represents the ability to fetch feeds.
+type FeedFetcher interface {
+	Fetch(url string, v feed.Validators) (*feed.Result, error)
+}
+
+// TodoService represents the ability of the to-do service.
+type TodoService interface {
+	GetOrNewList(userID uint, name string) (*model.TodoList, error)
+	GetList(userID uint, name string) (*model.TodoList, error)
+	ListLists(userID uint) ([]model.TodoList, error)
+	ListMorningLists() ([]model.TodoList, error)
+	SetMorningTime(listID uint, morningTime string) error
+	MarkMorningPrinted(listID uint, at time.Time) error
+	DeleteList(listID uint) error
+	AddItem(listID uint, text string) (*model.TodoItem, error)
+	ListItems(listID uint) ([]model.TodoItem, error)
+	SetDone(itemID uint, at *time.Time) error
+	DeleteDone(listID uint) (int64, error)
+}
+
+// DraftService represents the ability of the draft service.
+type DraftService interface {
+	GetByUserID(userID uint) (*model.Draft, error)
+	GetOrNew(userID uint) (*model.Draft, error)
+	AddPart(*model.DraftPart) error
+	CountParts(draftID uint) (int, error)
+	ListParts(draftID uint) ([]model.DraftPart, error)
+	ListIdle(before time.Time) ([]model.Draft, error)
+	Delete(draftID uint) error
+}
+
+// AccountService represents the ability of the account service.
+type AccountService interface {
+	Export(userID uint, now time.Time) (*model.Archive, error)
+	Delete(userID uint) error
+}
+
 // Bot is a telegram bot.
 type Bot struct {
 	*Config
 	*tb.Bot
+
+	jobs         jobs
+	metrics      botMetrics
+	activeUsers  activeUsers
+	webhookDedup webhook.Deduper
+	forwards     forwardBatches
+	queue        printQueue
+	deviceLocks  deviceLocks
+
+	tasks        []task
+	stopTasks    sync.Once
+	tasksStopped chan struct{}
 }
 
 // New creates a new telegram bot.
 func New(config *Config) (*Bot, error) {
 	rawBot, err := tb.NewBot(tb.Settings{
+		URL:    config.APIURL,
 		Token:  config.Token,
-		Poller: &tb.LongPoller{Timeout: config.PollerTimeout},
+		Poller: &poller{Timeout: config.PollerTimeout},
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("error creating bot: %w", err)
 	}
 	b := &Bot{
-		Bot:    rawBot,
-		Config: config,
+		Bot:          rawBot,
+		Config:       config,
+		tasksStopped: make(chan struct{}),
+	}
+	b.webhookDedup.Window = webhookDedupWindow
+	registry := config.Metrics
+	if registry == nil {
+		registry = metrics.NewRegistry()
 	}
+	b.registerMetrics(registry)
+
+	b.forwards.window = forwardBatchWindow
+	b.forwards.flush = b.printForwards
 
 	b.Handle(tb.OnText, b.handleText)
+	b.Handle(tb.OnPhoto, b.handleMedia)
+	b.Handle(tb.OnVideo, b.handleMedia)
+	// venues come with locations, they are told apart by the handler
+	b.Handle(tb.OnLocation, b.handleLocation)
+	b.Handle(tb.OnVenue, b.handleLocation)
+	b.Handle(tb.OnContact, b.handleContact)
+	b.Handle(tb.OnDocument, b.handleDocument)
+	b.Handle(tb.OnSticker, b.handleSticker)
+	b.Handle(tb.OnEdited, b.handleEdited)
+	b.Handle(tb.OnChannelPost, b.handleChannelPost)
+	b.Handle(tb.OnEditedChannelPost, b.handleEditedChannelPost)
+	b.Handle(tb.OnQuery, b.handleQuery)
+	b.Handle(tb.OnChosenInlineResult, b.handleChosenInlineResult)
+	b.Handle(&btnChannelApprove, b.withCallback(b.handleChannelApprove))
+	b.Handle(&btnChannelReject, b.withCallback(b.handleChannelReject))
+	b.Handle(&btnHistoryPage, b.withCallback(b.handleHistoryPage))
+	b.Handle(&btnHistoryReprint, b.withCallback(b.handleHistoryReprint))
+	b.Handle(&btnHistoryForget, b.withCallback(b.handleHistoryForget))
+	b.Handle(&btnDocumentPrint, b.withCallback(b.handleDocumentPrint))
+	b.Handle(&btnDocumentCancel, b.withCallback(b.handleDocumentCancel))
+	b.Handle(&btnEditReprint, b.withCallback(b.handleEditReprint))
+	b.Handle(&btnDeleteMe, b.withCallback(b.handleDeleteMeConfirm))
+	b.Handle(&btnDeleteMeCancel, b.withCallback(b.handleDeleteMeCancel))
+
+	b.tasks = append(b.tasks, b.printChannelDigests, b.pollFeeds, b.printTodoMornings, b.expireDrafts)
 	return b, nil
 }
 
+// keys of replies in catalogs.
 const (
-	replyFailedGettingData         = "I'm having trouble getting your data, please try again in a moment."
-	replyMetBeforeS                = "Hi %s, we've met before."
-	replyNiceToMeetYouS            = "Hello %s, nice to meet you!"
-	replyCheckMemobirdID           = "Please check the Memobird ID provided."
-	replyBindHelp                  = "Please use /bind [YourMemobirdID] to bind a Memobird before sending anything for printing"
-	replyVerificationInstructionDS = `To complete the verification
-please send:
-    /verify %d
-to:
-    @%s
-`
-	replyFailedSendingVerification = "I'm having trouble sending you a verification code, please check the Memobird ID provided or try again in a moment."
-	replyVerificationSent          = "A verification code with instructions was sent to your device, please follow it to complete the binding."
-	replyBindComplete              = "Device binding complete!"
-	replyVerificationFailed        = "Verification failed, please check the code or try again in a moment."
-	replyFailedSendingMessageS     = "Error sending your message: %s"
-	replySentPrintedTT             = "- Sent: %t\n- Printed: %t"
-	replySent                      = "Sent"
-	replySentFailure               = "The message failed to deliver"
+	replyFailedGettingData           = "failed_getting_data"
+	replyMetBefore                   = "met_before"
+	replyNiceToMeetYou               = "nice_to_meet_you"
+	replyCheckMemobirdID             = "check_memobird_id"
+	replyBindHelp                    = "bind_help"
+	replyVerificationInstruction     = "verification_instruction"
+	replyFailedSendingVerification   = "failed_sending_verification"
+	replyVerificationSent            = "verification_sent"
+	replyBindComplete                = "bind_complete"
+	replyVerificationFailed          = "verification_failed"
+	replyFailedSendingMessage        = "failed_sending_message"
+	replySentPrinted                 = "sent_printed"
+	replySent                        = "sent"
+	replySentFailure                 = "sent_failure"
+	replyHistoryEmpty                = "history_empty"
+	replyHistoryPage                 = "history_page"
+	replyHistoryImage                = "history_image"
+	replyHistoryReprint              = "history_reprint"
+	replyHistoryForget               = "history_forget"
+	replyHistoryNewer                = "history_newer"
+	replyHistoryOlder                = "history_older"
+	replyHistoryNotFound             = "history_not_found"
+	replyHistoryForgotten            = "history_forgotten"
+	replyRetention                   = "retention"
+	replyRetentionForever            = "retention_forever"
+	replyRetentionHelp               = "retention_help"
+	replyNeedVerifiedDevice          = "need_verified_device"
+	replyShareHelp                   = "share_help"
+	replyShareUserNotFound           = "share_user_not_found"
+	replyShared                      = "shared"
+	replySharedWithYou               = "shared_with_you"
+	replySharesNone                  = "shares_none"
+	replySharesHeader                = "shares_header"
+	replyUnshared                    = "unshared"
+	replyNotShared                   = "not_shared"
+	replyLimitHelp                   = "limit_help"
+	replyLimits                      = "limits"
+	replyLimitSet                    = "limit_set"
+	replyQuotaMessages               = "quota_messages"
+	replyQuotaMessagesUnlimited      = "quota_messages_unlimited"
+	replyQuotaChars                  = "quota_chars"
+	replyQuotaCharsUnlimited         = "quota_chars_unlimited"
+	replyQuotaExceeded               = "quota_exceeded"
+	replyDeviceQuotaExceeded         = "device_quota_exceeded"
+	replyQuotaTooLong                = "quota_too_long"
+	replyLangName                    = "lang_name"
+	replyLangCurrent                 = "lang_current"
+	replyLangAuto                    = "lang_auto"
+	replyLangSet                     = "lang_set"
+	replyLangHelp                    = "lang_help"
+	replyYes                         = "yes"
+	replyNo                          = "no"
+	replyNone                        = "none"
+	replyBanned                      = "banned"
+	replyAdminOnly                   = "admin_only"
+	replyStats                       = "stats"
+	replyStatsDay                    = "stats_day"
+	replyBroadcastHelp               = "broadcast_help"
+	replyBroadcastStarted            = "broadcast_started"
+	replyBroadcastDone               = "broadcast_done"
+	replyBanHelp                     = "ban_help"
+	replyCannotBanAdmin              = "cannot_ban_admin"
+	replyUserBanned                  = "user_banned"
+	replyUserUnbanned                = "user_unbanned"
+	replyUserHelp                    = "user_help"
+	replyUserNotFound                = "user_not_found"
+	replyUserInfo                    = "user_info"
+	replyUserDevice                  = "user_device"
+	replyShuttingDown                = "shutting_down"
+	replyFeatureDisabled             = "feature_disabled"
+	replyWebhookHelp                 = "webhook_help"
+	replyWebhooksHeader              = "webhooks_header"
+	replyWebhookCreated              = "webhook_created"
+	replyWebhookTemplateNeeded       = "webhook_template_needed"
+	replyWebhookSigningSecret        = "webhook_signing_secret"
+	replyWebhookNoPublicURL          = "webhook_no_public_url"
+	replyWebhookNotFound             = "webhook_not_found"
+	replyWebhookDeleted              = "webhook_deleted"
+	replyWebhookInvalidTemplate      = "webhook_invalid_template"
+	replyWebhookTemplateSet          = "webhook_template_set"
+	replyTemplateHelp                = "template_help"
+	replyTemplateShow                = "template_show"
+	replyTemplateSourceUser          = "template_source_user"
+	replyTemplateSourceDevice        = "template_source_device"
+	replyTemplateSourceDefault       = "template_source_default"
+	replyTemplateInvalid             = "template_invalid"
+	replyTemplateTooLong             = "template_too_long"
+	replyTemplateSet                 = "template_set"
+	replyTemplateReset               = "template_reset"
+	replyDeviceHelp                  = "device_help"
+	replyDeviceInfo                  = "device_info"
+	replyDeviceNicknameSet           = "device_nickname_set"
+	replyDeviceTimezoneSet           = "device_timezone_set"
+	replyDeviceTimezoneInvalid       = "device_timezone_invalid"
+	replyForwardHiddenSender         = "forward_hidden_sender"
+	replyMediaPhoto                  = "media_photo"
+	replyMediaVideo                  = "media_video"
+	replyChannelHelp                 = "channel_help"
+	replyChannelsNone                = "channels_none"
+	replyChannelsHeader              = "channels_header"
+	replyChannelItem                 = "channel_item"
+	replyChannelStatusPending        = "channel_status_pending"
+	replyChannelStatusApproved       = "channel_status_approved"
+	replyChannelAllPosts             = "channel_all_posts"
+	replyChannelTextOnly             = "channel_text_only"
+	replyChannelWithPhotos           = "channel_with_photos"
+	replyChannelDigestOff            = "channel_digest_off"
+	replyChannelDigestEvery          = "channel_digest_every"
+	replyChannelNotFound             = "channel_not_found"
+	replyChannelNeedAdmin            = "channel_need_admin"
+	replyChannelNotAdmin             = "channel_not_admin"
+	replyChannelDeviceNotFound       = "channel_device_not_found"
+	replyChannelLinked               = "channel_linked"
+	replyChannelPending              = "channel_pending"
+	replyChannelApproval             = "channel_approval"
+	replyChannelApprove              = "channel_approve"
+	replyChannelReject               = "channel_reject"
+	replyChannelApproved             = "channel_approved"
+	replyChannelRejected             = "channel_rejected"
+	replyChannelSubscriptionNotFound = "channel_subscription_not_found"
+	replyChannelUnlinked             = "channel_unlinked"
+	replyChannelUpdated              = "channel_updated"
+	replyChannelDigestHeader         = "channel_digest_header"
+	replyChannelPostEdited           = "channel_post_edited"
+	replyFeedHelp                    = "feed_help"
+	replyFeedTooMany                 = "feed_too_many"
+	replyFeedUnreachable             = "feed_unreachable"
+	replyFeedSubscribed              = "feed_subscribed"
+	replyFeedInstantly               = "feed_instantly"
+	replyFeedDaily                   = "feed_daily"
+	replyFeedsNone                   = "feeds_none"
+	replyFeedsHeader                 = "feeds_header"
+	replyFeedNotFound                = "feed_not_found"
+	replyFeedUnsubscribed            = "feed_unsubscribed"
+	replyTodoHelp                    = "todo_help"
+	replyTodoListsNone               = "todo_lists_none"
+	replyTodoListsHeader             = "todo_lists_header"
+	replyTodoOpenItems               = "todo_open_items"
+	replyTodoMorningAt               = "todo_morning_at"
+	replyTodoItemTooLong             = "todo_item_too_long"
+	replyTodoTooManyLists            = "todo_too_many_lists"
+	replyTodoTooManyItems            = "todo_too_many_items"
+	replyTodoAdded                   = "todo_added"
+	replyTodoEmpty                   = "todo_empty"
+	replyTodoListNotFound            = "todo_list_not_found"
+	replyTodoItemNotFound            = "todo_item_not_found"
+	replyTodoNothingToPrint          = "todo_nothing_to_print"
+	replyTodoCleared                 = "todo_cleared"
+	replyTodoDeleted                 = "todo_deleted"
+	replyTodoMorningOff              = "todo_morning_off"
+	replyTodoMorningSet              = "todo_morning_set"
+	replyTodoSlipTitle               = "todo_slip_title"
+	replyDraftStarted                = "draft_started"
+	replyDraftAlready                = "draft_already"
+	replyDraftNone                   = "draft_none"
+	replyDraftEmpty                  = "draft_empty"
+	replyDraftFull                   = "draft_full"
+	replyDraftAdded                  = "draft_added"
+	replyDraftPreview                = "draft_preview"
+	replyDraftFailed                 = "draft_failed"
+	replyDraftDiscarded              = "draft_discarded"
+	replyDraftExpired                = "draft_expired"
+	replyPreviewHelp                 = "preview_help"
+	replyPreviewFailed               = "preview_failed"
+	replyPreviewWide                 = "preview_wide"
+	replyInlinePrintTo               = "inline_print_to"
+	replyInlineBindFirst             = "inline_bind_first"
+	replyInlinePrinted               = "inline_printed"
+	replyLocationTitle               = "location_title"
+	replyLocationFailed              = "location_failed"
+	replyContactPhone                = "contact_phone"
+	replyContactTelegram             = "contact_telegram"
+	replyContactFailed               = "contact_failed"
+	replySettings                    = "settings"
+	replySettingLine                 = "setting_line"
+	replySettingContact              = "setting_contact"
+	replySettingUnknown              = "setting_unknown"
+	replySettingInvalid              = "setting_invalid"
+	replySettingSet                  = "setting_set"
+	replyDocumentUnsupported         = "document_unsupported"
+	replyDocumentTooLarge            = "document_too_large"
+	replyDocumentFailed              = "document_failed"
+	replyDocumentEncoding            = "document_encoding"
+	replyDocumentEmpty               = "document_empty"
+	replyDocumentConfirm             = "document_confirm"
+	replyDocumentPrint               = "document_print"
+	replyDocumentCancel              = "document_cancel"
+	replyDocumentGone                = "document_gone"
+	replyDocumentPrinting            = "document_printing"
+	replyDocumentPartFailed          = "document_part_failed"
+	replyDocumentPrinted             = "document_printed"
+	replyDocumentCancelled           = "document_cancelled"
+	replyStickerFailed               = "sticker_failed"
+	replySettingStickerCaption       = "setting_sticker_caption"
+	replyQueued                      = "queued"
+	replyEditQueued                  = "edit_queued"
+	replyEditPrinted                 = "edit_printed"
+	replyEditReprint                 = "edit_reprint"
+	replyEditReprinting              = "edit_reprinting"
+	replyEditGone                    = "edit_gone"
+	replyCancelHelp                  = "cancel_help"
+	replyCancelled                   = "cancelled"
+	replyCancelTooLate               = "cancel_too_late"
+	replyExportFailed                = "export_failed"
+	replyExportCaption               = "export_caption"
+	replyDeleteMeConfirm             = "deleteme_confirm"
+	replyDeleteMe                    = "deleteme"
+	replyDeleteMeCancel              = "deleteme_cancel"
+	replyDeleteMeCancelled           = "deleteme_cancelled"
+	replyDeleteMeDone                = "deleteme_done"
+	replyDeleteMeFailed              = "deleteme_failed"
 )
 
 func (b *Bot) handleStart(m *message) {
-	b.Send(m.Sender, fmt.Sprintf(replyNiceToMeetYouS, m.SenderUser.TelegramFullName))
+	b.Send(m.Sender, m.T(replyNiceToMeetYou, i18n.Params{"name": m.SenderUser.TelegramFullName}))
+	if strings.TrimSpace(m.Payload) == inlineStartParameter {
+		b.Send(m.Sender, m.T(replyBindHelp))
+	}
 }
 
 func (b *Bot) handleBind(m *message) {
 	memobirdID := strings.TrimSpace(m.Payload)
 	if memobirdID == "" {
-		b.Send(m.Sender, replyBindHelp)
+		b.Send(m.Sender, m.T(replyBindHelp))
 		return
 	}
 
@@ -103,10 +483,17 @@ func (b *Bot) handleBind(m *message) {
 		return
 	}
 	if !isFree {
-		b.Send(m.Sender, replyCheckMemobirdID)
+		b.Send(m.Sender, m.T(replyCheckMemobirdID))
 		return
 	}
 
+	done, ok := b.jobs.begin(fmt.Sprintf("binding device[%s] of user[%d]", memobirdID, m.SenderUser.ID))
+	if !ok {
+		b.Send(m.Sender, m.T(replyShuttingDown))
+		return
+	}
+	defer done()
+
 	device, err := b.DeviceService.New(&model.Device{
 		UserID:     m.SenderUser.ID,
 		MemobirdID: memobirdID,
@@ -115,21 +502,22 @@ func (b *Bot) handleBind(m *message) {
 		log.Warnf("error creating device[%s] of user[%d]: %s", memobirdID, m.Sender.ID, err)
 		return
 	}
-	_, err = b.BirdService.BindBirdWithMessage(memobirdID, fmt.Sprintf(replyVerificationInstructionDS,
-		device.VerificationCode,
-		b.Bot.Me.Username))
+	_, err = b.BirdService.BindBirdWithMessage(memobirdID, m.T(replyVerificationInstruction, i18n.Params{
+		"code": device.VerificationCode,
+		"bot":  b.Bot.Me.Username,
+	}))
 	if err != nil {
 		log.Warnf("Error binding: %s", err)
-		b.Send(m.Sender, replyFailedSendingVerification)
+		b.Send(m.Sender, m.T(replyFailedSendingVerification))
 		return
 	}
-	b.Send(m.Sender, replyVerificationSent)
+	b.Send(m.Sender, m.T(replyVerificationSent))
 }
 
 func (b *Bot) handleVerify(m *message) {
 	verificationCode := strings.TrimSpace(m.Payload)
 	if verificationCode == "" {
-		b.Send(m.Sender, replyVerificationFailed)
+		b.Send(m.Sender, m.T(replyVerificationFailed))
 		return
 	}
 
@@ -143,50 +531,167 @@ func (b *Bot) handleVerify(m *message) {
 	if success {
 		msg = replyBindComplete
 	}
-	b.Send(m.Sender, msg)
+	b.Send(m.Sender, m.T(msg))
 }
 
 func (b *Bot) handleSend(m *message) {
-	reply := ""
-
-	device, err := b.DeviceService.GetByUserID(m.SenderUser.ID)
+	device, err := b.printableDevice(m.SenderUser)
 	if err != nil && !service.IsRecordNotFoundError(err) {
 		log.Warn("error querying device:", err)
 		return
 	}
 	if service.IsRecordNotFoundError(err) {
-		reply = replyBindHelp
+		b.Send(m.Sender, m.T(replyBindHelp), &tb.SendOptions{
+			ReplyTo:   m.Message,
+			ParseMode: tb.ModeMarkdown,
+		})
+		return
+	}
+	b.queueSend(m, device)
+}
+
+// printText prints text to device on behalf of user, records the content and returns the reply in lang
+// with the outcome.
+func (b *Bot) printText(lang i18n.Lang, user *model.User, device *model.Device, text string) (string, string) {
+	return b.printDocument(lang, user, device, nil, "", text)
+}
+
+// printDocument is printText printing doc instead if it's not nil, text is what's recorded and counted in quotas.
+// imageFileID is the telegram file of the photo in doc recorded for reprinting, empty if none.
+func (b *Bot) printDocument(lang i18n.Lang, user *model.User, device *model.Device, doc *memobird.Document, imageFileID, text string) (string, string) {
+	done, ok := b.jobs.begin(fmt.Sprintf("printing to device[%d] for user[%d]", device.ID, user.ID))
+	if !ok {
+		b.metrics.prints.Inc(printShuttingDown)
+		return catalogs.T(lang, replyShuttingDown), printShuttingDown
+	}
+	defer done()
+	return b.printDocumentInJob(lang, user, device, doc, imageFileID, text)
+}
+
+// printTextInJob is printText for callers who have begun a job already.
+func (b *Bot) printTextInJob(lang i18n.Lang, user *model.User, device *model.Device, text string) (string, string) {
+	return b.printDocumentInJob(lang, user, device, nil, "", text)
+}
+
+// printSent is printText wrapping body sent by user at sentAt with the active template. Only body is
+// recorded and counted in quotas, so that the history shows and reprints what was sent.
+func (b *Bot) printSent(lang i18n.Lang, user *model.User, device *model.Device, body string, sentAt time.Time) (string, string) {
+	done, ok := b.jobs.begin(fmt.Sprintf("printing to device[%d] for user[%d]", device.ID, user.ID))
+	if !ok {
+		b.metrics.prints.Inc(printShuttingDown)
+		return catalogs.T(lang, replyShuttingDown), printShuttingDown
+	}
+	defer done()
+	return b.printSentInJob(lang, user, device, body, sentAt)
+}
+
+// printSentInJob is printSent for callers who have begun a job already.
+func (b *Bot) printSentInJob(lang i18n.Lang, user *model.User, device *model.Device, body string, sentAt time.Time) (string, string) {
+	return b.printInJob(lang, user, device, nil, "", wrapPrint(user, device, body, sentAt), body)
+}
+
+// printDocumentInJob is printDocument for callers who have begun a job already.
+func (b *Bot) printDocumentInJob(lang i18n.Lang, user *model.User, device *model.Device, doc *memobird.Document, imageFileID, text string) (string, string) {
+	return b.printInJob(lang, user, device, doc, imageFileID, text, text)
+}
+
+// printInJob prints doc, or printed if doc is nil, to device on behalf of user. text is what's recorded and
+// counted in quotas along with imageFileID.
+func (b *Bot) printInJob(lang i18n.Lang, user *model.User, device *model.Device, doc *memobird.Document, imageFileID, printed, text string) (string, string) {
+	outcome := printSuccess
+	defer func() {
+		b.metrics.prints.Inc(outcome)
+	}()
+
+	// the usage is recorded before unlocking, so others wait for the quota of the device
+	defer b.deviceLocks.lock(device.ID)()
+	if reply, ok := b.checkQuota(lang, user, device, text); !ok {
+		outcome = printQuotaExceeded
+		return reply, outcome
+	}
+
+	content := &model.Content{
+		UserID:      user.ID,
+		DeviceID:    device.ID,
+		Text:        text,
+		ImageFileID: imageFileID,
+	}
+	defer b.recordContent(user, content)
+	defer func() {
+		b.recordAttempt(user, device, content.Error)
+	}()
+
+	var result *memobird.PrintResult
+	var err error
+	if doc != nil {
+		result, err = b.BirdService.PrintDocumentToBird(device.MemobirdID, doc)
 	} else {
-		result, err := b.BirdService.PrintTextToBird(device.MemobirdID, m.Payload)
-		if err != nil {
-			reply = fmt.Sprintf(replyFailedSendingMessageS, err)
-		} else {
-			if result.IsSuccess {
-				reply = replySent
-			} else {
-				reply = replySentFailure
-			}
+		result, err = b.BirdService.PrintTextToBird(device.MemobirdID, printed)
+	}
+	if err != nil {
+		outcome = printRequestError
+		content.Error = err.Error()
+		return catalogs.T(lang, replyFailedSendingMessage, i18n.Params{"error": err}), outcome
+	}
+	content.ContentID = result.ContentID
+	content.IsPrinted = result.IsPrinted
+	if result.IsPrinted {
+		now := time.Now()
+		content.PrintedAt = &now
+	}
+	if !result.IsSuccess {
+		outcome = printNotSuccessful
+		content.Error = "not successful"
+		if result.Err != nil {
+			content.Error = result.Err.Error()
 		}
+		return catalogs.T(lang, replySentFailure), outcome
 	}
+	b.recordUsage(user, device, text)
+	return catalogs.T(lang, replySent), outcome
+}
 
-	b.Send(m.Sender, reply, &tb.SendOptions{
-		ReplyTo:   m.Message,
-		ParseMode: tb.ModeMarkdown,
-	})
+// receive prepares msg for handlers, false is returned if it should not be handled.
+func (b *Bot) receive(msg *tb.Message) (*message, bool) {
+	b.activeUsers.touch(msg.Sender.ID, time.Now())
+	user, err := b.upsertUser(msg.Sender)
+	if err != nil {
+		log.Warnf("error upserting telegram user[%d]: %s", msg.Sender.ID, err)
+		b.Send(msg.Sender, catalogs.T(langOf(&model.User{}, msg.Sender.LanguageCode), replyFailedGettingData))
+		return nil, false
+	}
+	m := wrapMessage(msg, user)
+	if m.SenderUser.IsBanned() {
+		b.Send(m.Sender, m.T(replyBanned))
+		return nil, false
+	}
+	return m, true
 }
 
 func (b *Bot) handleText(msg *tb.Message) {
-	if err := b.createUserIfNot(msg.Sender); err != nil {
-		log.Warnf("Error creating telegram user[%s]: %s", msg.Sender.ID, err)
-		b.Send(msg.Sender, replyFailedGettingData)
+	m, ok := b.receive(msg)
+	if !ok {
 		return
 	}
-	m, err := b.wrapMessage(msg)
-	if err != nil {
-		log.Warnf("error wrapping message: %s", err)
-		b.Send(m.Sender, replyFailedGettingData)
+	// forwards are never commands
+	if isForwarded(msg) {
+		m.Command, m.Payload = "", msg.Text
+	}
+	if m.Command == "" && b.addToDraft(m) {
+		b.metrics.updates.Inc("draft")
 		return
 	}
+	// texts following forwards are part of the quote
+	if isForwarded(msg) || (m.Command == "" && b.forwards.has(msg.Chat.ID)) {
+		b.metrics.updates.Inc("forward")
+		b.batchForward(m)
+		return
+	}
+
+	command := m.Command
+	defer func() {
+		b.metrics.updates.Inc(command)
+	}()
 
 	switch m.Command {
 	case "/start":
@@ -197,30 +702,95 @@ func (b *Bot) handleText(msg *tb.Message) {
 		b.handleBind(m)
 	case "/send":
 		b.handleSend(m)
+	case "/cancel":
+		b.handleCancel(m)
+	case "/history":
+		b.featureOnly(b.Features.History, b.handleHistory)(m)
+	case "/retention":
+		b.featureOnly(b.Features.History, b.handleRetention)(m)
+	case "/share":
+		b.featureOnly(b.Features.Sharing, b.handleShare)(m)
+	case "/unshare":
+		b.featureOnly(b.Features.Sharing, b.handleUnshare)(m)
+	case "/limit":
+		b.handleLimit(m)
+	case "/template":
+		b.handleTemplate(m)
+	case "/device":
+		b.handleDevice(m)
+	case "/channel":
+		b.handleChannel(m)
+	case "/subscribe":
+		b.handleSubscribe(m)
+	case "/subscriptions":
+		b.handleSubscriptions(m)
+	case "/unsubscribe":
+		b.handleUnsubscribe(m)
+	case "/todo":
+		b.handleTodo(m)
+	case "/draft":
+		b.handleDraft(m)
+	case "/preview":
+		b.handlePreview(m)
+	case "/print":
+		b.handlePrintDraft(m)
+	case "/discard":
+		b.handleDiscard(m)
+	case "/webhook":
+		b.handleWebhook(m)
+	case "/lang":
+		b.handleLang(m)
+	case "/export":
+		b.handleExport(m)
+	case "/deleteme":
+		b.handleDeleteMe(m)
+	case "/settings":
+		b.handleSettings(m)
+	case "/stats":
+		b.adminOnly(b.handleStats)(m)
+	case "/broadcast":
+		b.adminOnly(b.handleBroadcast)(m)
+	case "/ban":
+		b.adminOnly(b.handleBan)(m)
+	case "/unban":
+		b.adminOnly(b.handleUnban)(m)
+	case "/user":
+		b.adminOnly(b.handleUser)(m)
 	default:
+		// keep the label of metrics bounded
+		command = "text"
+		if m.Command != "" {
+			command = "unknown"
+		}
 		b.handleSend(m)
 	}
 }
 
 var reCmdPrefix = regexp.MustCompile(`^\/[a-z]+( .+)?`)
 
-func (b *Bot) createUserIfNot(sender *tb.User) error {
-	isUserExists, err := b.UserService.IsExistsByTelegramID(sender.ID)
-	if err != nil {
-		return fmt.Errorf("getting telegram user[%d]: %w", sender.ID, err)
+// featureOnly wraps handler so that it is only available if the feature is enabled.
+func (b *Bot) featureOnly(enabled bool, handler ctxHandler) ctxHandler {
+	return func(m *message) {
+		if !enabled {
+			b.Send(m.Sender, m.T(replyFeatureDisabled))
+			return
+		}
+		handler(m)
 	}
+}
 
-	if !isUserExists {
-		fullName := strings.TrimSpace(sender.FirstName + " " + sender.LastName)
-		b.UserService.New(&model.User{
-			TelegramID:       int64(sender.ID),
-			TelegramUserName: sender.Username,
-			TelegramFullName: fullName,
-		})
-	} else {
-		// TODO: update user profile
-	}
-	return nil
+// upsertUser creates the user of sender or refreshes its profile, the user is returned.
+func (b *Bot) upsertUser(sender *tb.User) (*model.User, error) {
+	return b.UserService.Upsert(&model.User{
+		TelegramID:           int64(sender.ID),
+		TelegramUserName:     sender.Username,
+		TelegramFullName:     fullName(sender),
+		TelegramLanguageCode: sender.LanguageCode,
+	}, time.Now())
+}
+
+func fullName(u *tb.User) string {
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
 }
 
 func splitCmdNPayload(txt string) (cmd, payload string) {
@@ -241,16 +811,37 @@ func splitCmdNPayload(txt string) (cmd, payload string) {
 	return cmd, payload
 }
 
-func (b *Bot) wrapMessage(m *tb.Message) (*message, error) {
-	user, err := b.UserService.GetByTelegramID(m.Sender.ID)
-	if err != nil {
-		return nil, fmt.Errorf("getting user by telegram ID[%d]: %w", m.Sender.ID, err)
-	}
+func wrapMessage(m *tb.Message, user *model.User) *message {
 	cmd, payload := splitCmdNPayload(m.Text)
 	return &message{
 		Message:    m,
 		SenderUser: user,
+		Lang:       langOf(user, m.Sender.LanguageCode),
 		Payload:    payload,
 		Command:    cmd,
-	}, nil
+	}
+}
+
+// withCallback wraps handler with the user who pressed the button.
+func (b *Bot) withCallback(handler ctxCallbackHandler) func(*tb.Callback) {
+	return func(c *tb.Callback) {
+		b.activeUsers.touch(c.Sender.ID, time.Now())
+		user, err := b.upsertUser(c.Sender)
+		if err != nil {
+			log.Warnf("error upserting telegram user[%d]: %s", c.Sender.ID, err)
+			b.Respond(c, &tb.CallbackResponse{
+				Text: catalogs.T(langOf(&model.User{}, c.Sender.LanguageCode), replyFailedGettingData),
+			})
+			return
+		}
+		if user.IsBanned() {
+			b.Respond(c, &tb.CallbackResponse{Text: catalogs.T(langOf(user, c.Sender.LanguageCode), replyBanned)})
+			return
+		}
+		handler(&callback{
+			Callback:   c,
+			SenderUser: user,
+			Lang:       langOf(user, c.Sender.LanguageCode),
+		})
+	}
 }
@@ -0,0 +1,229 @@
+package bot
+
+import "github.com/awesome-memobird/the-memobird-bot/i18n"
+
+var catalogEN = i18n.Catalog{
+	replyFailedGettingData: {Other: "I'm having trouble getting your data, please try again in a moment."},
+	replyMetBefore:         {Other: "Hi {name}, we've met before."},
+	replyNiceToMeetYou:     {Other: "Hello {name}, nice to meet you!"},
+	replyCheckMemobirdID:   {Other: "Please check the Memobird ID provided."},
+	replyBindHelp:          {Other: "Please use /bind [YourMemobirdID] to bind a Memobird before sending anything for printing"},
+	replyVerificationInstruction: {Other: `To complete the verification
+please send:
+    /verify {code}
+to:
+    @{bot}
+`},
+	replyFailedSendingVerification: {Other: "I'm having trouble sending you a verification code, please check the Memobird ID provided or try again in a moment."},
+	replyVerificationSent:          {Other: "A verification code with instructions was sent to your device, please follow it to complete the binding."},
+	replyBindComplete:              {Other: "Device binding complete!"},
+	replyVerificationFailed:        {Other: "Verification failed, please check the code or try again in a moment."},
+	replyFailedSendingMessage:      {Other: "Error sending your message: {error}"},
+	replySentPrinted:               {Other: "- Sent: {sent}\n- Printed: {printed}"},
+	replySent:                      {Other: "Sent"},
+	replySentFailure:               {Other: "The message failed to deliver"},
+
+	replyHistoryEmpty:     {Other: "Nothing has been printed yet."},
+	replyHistoryPage:      {Other: "Printed contents {from}-{to} of {total}:\n"},
+	replyHistoryImage:     {Other: "[image]"},
+	replyHistoryReprint:   {Other: "Reprint #{id}"},
+	replyHistoryForget:    {Other: "Forget #{id}"},
+	replyHistoryNewer:     {Other: "« Newer"},
+	replyHistoryOlder:     {Other: "Older »"},
+	replyHistoryNotFound:  {Other: "The content no longer exists."},
+	replyHistoryForgotten: {Other: "Forgotten"},
+	replyRetention: {
+		One:   "Printed contents are kept for {count} day, use /retention [days] to change it, 0 to keep them forever.",
+		Other: "Printed contents are kept for {count} days, use /retention [days] to change it, 0 to keep them forever.",
+	},
+	replyRetentionForever: {Other: "Printed contents are kept forever, use /retention [days] to change it."},
+	replyRetentionHelp:    {Other: "Please use /retention [days] to set how long printed contents are kept, 0 to keep them forever."},
+
+	replyNeedVerifiedDevice:     {Other: "Please bind and verify your Memobird first."},
+	replyShareHelp:              {Other: "Please use /share @username to share your Memobird with someone who has talked to me."},
+	replyShareUserNotFound:      {Other: "I don't know @{user} yet, please ask them to say hi to me first."},
+	replyShared:                 {Other: "Your Memobird is now shared with @{user}."},
+	replySharedWithYou:          {Other: "{name} shared a Memobird with you, send me anything to print on it."},
+	replySharesNone:             {Other: "Your Memobird is not shared with anyone, use /share @username to share it."},
+	replySharesHeader:           {Other: "Your Memobird is shared with:"},
+	replyUnshared:               {Other: "Your Memobird is no longer shared with @{user}."},
+	replyNotShared:              {Other: "Your Memobird is not shared with @{user}."},
+	replyLimitHelp:              {Other: "Please use /limit [messages per hour] [characters per day] to limit your Memobird, or /limit @username [messages per hour] [characters per day] to limit someone you share with. 0 means the default, while unlimited lifts it."},
+	replyLimits:                 {Other: "Limits of your Memobird: {device}\nDefault limits of everyone: {user}\nUse /limit to change them."},
+	replyLimitSet:               {Other: "Limits updated."},
+	replyQuotaMessages:          {One: "{count} message per hour", Other: "{count} messages per hour"},
+	replyQuotaMessagesUnlimited: {Other: "unlimited messages per hour"},
+	replyQuotaChars:             {One: "{count} character per day", Other: "{count} characters per day"},
+	replyQuotaCharsUnlimited:    {Other: "unlimited characters per day"},
+	replyQuotaExceeded:          {Other: "You have used up your printing quota, please try again in {wait}."},
+	replyDeviceQuotaExceeded:    {Other: "The Memobird has used up its printing quota, please try again in {wait}."},
+	replyQuotaTooLong:           {One: "The message exceeds the daily quota of {count} character.", Other: "The message exceeds the daily quota of {count} characters."},
+
+	replyLangName:    {Other: "English"},
+	replyLangCurrent: {Other: "I'm speaking {lang} with you."},
+	replyLangAuto:    {Other: "I'll follow the language of your Telegram app."},
+	replyLangSet:     {Other: "I'll speak {lang} with you from now on."},
+	replyLangHelp:    {Other: "Please use /lang [{langs}] to choose a language, or /lang auto to follow your Telegram app."},
+
+	replyYes:                         {Other: "yes"},
+	replyNo:                          {Other: "no"},
+	replyNone:                        {Other: "none"},
+	replyBanned:                      {Other: "Sorry, you are not allowed to use this bot."},
+	replyAdminOnly:                   {Other: "This command is for administrators only."},
+	replyStats:                       {One: "Users: {users} ({active} active in 24 hours)\nVerified devices: {devices}\nPrints of today:\n{prints}\nFailure rate: {rate}", Other: "Users: {users} ({active} active in 24 hours)\nVerified devices: {devices}\nPrints of the last {count} days:\n{prints}\nFailure rate: {rate}"},
+	replyStatsDay:                    {Other: "{day}: {total} ({failed} failed)"},
+	replyBroadcastHelp:               {Other: "Please use /broadcast [message] to send a message to all users."},
+	replyBroadcastStarted:            {One: "Broadcasting to {count} user...", Other: "Broadcasting to {count} users..."},
+	replyBroadcastDone:               {Other: "Broadcast delivered to {sent} of {total} users."},
+	replyBanHelp:                     {Other: "Please use /ban [TelegramID|@username] or /unban [TelegramID|@username]."},
+	replyCannotBanAdmin:              {Other: "Administrators can not be banned."},
+	replyUserBanned:                  {Other: "{user} is banned."},
+	replyUserUnbanned:                {Other: "{user} is no longer banned."},
+	replyUserHelp:                    {Other: "Please use /user [TelegramID|@username] to look up a user."},
+	replyUserNotFound:                {Other: "No such user."},
+	replyUserInfo:                    {Other: "{name} (@{username}, Telegram ID {id})\nJoined: {joined}\nBanned: {banned}\nDevices:\n{devices}\nRecent errors:\n{errors}"},
+	replyUserDevice:                  {Other: "- #{id} {memobird}, verified: {verified}"},
+	replyShuttingDown:                {Other: "I'm restarting, please try again in a minute."},
+	replyFeatureDisabled:             {Other: "This feature is disabled."},
+	replyWebhookHelp:                 {Other: "Please use:\n/webhook new [{kinds}] to create a webhook printing to your device\n/webhook list to list your webhooks\n/webhook template [id] [Go template] to render generic JSON payloads\n/webhook delete [id] to delete a webhook"},
+	replyWebhooksHeader:              {Other: "Your webhooks:"},
+	replyWebhookCreated:              {Other: "Webhook #{id} ({kind}) is created, please POST payloads to:\n{url}\nKeep the URL secret, anyone who knows it can print to your device."},
+	replyWebhookTemplateNeeded:       {Other: "Please set its template with /webhook template {id} [Go template], e.g. {example}"},
+	replyWebhookSigningSecret:        {Other: "Please set the secret of the webhook to {secret}, payloads not signed by it are rejected."},
+	replyWebhookNoPublicURL:          {Other: "Webhooks are unavailable as the public URL of the bot is not configured, please ask its admin to set it."},
+	replyWebhookNotFound:             {Other: "No such webhook."},
+	replyWebhookDeleted:              {Other: "Webhook #{id} is deleted."},
+	replyWebhookInvalidTemplate:      {Other: "Invalid template: {error}"},
+	replyWebhookTemplateSet:          {Other: "The template of webhook #{id} is set."},
+	replyTemplateHelp:                {Other: "Please use:\n/template show to show the active template\n/template set [preset|Go template] to wrap what you print\n/template set device [preset|Go template] to wrap everything printed to your device, which wins over the templates of those you share it with\n/template reset [device] to stop wrapping\nPresets: {presets}\nTemplates can use .Sender, .Time, .Device and .Body."},
+	replyTemplateShow:                {Other: "Active template ({source}):\n{template}\n\nPresets: {presets}"},
+	replyTemplateSourceUser:          {Other: "yours"},
+	replyTemplateSourceDevice:        {Other: "the device's"},
+	replyTemplateSourceDefault:       {Other: "default"},
+	replyTemplateInvalid:             {Other: "Invalid template: {error}"},
+	replyTemplateTooLong:             {One: "no more than {count} character", Other: "no more than {count} characters"},
+	replyTemplateSet:                 {Other: "Template is set."},
+	replyTemplateReset:               {Other: "Template is reset."},
+	replyDeviceHelp:                  {Other: "Please use:\n/device to show your device\n/device name [nickname] to name your device\n/device tz [time zone] to set the time zone of your device, e.g. Europe/Berlin"},
+	replyDeviceInfo:                  {Other: "Memobird ID: {memobird}\nNickname: {nickname}\nTime zone: {timezone}"},
+	replyDeviceNicknameSet:           {Other: "Your device is now named {nickname}."},
+	replyDeviceTimezoneSet:           {Other: "The time zone of your device is now {timezone}."},
+	replyDeviceTimezoneInvalid:       {Other: "Unknown time zone {timezone}, please use a name like Asia/Shanghai."},
+	replyForwardHiddenSender:         {Other: "Hidden user"},
+	replyMediaPhoto:                  {Other: "[Photo]"},
+	replyMediaVideo:                  {Other: "[Video]"},
+	replyChannelHelp:                 {Other: "Add me to your channel as an administrator, then use:\n/channel link [@channel] [@owner] to print its posts on your device or the device of the owner, who needs to approve\n/channel list to list linked channels\n/channel tags [id] [#hashtag ...] to print only posts with any of the hashtags, all posts without hashtags\n/channel photos [id] on|off to print photo posts or text only\n/channel digest [id] [hours|off] to print posts in a digest every few hours\n/channel unlink [id] to stop printing"},
+	replyChannelsNone:                {Other: "No channel is linked, use /channel link [@channel] to link one."},
+	replyChannelsHeader:              {Other: "Linked channels:"},
+	replyChannelItem:                 {Other: "#{id} {channel} → {device}: {status}, {hashtags}, {photos}, {digest}"},
+	replyChannelStatusPending:        {Other: "waiting for approval"},
+	replyChannelStatusApproved:       {Other: "approved"},
+	replyChannelAllPosts:             {Other: "all posts"},
+	replyChannelTextOnly:             {Other: "text only"},
+	replyChannelWithPhotos:           {Other: "with photos"},
+	replyChannelDigestOff:            {Other: "printed as posted"},
+	replyChannelDigestEvery:          {One: "a digest every hour", Other: "a digest every {count} hours"},
+	replyChannelNotFound:             {Other: "Channel {channel} is not found, please use its @username or ID."},
+	replyChannelNeedAdmin:            {Other: "Please add @{bot} to the channel as an administrator first."},
+	replyChannelNotAdmin:             {Other: "Only administrators of {channel} can link it."},
+	replyChannelDeviceNotFound:       {Other: "@{user} has no verified Memobird."},
+	replyChannelLinked:               {Other: "Posts of {channel} will be printed, see /channel to change subscription #{id}."},
+	replyChannelPending:              {Other: "Waiting for {user} to approve printing posts of {channel}."},
+	replyChannelApproval:             {Other: "{user} would like to print posts of the channel {channel} on your Memobird."},
+	replyChannelApprove:              {Other: "Approve"},
+	replyChannelReject:               {Other: "Reject"},
+	replyChannelApproved:             {Other: "Posts of {channel} will be printed on {device}."},
+	replyChannelRejected:             {Other: "Posts of {channel} will not be printed on {device}."},
+	replyChannelSubscriptionNotFound: {Other: "No such channel subscription."},
+	replyChannelUnlinked:             {Other: "Posts of {channel} will no longer be printed."},
+	replyChannelUpdated:              {Other: "Channel subscription #{id} is updated."},
+	replyChannelDigestHeader:         {Other: "Digest of {channel}"},
+	replyChannelPostEdited:           {Other: "edited"},
+	replyFeedHelp:                    {Other: "Please use:\n/subscribe [feed URL] to print new items of an RSS or Atom feed\n/subscribe [feed URL] [HH:MM] to print them in a daily digest instead\n/subscriptions to list your feeds\n/unsubscribe [id] to stop printing a feed"},
+	replyFeedTooMany:                 {One: "You can subscribe to no more than {count} feed.", Other: "You can subscribe to no more than {count} feeds."},
+	replyFeedUnreachable:             {Other: "The feed is unreachable, please check the URL."},
+	replyFeedSubscribed:              {Other: "Subscribed to {feed} as #{id}, new items will be printed {schedule}."},
+	replyFeedInstantly:               {Other: "as they come"},
+	replyFeedDaily:                   {Other: "daily at {time}"},
+	replyFeedsNone:                   {Other: "You have no subscriptions, use /subscribe [feed URL] to add one."},
+	replyFeedsHeader:                 {Other: "Your subscriptions:"},
+	replyFeedNotFound:                {Other: "No such subscription."},
+	replyFeedUnsubscribed:            {Other: "Subscription #{id} is removed."},
+	replyTodoHelp:                    {Other: "Please use:\n/todo add [#list] [item] to add an item, to the list named todo if no #list is given\n/todo list [#list] to show the items\n/todo done [#list] [n] to check off item n, /todo undo to uncheck it\n/todo print [#list] to print the items left as a checklist\n/todo clear [#list] to remove the checked off items\n/todo morning [#list] [HH:MM|off] to print the list every morning\n/todo delete [#list] to delete the list\n/todo lists to show your lists"},
+	replyTodoListsNone:               {Other: "You have no lists, use /todo add [item] to start one."},
+	replyTodoListsHeader:             {Other: "Your lists:"},
+	replyTodoOpenItems:               {One: "{count} item left", Other: "{count} items left"},
+	replyTodoMorningAt:               {Other: "printed daily at {time}"},
+	replyTodoItemTooLong:             {One: "An item can have no more than {count} character.", Other: "An item can have no more than {count} characters."},
+	replyTodoTooManyLists:            {One: "You can have no more than {count} list.", Other: "You can have no more than {count} lists."},
+	replyTodoTooManyItems:            {One: "A list can have no more than {count} item, use /todo clear to remove the checked off ones.", Other: "A list can have no more than {count} items, use /todo clear to remove the checked off ones."},
+	replyTodoAdded:                   {Other: "Added to #{list} as item {n}."},
+	replyTodoEmpty:                   {Other: "#{list} is empty."},
+	replyTodoListNotFound:            {Other: "You have no list named #{list}, see /todo lists."},
+	replyTodoItemNotFound:            {Other: "No such item in #{list}, see /todo list #{list}."},
+	replyTodoNothingToPrint:          {Other: "Nothing left to do in #{list}."},
+	replyTodoCleared:                 {One: "Removed {count} checked off item from #{list}.", Other: "Removed {count} checked off items from #{list}."},
+	replyTodoDeleted:                 {Other: "#{list} is deleted."},
+	replyTodoMorningOff:              {Other: "#{list} will no longer be printed every morning."},
+	replyTodoMorningSet:              {Other: "#{list} will be printed daily at {time} if anything is left to do."},
+	replyTodoSlipTitle:               {Other: "To-do: {list}"},
+	replyDraftStarted:                {Other: "Drafting, texts, photos and forwards you send from now on are collected instead of printed.\n/preview to see the draft\n/print to print it at once\n/discard to drop it"},
+	replyDraftAlready:                {One: "You are drafting already, the draft has {count} part.", Other: "You are drafting already, the draft has {count} parts."},
+	replyDraftNone:                   {Other: "You have no draft, use /draft to start one."},
+	replyDraftEmpty:                  {Other: "The draft is empty, send texts, photos or forwards to add to it."},
+	replyDraftFull:                   {One: "A draft can have no more than {count} part, please /print it first.", Other: "A draft can have no more than {count} parts, please /print it first."},
+	replyDraftAdded:                  {One: "Added to the draft, {count} part so far.", Other: "Added to the draft, {count} parts so far."},
+	replyDraftPreview:                {One: "The draft has {count} part.", Other: "The draft has {count} parts."},
+	replyDraftFailed:                 {Other: "Failed to prepare the draft: {error}"},
+	replyDraftDiscarded:              {Other: "The draft is discarded."},
+	replyDraftExpired:                {Other: "Your draft is discarded after being idle for {idle}."},
+	replyPreviewHelp:                 {Other: "Please use /preview [text] to see how the text would be printed, or /draft to compose a print and /preview it."},
+	replyPreviewFailed:               {Other: "Failed to render the preview: {error}"},
+	replyPreviewWide:                 {Other: "Previews can't show Chinese, Japanese or Korean characters yet, they are printed as usual though."},
+	replyInlinePrintTo:               {Other: "Print to {device}"},
+	replyInlineBindFirst:             {Other: "Bind a Memobird to print from here"},
+	replyInlinePrinted:               {Other: "Printing \"{text}\" to {device}: {result}"},
+	replyLocationTitle:               {Other: "Location"},
+	replyLocationFailed:              {Other: "Failed to prepare the location: {error}"},
+	replyContactPhone:                {Other: "Phone: {phone}"},
+	replyContactTelegram:             {Other: "Telegram: @{username}"},
+	replyContactFailed:               {Other: "Failed to prepare the contact: {error}"},
+	replySettings:                    {Other: "Your settings:\n{settings}\n\nUse /settings [name] [value] to change one."},
+	replySettingLine:                 {Other: "- {name}: {value} ({values})\n  {description}"},
+	replySettingContact:              {Other: "Layout of the contact cards printed, compact puts the name and phone on one line."},
+	replySettingUnknown:              {Other: "Unknown setting, please use one of {names}."},
+	replySettingInvalid:              {Other: "Please use /settings {name} [{values}]."},
+	replySettingSet:                  {Other: "{name} is set to {value}."},
+	replyDocumentUnsupported:         {Other: "Only text documents (.txt, .md, .csv and .log) can be printed."},
+	replyDocumentTooLarge:            {Other: "The document is too large, documents up to {max} can be printed."},
+	replyDocumentFailed:              {Other: "Failed to prepare the document: {error}"},
+	replyDocumentEncoding:            {Other: "The document is not in UTF-8, GBK, or UTF-16 with a BOM."},
+	replyDocumentEmpty:               {Other: "The document has nothing to print."},
+	replyDocumentConfirm:             {Other: "{name} takes about {length} of paper and will be printed in {count} parts, print it?"},
+	replyDocumentPrint:               {Other: "Print"},
+	replyDocumentCancel:              {Other: "Cancel"},
+	replyDocumentGone:                {Other: "The document no longer exists, please send it again."},
+	replyDocumentPrinting:            {Other: "Printing..."},
+	replyDocumentPartFailed:          {Other: "Part {part} of {count} failed, the rest is not printed: {error}"},
+	replyDocumentPrinted:             {Other: "All {count} parts of the document are printed."},
+	replyDocumentCancelled:           {Other: "Cancelled."},
+	replyStickerFailed:               {Other: "Failed to prepare the sticker: {error}"},
+	replySettingStickerCaption:       {Other: "Whether stickers are printed with short names of their emoji, the ones without a name are omitted."},
+	replyQueued:                      {One: "The message will be printed in {count} second, edit it or reply /cancel to it before then to change your mind.", Other: "The message will be printed in {count} seconds, edit it or reply /cancel to it before then to change your mind."},
+	replyEditQueued:                  {Other: "The corrected message will be printed instead."},
+	replyEditPrinted:                 {Other: "This message was sent to print already. Reprint the corrected version?"},
+	replyEditReprint:                 {Other: "Reprint"},
+	replyEditReprinting:              {Other: "Reprinting..."},
+	replyEditGone:                    {Other: "The message no longer exists."},
+	replyCancelHelp:                  {Other: "Please reply /cancel to a message waiting to be printed to cancel it."},
+	replyCancelled:                   {Other: "Cancelled, the message won't be printed."},
+	replyCancelTooLate:               {Other: "The message was sent to print already and can't be cancelled."},
+	replyExportFailed:                {Other: "Failed to export your data, please try again later."},
+	replyExportCaption:               {Other: "Everything stored about you, including your devices and printed contents."},
+	replyDeleteMeConfirm:             {Other: "This permanently deletes everything stored about you: your profile, printed contents, to-do lists, drafts and subscriptions. Your devices will be unbound, and shares and webhooks of them revoked. It can't be undone, you may /export your data first."},
+	replyDeleteMe:                    {Other: "Delete everything"},
+	replyDeleteMeCancel:              {Other: "Keep my data"},
+	replyDeleteMeCancelled:           {Other: "Nothing was deleted."},
+	replyDeleteMeDone:                {Other: "Everything stored about you was deleted. Sending another message starts over as a new user."},
+	replyDeleteMeFailed:              {Other: "Failed to delete your data, please try again later."},
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+)
+
+func sortedPlaceholders(msg i18n.Message) []string {
+	names := append(i18n.Placeholders(msg.One), i18n.Placeholders(msg.Other)...)
+	set := map[string]bool{}
+	for _, n := range names {
+		set[n] = true
+	}
+	names = names[:0]
+	for n := range set {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, msg := range catalogs[i18n.Default] {
+			translated, ok := catalog[key]
+			if !assert.True(t, ok, "%s is missing in %s", key, lang) {
+				continue
+			}
+			assert.NotEmpty(t, translated.Other, "%s of %s", key, lang)
+			assert.Equal(t, sortedPlaceholders(msg), sortedPlaceholders(translated), "%s of %s", key, lang)
+		}
+		for key := range catalog {
+			_, ok := catalogs[i18n.Default][key]
+			assert.True(t, ok, "%s of %s is missing in default", key, lang)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package bot
+
+import "github.com/awesome-memobird/the-memobird-bot/i18n"
+
+var catalogZH = i18n.Catalog{
+	replyFailedGettingData: {Other: "获取数据时出了点问题，请稍后再试。"},
+	replyMetBefore:         {Other: "{name}，我们见过面了。"},
+	replyNiceToMeetYou:     {Other: "{name}，你好，很高兴认识你！"},
+	replyCheckMemobirdID:   {Other: "请检查提供的咕咕机 ID。"},
+	replyBindHelp:          {Other: "请先使用 /bind [咕咕机ID] 绑定一台咕咕机，然后再发送要打印的内容"},
+	replyVerificationInstruction: {Other: `完成验证请发送:
+    /verify {code}
+给:
+    @{bot}
+`},
+	replyFailedSendingVerification: {Other: "发送验证码时出了点问题，请检查提供的咕咕机 ID 或稍后再试。"},
+	replyVerificationSent:          {Other: "验证码和说明已发送到你的咕咕机，请按照说明完成绑定。"},
+	replyBindComplete:              {Other: "咕咕机绑定成功！"},
+	replyVerificationFailed:        {Other: "验证失败，请检查验证码或稍后再试。"},
+	replyFailedSendingMessage:      {Other: "发送消息出错: {error}"},
+	replySentPrinted:               {Other: "- 已发送: {sent}\n- 已打印: {printed}"},
+	replySent:                      {Other: "已发送"},
+	replySentFailure:               {Other: "消息发送失败"},
+
+	replyHistoryEmpty:     {Other: "还没有打印过任何内容。"},
+	replyHistoryPage:      {Other: "打印记录 {from}-{to}，共 {total} 条:\n"},
+	replyHistoryImage:     {Other: "[图片]"},
+	replyHistoryReprint:   {Other: "重新打印 #{id}"},
+	replyHistoryForget:    {Other: "删除 #{id}"},
+	replyHistoryNewer:     {Other: "« 较新"},
+	replyHistoryOlder:     {Other: "较早 »"},
+	replyHistoryNotFound:  {Other: "该内容已不存在。"},
+	replyHistoryForgotten: {Other: "已删除"},
+	replyRetention:        {Other: "打印记录保留 {count} 天，使用 /retention [天数] 修改，0 表示永久保留。"},
+	replyRetentionForever: {Other: "打印记录永久保留，使用 /retention [天数] 修改。"},
+	replyRetentionHelp:    {Other: "请使用 /retention [天数] 设置打印记录的保留时间，0 表示永久保留。"},
+
+	replyNeedVerifiedDevice:     {Other: "请先绑定并验证你的咕咕机。"},
+	replyShareHelp:              {Other: "请使用 /share @用户名 把你的咕咕机共享给和我聊过天的人。"},
+	replyShareUserNotFound:      {Other: "我还不认识 @{user}，请让对方先和我打个招呼。"},
+	replyShared:                 {Other: "你的咕咕机已共享给 @{user}。"},
+	replySharedWithYou:          {Other: "{name} 与你共享了一台咕咕机，发给我任何内容即可打印。"},
+	replySharesNone:             {Other: "你的咕咕机还没有共享给任何人，使用 /share @用户名 共享。"},
+	replySharesHeader:           {Other: "你的咕咕机已共享给:"},
+	replyUnshared:               {Other: "你的咕咕机已不再共享给 @{user}。"},
+	replyNotShared:              {Other: "你的咕咕机没有共享给 @{user}。"},
+	replyLimitHelp:              {Other: "请使用 /limit [每小时消息数] [每天字数] 限制你的咕咕机，或使用 /limit @用户名 [每小时消息数] [每天字数] 限制共享对象。0 表示默认值，unlimited 表示不限。"},
+	replyLimits:                 {Other: "你的咕咕机的限额: {device}\n每个人的默认限额: {user}\n使用 /limit 修改。"},
+	replyLimitSet:               {Other: "限额已更新。"},
+	replyQuotaMessages:          {Other: "每小时 {count} 条消息"},
+	replyQuotaMessagesUnlimited: {Other: "每小时消息数不限"},
+	replyQuotaChars:             {Other: "每天 {count} 个字"},
+	replyQuotaCharsUnlimited:    {Other: "每天字数不限"},
+	replyQuotaExceeded:          {Other: "你的打印限额已用完，请在 {wait} 后再试。"},
+	replyDeviceQuotaExceeded:    {Other: "这台咕咕机的打印限额已用完，请在 {wait} 后再试。"},
+	replyQuotaTooLong:           {Other: "消息超过了每天 {count} 个字的限额。"},
+
+	replyLangName:    {Other: "简体中文"},
+	replyLangCurrent: {Other: "我正在用{lang}和你交流。"},
+	replyLangAuto:    {Other: "我会跟随你的 Telegram 应用的语言。"},
+	replyLangSet:     {Other: "从现在起我会用{lang}和你交流。"},
+	replyLangHelp:    {Other: "请使用 /lang [{langs}] 选择语言，或使用 /lang auto 跟随 Telegram 应用。"},
+
+	replyYes:                         {Other: "是"},
+	replyNo:                          {Other: "否"},
+	replyNone:                        {Other: "无"},
+	replyBanned:                      {Other: "抱歉，你已被禁止使用此机器人。"},
+	replyAdminOnly:                   {Other: "此命令仅限管理员使用。"},
+	replyStats:                       {Other: "用户数: {users} (24 小时内活跃 {active})\n已验证的咕咕机: {devices}\n最近 {count} 天的打印:\n{prints}\n失败率: {rate}"},
+	replyStatsDay:                    {Other: "{day}: {total} (失败 {failed})"},
+	replyBroadcastHelp:               {Other: "请使用 /broadcast [消息] 向所有用户发送消息。"},
+	replyBroadcastStarted:            {Other: "正在向 {count} 位用户广播..."},
+	replyBroadcastDone:               {Other: "广播已送达 {sent}/{total} 位用户。"},
+	replyBanHelp:                     {Other: "请使用 /ban [Telegram ID|@用户名] 或 /unban [Telegram ID|@用户名]。"},
+	replyCannotBanAdmin:              {Other: "不能禁止管理员。"},
+	replyUserBanned:                  {Other: "已禁止 {user}。"},
+	replyUserUnbanned:                {Other: "已解除对 {user} 的禁止。"},
+	replyUserHelp:                    {Other: "请使用 /user [Telegram ID|@用户名] 查询用户。"},
+	replyUserNotFound:                {Other: "没有这个用户。"},
+	replyUserInfo:                    {Other: "{name} (@{username}, Telegram ID {id})\n加入时间: {joined}\n已禁止: {banned}\n咕咕机:\n{devices}\n最近的错误:\n{errors}"},
+	replyUserDevice:                  {Other: "- #{id} {memobird}，已验证: {verified}"},
+	replyShuttingDown:                {Other: "我正在重启，请稍后再试。"},
+	replyFeatureDisabled:             {Other: "此功能已被禁用。"},
+	replyWebhookHelp:                 {Other: "请使用:\n/webhook new [{kinds}] 创建打印到你的咕咕机的 Webhook\n/webhook list 列出你的 Webhook\n/webhook template [编号] [Go 模板] 设置通用 JSON 的渲染模板\n/webhook delete [编号] 删除 Webhook"},
+	replyWebhooksHeader:              {Other: "你的 Webhook:"},
+	replyWebhookCreated:              {Other: "Webhook #{id} ({kind}) 已创建，请将内容 POST 到:\n{url}\n请妥善保管此地址，任何知道它的人都能打印到你的咕咕机。"},
+	replyWebhookTemplateNeeded:       {Other: "请使用 /webhook template {id} [Go 模板] 设置模板，例如 {example}"},
+	replyWebhookSigningSecret:        {Other: "请将 Webhook 的 Secret 设置为 {secret}，未用它签名的内容会被拒绝。"},
+	replyWebhookNoPublicURL:          {Other: "机器人未配置公开访问地址，暂时无法使用 Webhook，请联系管理员设置。"},
+	replyWebhookNotFound:             {Other: "没有这个 Webhook。"},
+	replyWebhookDeleted:              {Other: "Webhook #{id} 已删除。"},
+	replyWebhookInvalidTemplate:      {Other: "模板无效: {error}"},
+	replyWebhookTemplateSet:          {Other: "Webhook #{id} 的模板已设置。"},
+	replyTemplateHelp:                {Other: "请使用:\n/template show 查看当前模板\n/template set [预设|Go 模板] 设置你的打印模板\n/template set device [预设|Go 模板] 设置咕咕机的打印模板，共享给他人时优先于他们的模板\n/template reset [device] 取消模板\n预设: {presets}\n模板中可以使用 .Sender、.Time、.Device 和 .Body。"},
+	replyTemplateShow:                {Other: "当前模板 ({source}):\n{template}\n\n预设: {presets}"},
+	replyTemplateSourceUser:          {Other: "你的"},
+	replyTemplateSourceDevice:        {Other: "咕咕机的"},
+	replyTemplateSourceDefault:       {Other: "默认"},
+	replyTemplateInvalid:             {Other: "模板无效: {error}"},
+	replyTemplateTooLong:             {Other: "不能超过 {count} 个字符"},
+	replyTemplateSet:                 {Other: "模板已设置。"},
+	replyTemplateReset:               {Other: "模板已取消。"},
+	replyDeviceHelp:                  {Other: "请使用:\n/device 查看你的咕咕机\n/device name [昵称] 为咕咕机取名\n/device tz [时区] 设置咕咕机所在的时区，例如 Asia/Shanghai"},
+	replyDeviceInfo:                  {Other: "咕咕号: {memobird}\n昵称: {nickname}\n时区: {timezone}"},
+	replyDeviceNicknameSet:           {Other: "你的咕咕机现在叫 {nickname}。"},
+	replyDeviceTimezoneSet:           {Other: "你的咕咕机的时区已设为 {timezone}。"},
+	replyDeviceTimezoneInvalid:       {Other: "未知的时区 {timezone}，请使用类似 Asia/Shanghai 的名称。"},
+	replyForwardHiddenSender:         {Other: "隐藏的用户"},
+	replyMediaPhoto:                  {Other: "[图片]"},
+	replyMediaVideo:                  {Other: "[视频]"},
+	replyChannelHelp:                 {Other: "请先将我添加为频道管理员，然后使用:\n/channel link [@频道] [@咕咕机主人] 在你或主人的咕咕机上打印频道消息，需要主人同意\n/channel list 查看关联的频道\n/channel tags [编号] [#话题 ...] 只打印带有这些话题的消息，不填则打印全部\n/channel photos [编号] on|off 打印图片消息或只打印文字\n/channel digest [编号] [小时|off] 每隔几小时汇总打印\n/channel unlink [编号] 停止打印"},
+	replyChannelsNone:                {Other: "还没有关联的频道，使用 /channel link [@频道] 关联一个。"},
+	replyChannelsHeader:              {Other: "关联的频道:"},
+	replyChannelItem:                 {Other: "#{id} {channel} → {device}: {status}，{hashtags}，{photos}，{digest}"},
+	replyChannelStatusPending:        {Other: "等待同意"},
+	replyChannelStatusApproved:       {Other: "已同意"},
+	replyChannelAllPosts:             {Other: "全部消息"},
+	replyChannelTextOnly:             {Other: "只打印文字"},
+	replyChannelWithPhotos:           {Other: "包括图片"},
+	replyChannelDigestOff:            {Other: "即时打印"},
+	replyChannelDigestEvery:          {Other: "每 {count} 小时汇总打印"},
+	replyChannelNotFound:             {Other: "找不到频道 {channel}，请使用它的 @用户名 或 ID。"},
+	replyChannelNeedAdmin:            {Other: "请先将 @{bot} 添加为频道管理员。"},
+	replyChannelNotAdmin:             {Other: "只有 {channel} 的管理员才能关联它。"},
+	replyChannelDeviceNotFound:       {Other: "@{user} 没有已验证的咕咕机。"},
+	replyChannelLinked:               {Other: "{channel} 的消息将会被打印，使用 /channel 修改订阅 #{id}。"},
+	replyChannelPending:              {Other: "正在等待 {user} 同意打印 {channel} 的消息。"},
+	replyChannelApproval:             {Other: "{user} 想在你的咕咕机上打印频道 {channel} 的消息。"},
+	replyChannelApprove:              {Other: "同意"},
+	replyChannelReject:               {Other: "拒绝"},
+	replyChannelApproved:             {Other: "{channel} 的消息将会在 {device} 上打印。"},
+	replyChannelRejected:             {Other: "{channel} 的消息不会在 {device} 上打印。"},
+	replyChannelSubscriptionNotFound: {Other: "没有这个频道订阅。"},
+	replyChannelUnlinked:             {Other: "{channel} 的消息不会再被打印。"},
+	replyChannelUpdated:              {Other: "频道订阅 #{id} 已更新。"},
+	replyChannelDigestHeader:         {Other: "{channel} 汇总"},
+	replyChannelPostEdited:           {Other: "已编辑"},
+	replyFeedHelp:                    {Other: "请使用:\n/subscribe [订阅地址] 打印 RSS 或 Atom 订阅的新内容\n/subscribe [订阅地址] [HH:MM] 改为每天定时汇总打印\n/subscriptions 查看你的订阅\n/unsubscribe [编号] 取消订阅"},
+	replyFeedTooMany:                 {Other: "最多只能订阅 {count} 个源。"},
+	replyFeedUnreachable:             {Other: "无法访问该订阅源，请检查链接。"},
+	replyFeedSubscribed:              {Other: "已订阅 {feed}，编号 #{id}，新内容将{schedule}打印。"},
+	replyFeedInstantly:               {Other: "即时"},
+	replyFeedDaily:                   {Other: "在每天 {time} "},
+	replyFeedsNone:                   {Other: "你还没有订阅，使用 /subscribe [订阅地址] 添加一个。"},
+	replyFeedsHeader:                 {Other: "你的订阅:"},
+	replyFeedNotFound:                {Other: "没有这个订阅。"},
+	replyFeedUnsubscribed:            {Other: "订阅 #{id} 已取消。"},
+	replyTodoHelp:                    {Other: "请使用:\n/todo add [#清单] [事项] 添加事项，不指定 #清单 时添加到名为 todo 的清单\n/todo list [#清单] 查看事项\n/todo done [#清单] [序号] 勾选事项，/todo undo 取消勾选\n/todo print [#清单] 将未完成的事项打印为清单\n/todo clear [#清单] 移除已勾选的事项\n/todo morning [#清单] [HH:MM|off] 每天早上打印清单\n/todo delete [#清单] 删除清单\n/todo lists 查看你的清单"},
+	replyTodoListsNone:               {Other: "你还没有清单，使用 /todo add [事项] 创建一个。"},
+	replyTodoListsHeader:             {Other: "你的清单:"},
+	replyTodoOpenItems:               {Other: "剩余 {count} 项"},
+	replyTodoMorningAt:               {Other: "每天 {time} 打印"},
+	replyTodoItemTooLong:             {Other: "事项最多 {count} 个字。"},
+	replyTodoTooManyLists:            {Other: "最多只能有 {count} 个清单。"},
+	replyTodoTooManyItems:            {Other: "清单最多只能有 {count} 项，使用 /todo clear 移除已勾选的事项。"},
+	replyTodoAdded:                   {Other: "已添加到 #{list}，序号 {n}。"},
+	replyTodoEmpty:                   {Other: "#{list} 是空的。"},
+	replyTodoListNotFound:            {Other: "没有名为 #{list} 的清单，请查看 /todo lists。"},
+	replyTodoItemNotFound:            {Other: "#{list} 中没有这一项，请查看 /todo list #{list}。"},
+	replyTodoNothingToPrint:          {Other: "#{list} 中的事项都已完成。"},
+	replyTodoCleared:                 {Other: "已从 #{list} 移除 {count} 个已勾选的事项。"},
+	replyTodoDeleted:                 {Other: "#{list} 已删除。"},
+	replyTodoMorningOff:              {Other: "#{list} 将不再每天早上打印。"},
+	replyTodoMorningSet:              {Other: "如有未完成的事项，#{list} 将在每天 {time} 打印。"},
+	replyTodoSlipTitle:               {Other: "待办: {list}"},
+	replyDraftStarted:                {Other: "开始草稿，之后发送的文字、图片和转发将被收集而不是立即打印。\n/preview 查看草稿\n/print 一次性打印\n/discard 丢弃草稿"},
+	replyDraftAlready:                {Other: "已经在草稿中了，草稿有 {count} 部分。"},
+	replyDraftNone:                   {Other: "你没有草稿，使用 /draft 开始一个。"},
+	replyDraftEmpty:                  {Other: "草稿是空的，发送文字、图片或转发来添加内容。"},
+	replyDraftFull:                   {Other: "草稿最多只能有 {count} 部分，请先 /print 打印。"},
+	replyDraftAdded:                  {Other: "已添加到草稿，目前共 {count} 部分。"},
+	replyDraftPreview:                {Other: "草稿共 {count} 部分。"},
+	replyDraftFailed:                 {Other: "准备草稿失败: {error}"},
+	replyDraftDiscarded:              {Other: "草稿已丢弃。"},
+	replyDraftExpired:                {Other: "你的草稿闲置 {idle} 后已被丢弃。"},
+	replyPreviewHelp:                 {Other: "请使用 /preview [文字] 查看文字打印出来的样子，或使用 /draft 编写草稿后再 /preview 预览。"},
+	replyPreviewFailed:               {Other: "生成预览失败: {error}"},
+	replyPreviewWide:                 {Other: "预览暂时无法显示中日韩文字，不过打印不受影响。"},
+	replyInlinePrintTo:               {Other: "打印到 {device}"},
+	replyInlineBindFirst:             {Other: "绑定咕咕机后即可在这里打印"},
+	replyInlinePrinted:               {Other: "打印“{text}”到 {device}: {result}"},
+	replyLocationTitle:               {Other: "位置"},
+	replyLocationFailed:              {Other: "准备位置失败: {error}"},
+	replyContactPhone:                {Other: "电话: {phone}"},
+	replyContactTelegram:             {Other: "Telegram: @{username}"},
+	replyContactFailed:               {Other: "准备联系人失败: {error}"},
+	replySettings:                    {Other: "你的设置:\n{settings}\n\n使用 /settings [名称] [值] 修改设置。"},
+	replySettingLine:                 {Other: "- {name}: {value} ({values})\n  {description}"},
+	replySettingContact:              {Other: "打印联系人名片的排版，compact 将姓名和电话排在同一行。"},
+	replySettingUnknown:              {Other: "未知的设置，请使用 {names} 之一。"},
+	replySettingInvalid:              {Other: "请使用 /settings {name} [{values}]。"},
+	replySettingSet:                  {Other: "{name} 已设置为 {value}。"},
+	replyDocumentUnsupported:         {Other: "只能打印文本文档 (.txt、.md、.csv 和 .log)。"},
+	replyDocumentTooLarge:            {Other: "文档太大了，最大可以打印 {max} 的文档。"},
+	replyDocumentFailed:              {Other: "准备文档失败: {error}"},
+	replyDocumentEncoding:            {Other: "文档的编码不是 UTF-8、GBK 或带 BOM 的 UTF-16。"},
+	replyDocumentEmpty:               {Other: "文档没有可以打印的内容。"},
+	replyDocumentConfirm:             {Other: "{name} 大约需要 {length} 纸，将分 {count} 次打印，确定打印吗？"},
+	replyDocumentPrint:               {Other: "打印"},
+	replyDocumentCancel:              {Other: "取消"},
+	replyDocumentGone:                {Other: "文档已不存在，请重新发送。"},
+	replyDocumentPrinting:            {Other: "正在打印..."},
+	replyDocumentPartFailed:          {Other: "第 {part}/{count} 部分打印失败，其余部分未打印: {error}"},
+	replyDocumentPrinted:             {Other: "文档的 {count} 个部分都已打印。"},
+	replyDocumentCancelled:           {Other: "已取消。"},
+	replyStickerFailed:               {Other: "准备贴纸失败: {error}"},
+	replySettingStickerCaption:       {Other: "打印贴纸时是否在下方印上其表情的简短名称，没有名称的表情将被省略。"},
+	replyQueued:                      {Other: "消息将在 {count} 秒后打印，在此之前可以编辑消息，或用 /cancel 回复它来取消。"},
+	replyEditQueued:                  {Other: "将改为打印修改后的消息。"},
+	replyEditPrinted:                 {Other: "这条消息已经发送打印了，要重新打印修改后的版本吗？"},
+	replyEditReprint:                 {Other: "重新打印"},
+	replyEditReprinting:              {Other: "正在重新打印..."},
+	replyEditGone:                    {Other: "消息已不存在。"},
+	replyCancelHelp:                  {Other: "请用 /cancel 回复一条等待打印的消息来取消打印。"},
+	replyCancelled:                   {Other: "已取消，这条消息不会被打印。"},
+	replyCancelTooLate:               {Other: "这条消息已经发送打印了，无法取消。"},
+	replyExportFailed:                {Other: "导出数据失败，请稍后重试。"},
+	replyExportCaption:               {Other: "关于你的全部数据，包括你的咕咕机和打印过的内容。"},
+	replyDeleteMeConfirm:             {Other: "这将永久删除关于你的全部数据：个人资料、打印过的内容、待办清单、草稿和订阅。你的咕咕机将被解绑，它们的共享和 webhook 也会被撤销。此操作无法撤销，你可以先用 /export 导出数据。"},
+	replyDeleteMe:                    {Other: "全部删除"},
+	replyDeleteMeCancel:              {Other: "保留我的数据"},
+	replyDeleteMeCancelled:           {Other: "没有删除任何数据。"},
+	replyDeleteMeDone:                {Other: "关于你的全部数据已删除。再次发送消息将作为新用户重新开始。"},
+	replyDeleteMeFailed:              {Other: "删除数据失败，请稍后重试。"},
+}
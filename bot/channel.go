@@ -0,0 +1,489 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// channelMaxDigestHours is the longest interval of digests, a week.
+const channelMaxDigestHours = 24 * 7
+
+// callback endpoints of the approval keyboard.
+var (
+	btnChannelApprove = tb.InlineButton{Unique: "channel_approve"}
+	btnChannelReject  = tb.InlineButton{Unique: "channel_reject"}
+)
+
+func (b *Bot) handleChannel(m *message) {
+	action, rest := cutWord(m.Payload)
+	args := strings.Fields(rest)
+	switch {
+	case action == "" || action == "list":
+		b.listChannels(m)
+	case action == "link" && (len(args) == 1 || len(args) == 2):
+		b.linkChannel(m, args)
+	case (action == "unlink" || action == "tags" || action == "photos" || action == "digest") && len(args) > 0:
+		b.updateChannel(m, action, args[0], args[1:])
+	default:
+		b.Send(m.Sender, m.T(replyChannelHelp))
+	}
+}
+
+func (b *Bot) listChannels(m *message) {
+	subs, err := b.ChannelService.ListByUserID(m.SenderUser.ID)
+	if err != nil {
+		log.Warnf("error querying channels of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if len(subs) == 0 {
+		b.Send(m.Sender, m.T(replyChannelsNone))
+		return
+	}
+
+	lines := []string{m.T(replyChannelsHeader)}
+	for _, sub := range subs {
+		device, err := b.DeviceService.GetByID(sub.DeviceID)
+		if err != nil {
+			log.Warnf("error querying device[%d]: %s", sub.DeviceID, err)
+			continue
+		}
+		lines = append(lines, describeChannelSubscription(m.Lang, &sub, device))
+	}
+	b.Send(m.Sender, strings.Join(lines, "\n"))
+}
+
+func describeChannelSubscription(lang i18n.Lang, sub *model.ChannelSubscription, device *model.Device) string {
+	status := catalogs.T(lang, replyChannelStatusPending)
+	if sub.IsApproved() {
+		status = catalogs.T(lang, replyChannelStatusApproved)
+	}
+	hashtags := catalogs.T(lang, replyChannelAllPosts)
+	if sub.Hashtags != "" {
+		hashtags = sub.Hashtags
+	}
+	photos := catalogs.T(lang, replyChannelTextOnly)
+	if sub.IncludePhotos {
+		photos = catalogs.T(lang, replyChannelWithPhotos)
+	}
+	digest := catalogs.T(lang, replyChannelDigestOff)
+	if sub.DigestHours > 0 {
+		digest = catalogs.N(lang, replyChannelDigestEvery, sub.DigestHours)
+	}
+	return catalogs.T(lang, replyChannelItem, i18n.Params{
+		"id":       sub.ID,
+		"channel":  sub.ChannelTitle,
+		"device":   device.Name(),
+		"status":   status,
+		"hashtags": hashtags,
+		"photos":   photos,
+		"digest":   digest,
+	})
+}
+
+// isChannelAdmin returns whether the telegram user is an administrator of chat, which fails if the bot
+// is not a member of chat.
+func (b *Bot) isChannelAdmin(chat *tb.Chat, telegramID int) (bool, error) {
+	admins, err := b.AdminsOf(chat)
+	if err != nil {
+		return false, err
+	}
+	for _, admin := range admins {
+		if admin.User != nil && admin.User.ID == telegramID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// linkChannel handles "link [channel] [@owner]", the owner of the device must approve unless it's the sender.
+func (b *Bot) linkChannel(m *message, args []string) {
+	chat, err := b.ChatByID(args[0])
+	if err != nil || (chat.Type != tb.ChatChannel && chat.Type != tb.ChatChannelPrivate) {
+		b.Send(m.Sender, m.T(replyChannelNotFound, i18n.Params{"channel": args[0]}))
+		return
+	}
+	isAdmin, err := b.isChannelAdmin(chat, m.Sender.ID)
+	if err != nil {
+		log.Warnf("error querying admins of channel[%d]: %s", chat.ID, err)
+		b.Send(m.Sender, m.T(replyChannelNeedAdmin, i18n.Params{"bot": b.Me.Username}))
+		return
+	}
+	if !isAdmin {
+		b.Send(m.Sender, m.T(replyChannelNotAdmin, i18n.Params{"channel": chat.Title}))
+		return
+	}
+
+	var device *model.Device
+	if len(args) == 1 {
+		var ok bool
+		if device, ok = b.ownedVerifiedDevice(m); !ok {
+			return
+		}
+	} else {
+		owner, err := b.userByMention(args[1])
+		if err == nil && owner != nil {
+			device, err = b.DeviceService.GetByUserID(owner.ID)
+		}
+		if err != nil && !service.IsRecordNotFoundError(err) {
+			log.Warnf("error querying device of user[%s]: %s", args[1], err)
+			b.Send(m.Sender, m.T(replyFailedGettingData))
+			return
+		}
+		if owner == nil || err != nil || !device.IsVerified() {
+			b.Send(m.Sender, m.T(replyChannelDeviceNotFound, i18n.Params{"user": strings.TrimPrefix(args[1], "@")}))
+			return
+		}
+	}
+
+	sub, err := b.ChannelService.New(&model.ChannelSubscription{
+		ChannelID:    chat.ID,
+		ChannelTitle: chat.Title,
+		DeviceID:     device.ID,
+		RequesterID:  m.SenderUser.ID,
+	})
+	if err != nil {
+		log.Warnf("error linking channel[%d] to device[%d]: %s", chat.ID, device.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if sub.IsApproved() {
+		b.Send(m.Sender, m.T(replyChannelLinked, i18n.Params{"id": sub.ID, "channel": sub.ChannelTitle}))
+		return
+	}
+
+	if device.UserID == m.SenderUser.ID {
+		if err := b.ChannelService.Approve(sub.ID, time.Now()); err != nil {
+			log.Warnf("error approving channel subscription[%d]: %s", sub.ID, err)
+			b.Send(m.Sender, m.T(replyFailedGettingData))
+			return
+		}
+		b.Send(m.Sender, m.T(replyChannelLinked, i18n.Params{"id": sub.ID, "channel": sub.ChannelTitle}))
+		return
+	}
+
+	owner, err := b.UserService.GetByID(device.UserID)
+	if err != nil {
+		log.Warnf("error querying user[%d]: %s", device.UserID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	lang := langOf(owner, "")
+	data := strconv.Itoa(int(sub.ID))
+	approve, reject := btnChannelApprove, btnChannelReject
+	approve.Text, approve.Data = catalogs.T(lang, replyChannelApprove), data
+	reject.Text, reject.Data = catalogs.T(lang, replyChannelReject), data
+	_, err = b.Send(&tb.User{ID: int(owner.TelegramID)}, catalogs.T(lang, replyChannelApproval, i18n.Params{
+		"user":    m.SenderUser.TelegramFullName,
+		"channel": sub.ChannelTitle,
+	}), &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{approve, reject}}})
+	if err != nil {
+		log.Warnf("error asking user[%d] for approval: %s", owner.ID, err)
+		b.Send(m.Sender, m.T(replyFailedSendingMessage, i18n.Params{"error": err}))
+		return
+	}
+	b.Send(m.Sender, m.T(replyChannelPending, i18n.Params{"channel": sub.ChannelTitle, "user": owner.TelegramFullName}))
+}
+
+// channelSubscriptionOf returns the subscription of id if the user requested it or owns its device.
+func (b *Bot) channelSubscriptionOf(userID uint, id string) (*model.ChannelSubscription, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(id, "#"))
+	if err != nil || n <= 0 {
+		return nil, service.ErrRecordNotFound
+	}
+	sub, err := b.ChannelService.GetByID(uint(n))
+	if err != nil {
+		return nil, err
+	}
+	device, err := b.DeviceService.GetByID(sub.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.RequesterID != userID && device.UserID != userID {
+		return nil, service.ErrRecordNotFound
+	}
+	return sub, nil
+}
+
+func (b *Bot) updateChannel(m *message, action, id string, args []string) {
+	sub, err := b.channelSubscriptionOf(m.SenderUser.ID, id)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyChannelSubscriptionNotFound))
+		return
+	}
+	if err != nil {
+		log.Warnf("error querying channel subscription[%s]: %s", id, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	switch action {
+	case "unlink":
+		_, err = b.ChannelService.Delete(sub.ID)
+	case "tags":
+		err = b.ChannelService.SetFilter(sub.ID, normalizeHashtags(args), sub.IncludePhotos)
+	case "photos":
+		if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+			b.Send(m.Sender, m.T(replyChannelHelp))
+			return
+		}
+		err = b.ChannelService.SetFilter(sub.ID, sub.Hashtags, args[0] == "on")
+	case "digest":
+		hours := -1
+		if len(args) == 1 {
+			if n, err := strconv.Atoi(args[0]); err == nil {
+				hours = n
+			} else if args[0] == "off" {
+				hours = 0
+			}
+		}
+		if hours < 0 || hours > channelMaxDigestHours {
+			b.Send(m.Sender, m.T(replyChannelHelp))
+			return
+		}
+		err = b.ChannelService.SetDigestHours(sub.ID, hours)
+	}
+	if err != nil {
+		log.Warnf("error updating channel subscription[%d]: %s", sub.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	if action == "unlink" {
+		b.Send(m.Sender, m.T(replyChannelUnlinked, i18n.Params{"channel": sub.ChannelTitle}))
+		return
+	}
+	b.Send(m.Sender, m.T(replyChannelUpdated, i18n.Params{"id": sub.ID}))
+}
+
+// normalizeHashtags returns tags as lower cased hashtags separated by spaces.
+func normalizeHashtags(tags []string) string {
+	var hashtags []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimLeft(tag, "#"))
+		if tag != "" {
+			hashtags = append(hashtags, "#"+tag)
+		}
+	}
+	return strings.Join(hashtags, " ")
+}
+
+func (b *Bot) handleChannelApprove(c *callback) {
+	b.answerChannelApproval(c, true)
+}
+
+func (b *Bot) handleChannelReject(c *callback) {
+	b.answerChannelApproval(c, false)
+}
+
+func (b *Bot) answerChannelApproval(c *callback, approved bool) {
+	values, err := parseCallbackInts(c.Data, 1)
+	if err != nil {
+		log.Warnf("error parsing callback data[%s]: %s", c.Data, err)
+		b.Respond(c.Callback)
+		return
+	}
+	sub, err := b.ChannelService.GetByID(uint(values[0]))
+	var device *model.Device
+	if err == nil {
+		device, err = b.DeviceService.GetByID(sub.DeviceID)
+	}
+	if service.IsRecordNotFoundError(err) || (err == nil && device.UserID != c.SenderUser.ID) {
+		c.Answer(b, replyChannelSubscriptionNotFound)
+		return
+	}
+	if err != nil {
+		log.Warnf("error querying channel subscription[%d]: %s", values[0], err)
+		c.Answer(b, replyFailedGettingData)
+		return
+	}
+
+	reply := replyChannelRejected
+	if approved {
+		reply = replyChannelApproved
+		err = b.ChannelService.Approve(sub.ID, time.Now())
+	} else {
+		_, err = b.ChannelService.Delete(sub.ID)
+	}
+	if err != nil {
+		log.Warnf("error answering channel subscription[%d]: %s", sub.ID, err)
+		c.Answer(b, replyFailedGettingData)
+		return
+	}
+
+	params := i18n.Params{"channel": sub.ChannelTitle, "device": device.Name()}
+	b.Respond(c.Callback)
+	if _, err := b.Edit(c.Message, c.T(reply, params)); err != nil {
+		log.Warnf("error editing approval of channel subscription[%d]: %s", sub.ID, err)
+	}
+	requester, err := b.UserService.GetByID(sub.RequesterID)
+	if err != nil {
+		log.Warnf("error querying user[%d]: %s", sub.RequesterID, err)
+		return
+	}
+	b.Send(&tb.User{ID: int(requester.TelegramID)}, catalogs.T(langOf(requester, ""), reply, params))
+}
+
+func (b *Bot) handleChannelPost(msg *tb.Message) {
+	b.receiveChannelPost(msg, false)
+}
+
+func (b *Bot) handleEditedChannelPost(msg *tb.Message) {
+	b.receiveChannelPost(msg, true)
+}
+
+// channelPostWanted returns whether msg passes the filters of sub.
+func channelPostWanted(sub *model.ChannelSubscription, msg *tb.Message) bool {
+	if msg.Photo != nil && !sub.IncludePhotos {
+		return false
+	}
+	if msg.Photo == nil && msg.Text == "" {
+		// stickers, videos and others are not printable
+		return false
+	}
+	return sub.MatchesHashtags(msg.Text + " " + msg.Caption)
+}
+
+func (b *Bot) receiveChannelPost(msg *tb.Message, edited bool) {
+	b.metrics.updates.Inc("channel_post")
+	subs, err := b.ChannelService.ListApprovedByChannelID(msg.Chat.ID)
+	if err != nil {
+		log.Warnf("error querying subscriptions of channel[%d]: %s", msg.Chat.ID, err)
+		return
+	}
+
+	post := model.ChannelPost{
+		MessageID: msg.ID,
+		Text:      strings.TrimSpace(msg.Text + msg.Caption),
+		PostedAt:  msg.Time(),
+		Edited:    edited,
+	}
+	if msg.Photo != nil {
+		post.PhotoFileID = msg.Photo.FileID
+	}
+	for i := range subs {
+		sub := &subs[i]
+		if !channelPostWanted(sub, msg) {
+			continue
+		}
+		if sub.DigestHours > 0 {
+			post := post
+			post.SubscriptionID = sub.ID
+			if err := b.ChannelService.SavePost(&post); err != nil {
+				log.Warnf("error saving post[%d] of channel subscription[%d]: %s", msg.ID, sub.ID, err)
+			}
+			continue
+		}
+		b.printChannelPosts(sub, []model.ChannelPost{post})
+	}
+}
+
+// lastPrintedPostID returns the ID of the last one of posts printed with outcome, 0 if they are not
+// printed for whatever reason so that they are kept for the next digest.
+func lastPrintedPostID(outcome string, posts []model.ChannelPost) uint {
+	if outcome != printSuccess {
+		return 0
+	}
+	var id uint
+	for _, post := range posts {
+		if post.ID > id {
+			id = post.ID
+		}
+	}
+	return id
+}
+
+// printChannelPosts prints posts of sub as one slip and returns the outcome.
+func (b *Bot) printChannelPosts(sub *model.ChannelSubscription, posts []model.ChannelPost) string {
+	device, err := b.DeviceService.GetByID(sub.DeviceID)
+	if err != nil {
+		log.Warnf("error querying device[%d]: %s", sub.DeviceID, err)
+		return printRequestError
+	}
+	owner, err := b.UserService.GetByID(device.UserID)
+	if err != nil {
+		log.Warnf("error querying user[%d]: %s", device.UserID, err)
+		return printRequestError
+	}
+	lang := langOf(owner, "")
+	parts := channelPostParts(lang, deviceLocation(device), sub.ChannelTitle, posts)
+	body := renderForwards(parts)
+	var reply, outcome string
+	if photoFileID := forwardPhotoFileID(parts); photoFileID == "" {
+		reply, outcome = b.printText(lang, owner, device, body)
+	} else {
+		// photos are printed as forwarded ones are
+		doc, err := forwardDocument(parts, b.downloadImage)
+		if err != nil {
+			log.Warnf("error rendering channel subscription[%d]: %s", sub.ID, err)
+			return printNotSuccessful
+		}
+		reply, outcome = b.printDocument(lang, owner, device, doc, photoFileID, body)
+	}
+	if outcome != printSuccess {
+		log.Warnf("error printing channel subscription[%d]: %s", sub.ID, reply)
+	}
+	return outcome
+}
+
+// channelPostParts returns the parts printed for posts of the channel titled title with times in loc as
+// forwards are printed, several posts are printed as a digest.
+func channelPostParts(lang i18n.Lang, loc *time.Location, title string, posts []model.ChannelPost) []forwardPart {
+	var parts []forwardPart
+	if len(posts) > 1 {
+		parts = append(parts, forwardPart{text: catalogs.T(lang, replyChannelDigestHeader, i18n.Params{"channel": title})})
+	}
+	for _, post := range posts {
+		when := post.PostedAt.In(loc).Format("2006-01-02 15:04")
+		if post.Edited {
+			when += ", " + catalogs.T(lang, replyChannelPostEdited)
+		}
+		part := forwardPart{attribution: fmt.Sprintf("(%s):", when), text: post.Text}
+		if len(posts) == 1 {
+			part.attribution = title + " " + part.attribution
+		}
+		if post.HasPhoto() {
+			part.photo = &tb.Photo{File: tb.File{FileID: post.PhotoFileID}}
+			part.media = catalogs.T(lang, replyMediaPhoto)
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// printChannelDigests prints posts of the subscriptions of which digests are due at now.
+func (b *Bot) printChannelDigests(now time.Time) {
+	subs, err := b.ChannelService.ListDigests()
+	if err != nil {
+		log.Warn("error querying channel digests:", err)
+		return
+	}
+	for i := range subs {
+		sub := &subs[i]
+		if !sub.IsDigestDue(now) {
+			continue
+		}
+		posts, err := b.ChannelService.ListPosts(sub.ID)
+		if err != nil {
+			log.Warnf("error querying posts of channel subscription[%d]: %s", sub.ID, err)
+			continue
+		}
+
+		var lastPostID uint
+		if len(posts) > 0 {
+			lastPostID = lastPrintedPostID(b.printChannelPosts(sub, posts), posts)
+		}
+		if err := b.ChannelService.FinishDigest(sub.ID, lastPostID, now); err != nil {
+			log.Warnf("error finishing digest of channel subscription[%d]: %s", sub.ID, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestNormalizeHashtags(t *testing.T) {
+	assert.Equal(t, "", normalizeHashtags(nil))
+	assert.Equal(t, "#family #news", normalizeHashtags([]string{"#Family", "news", "#"}))
+}
+
+func TestChannelPostWanted(t *testing.T) {
+	sub := &model.ChannelSubscription{}
+	text := &tb.Message{Text: "Dinner at 7 #family"}
+	photo := &tb.Message{Photo: &tb.Photo{}, Caption: "#family"}
+	sticker := &tb.Message{Sticker: &tb.Sticker{}}
+
+	assert.True(t, channelPostWanted(sub, text))
+	assert.False(t, channelPostWanted(sub, photo))
+	assert.False(t, channelPostWanted(sub, sticker))
+
+	sub.IncludePhotos = true
+	assert.True(t, channelPostWanted(sub, photo))
+
+	sub.Hashtags = "#family"
+	assert.True(t, channelPostWanted(sub, text))
+	assert.True(t, channelPostWanted(sub, photo))
+	assert.False(t, channelPostWanted(sub, &tb.Message{Text: "Weather"}))
+}
+
+func TestChannelPostParts(t *testing.T) {
+	at := time.Date(2019, 10, 1, 8, 30, 0, 0, time.UTC)
+	posts := []model.ChannelPost{
+		{Text: "Dinner at 7", PostedAt: at},
+		{Text: "Cake!", PostedAt: at.Add(time.Hour), PhotoFileID: "cake", Edited: true},
+	}
+	assert.Equal(t, "Family (2019-10-01 08:30):\nDinner at 7",
+		renderForwards(channelPostParts(i18n.English, time.UTC, "Family", posts[:1])))
+	parts := channelPostParts(i18n.English, time.UTC, "Family", posts)
+	assert.Equal(t, "cake", forwardPhotoFileID(parts))
+	assert.Equal(t, `Digest of Family
+
+(2019-10-01 08:30):
+Dinner at 7
+
+(2019-10-01 09:30, edited):
+[Photo]
+Cake!`, renderForwards(parts))
+}
+
+func TestLastPrintedPostID(t *testing.T) {
+	posts := []model.ChannelPost{{Model: gorm.Model{ID: 3}}, {Model: gorm.Model{ID: 5}}, {Model: gorm.Model{ID: 4}}}
+	assert.Equal(t, uint(5), lastPrintedPostID(printSuccess, posts))
+	for _, outcome := range []string{printQuotaExceeded, printRequestError, printNotSuccessful, printShuttingDown} {
+		assert.Zero(t, lastPrintedPostID(outcome, posts), outcome)
+	}
+}
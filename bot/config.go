@@ -2,14 +2,58 @@ package bot
 
 import (
 	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/metrics"
+	"github.com/awesome-memobird/the-memobird-bot/model"
 )
 
 // Config contains configurations to create a bot.
 type Config struct {
 	Token         string
 	PollerTimeout time.Duration
+	// APIURL is the URL of the telegram bot API, empty means the official one.
+	APIURL string
+	// AdminIDs are telegram IDs of the administrators.
+	AdminIDs []int
+
+	// UserQuota is the default quota of every user on a device.
+	UserQuota model.Quota
+	// DeviceQuota is the default quota of every device.
+	DeviceQuota model.Quota
+	// Features toggles optional features.
+	Features Features
+	// Metrics is the optional registry of metrics.
+	Metrics *metrics.Registry
+	// WebhookBaseURL is the public URL of the HTTP server, URLs of webhooks are relative to it.
+	WebhookBaseURL string
+	// DraftIdleTimeout is how long a draft is kept without being added to, 0 means forever.
+	DraftIdleTimeout time.Duration
+	// DocumentMaxSize is the size in bytes of the largest text document printed.
+	DocumentMaxSize int
+	// PrintDelay is how long texts wait before being printed so that they can be edited or cancelled,
+	// 0 prints them right away.
+	PrintDelay time.Duration
+
+	UserService         UserService
+	DeviceService       DeviceService
+	BirdService         BirdService
+	ContentService      ContentService
+	ShareService        ShareService
+	UsageService        UsageService
+	PrintAttemptService PrintAttemptService
+	WebhookService      WebhookService
+	ChannelService      ChannelService
+	FeedService         FeedService
+	FeedFetcher         FeedFetcher
+	TodoService         TodoService
+	DraftService        DraftService
+	AccountService      AccountService
+}
 
-	UserService   UserService
-	DeviceService DeviceService
-	BirdService   BirdService
+// Features toggles optional features of the bot.
+type Features struct {
+	// History keeps printed contents so that users can browse and reprint them.
+	History bool
+	// Sharing allows owners to share their devices with others.
+	Sharing bool
 }
@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"strings"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// vcardEscaper escapes the special characters in text values of vCard 3.0.
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+
+// handleContact prints shared contacts as business cards in the layout chosen by the user.
+func (b *Bot) handleContact(msg *tb.Message) {
+	m, ok := b.receive(msg)
+	if !ok {
+		return
+	}
+	b.metrics.updates.Inc("contact")
+
+	device, err := b.printableDevice(m.SenderUser)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyBindHelp))
+		return
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	doc, text, err := renderContactCard(m.Lang, contactLayout(m.SenderUser), msg.Contact, b.contactUserName(msg.Contact))
+	if err != nil {
+		log.Warnf("error rendering contact of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyContactFailed, i18n.Params{"error": err}))
+		return
+	}
+	reply, _ := b.printDocument(m.Lang, m.SenderUser, device, doc, "", text)
+	b.Send(m.Sender, reply, &tb.SendOptions{
+		ReplyTo:   m.Message,
+		ParseMode: tb.ModeMarkdown,
+	})
+}
+
+// contactUserName returns the telegram username of contact if known to the bot.
+func (b *Bot) contactUserName(contact *tb.Contact) string {
+	if contact.UserID == 0 {
+		return ""
+	}
+	user, err := b.UserService.GetByTelegramID(contact.UserID)
+	if err != nil {
+		if !service.IsRecordNotFoundError(err) {
+			log.Warnf("error getting user by telegram ID[%d]: %s", contact.UserID, err)
+		}
+		return ""
+	}
+	return user.TelegramUserName
+}
+
+// contactLayout returns the layout of contact cards chosen by user.
+func contactLayout(user *model.User) string {
+	if user.ContactLayout == "" {
+		return model.ContactLayoutFull
+	}
+	return user.ContactLayout
+}
+
+// renderContactCard renders contact in layout with a QR code of its vCard, the text of the card is
+// returned as well.
+func renderContactCard(lang i18n.Lang, layout string, contact *tb.Contact, userName string) (*memobird.Document, string, error) {
+	name := strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+	var lines []string
+	if layout == model.ContactLayoutCompact {
+		lines = append(lines, name+" "+contact.PhoneNumber)
+	} else {
+		lines = append(lines, name, catalogs.T(lang, replyContactPhone, i18n.Params{"phone": contact.PhoneNumber}))
+		if userName != "" {
+			lines = append(lines, catalogs.T(lang, replyContactTelegram, i18n.Params{"username": userName}))
+		}
+	}
+	text := strings.Join(lines, "\n")
+
+	doc := new(memobird.Document)
+	if err := doc.AddText(text); err != nil {
+		return nil, "", err
+	}
+	if err := doc.AddQRCode(vcard(contact, userName)); err != nil {
+		return nil, "", err
+	}
+	return doc, text, nil
+}
+
+// vcard returns the vCard 3.0 of contact, with a link to the telegram account if userName is not empty.
+func vcard(contact *tb.Contact, userName string) string {
+	first, last := vcardEscaper.Replace(contact.FirstName), vcardEscaper.Replace(contact.LastName)
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"N:" + last + ";" + first + ";;;",
+		"FN:" + strings.TrimSpace(first+" "+last),
+		"TEL;TYPE=CELL:" + vcardEscaper.Replace(contact.PhoneNumber),
+	}
+	if userName != "" {
+		lines = append(lines, "URL:https://t.me/"+userName)
+	}
+	lines = append(lines, "END:VCARD")
+	// lines are separated by CRLF
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
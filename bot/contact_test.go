@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestVCard(t *testing.T) {
+	contact := &tb.Contact{PhoneNumber: "+8613800000000", FirstName: "Alice", LastName: "Smith, Jr."}
+	assert.Equal(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nN:Smith\\, Jr.;Alice;;;\r\nFN:Alice Smith\\, Jr.\r\n"+
+		"TEL;TYPE=CELL:+8613800000000\r\nEND:VCARD\r\n", vcard(contact, ""))
+	assert.Contains(t, vcard(contact, "alice"), "\r\nURL:https://t.me/alice\r\n")
+}
+
+func TestRenderContactCard(t *testing.T) {
+	contact := &tb.Contact{PhoneNumber: "+8613800000000", FirstName: "Alice"}
+	_, text, err := renderContactCard(i18n.English, model.ContactLayoutFull, contact, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice\nPhone: +8613800000000\nTelegram: @alice", text)
+
+	_, text, err = renderContactCard(i18n.English, model.ContactLayoutFull, contact, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice\nPhone: +8613800000000", text)
+
+	_, text, err = renderContactCard(i18n.English, model.ContactLayoutCompact, contact, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice +8613800000000", text)
+}
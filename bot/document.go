@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// documentPartLines is the number of lines printed as one part of a text document, longer documents are
+// split and printed after confirmation.
+const documentPartLines = 100
+
+// inline buttons to confirm printing a long document, which is the message replied to.
+var (
+	btnDocumentPrint  = tb.InlineButton{Unique: "document_print"}
+	btnDocumentCancel = tb.InlineButton{Unique: "document_cancel"}
+)
+
+// handleDocument prints text documents, long ones are printed in parts after confirmation.
+func (b *Bot) handleDocument(msg *tb.Message) {
+	m, ok := b.receive(msg)
+	if !ok {
+		return
+	}
+	b.metrics.updates.Inc("document")
+
+	parts, lines, ok := b.textDocParts(m.Lang, m.Sender, msg.Document)
+	if !ok {
+		return
+	}
+	device, err := b.printableDevice(m.SenderUser)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyBindHelp))
+		return
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	if len(parts) == 1 {
+		reply, _ := b.printText(m.Lang, m.SenderUser, device, parts[0])
+		b.Send(m.Sender, reply, &tb.SendOptions{
+			ReplyTo:   m.Message,
+			ParseMode: tb.ModeMarkdown,
+		})
+		return
+	}
+
+	confirm, cancel := btnDocumentPrint, btnDocumentCancel
+	confirm.Text = m.T(replyDocumentPrint)
+	cancel.Text = m.T(replyDocumentCancel)
+	b.Send(m.Sender, m.T(replyDocumentConfirm, i18n.Params{
+		"name":   msg.Document.FileName,
+		"count":  len(parts),
+		"length": formatLength(paperLength(lines)),
+	}), &tb.SendOptions{
+		ReplyTo:     m.Message,
+		ReplyMarkup: &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{confirm, cancel}}},
+	})
+}
+
+// textDocParts downloads and renders doc in parts to be printed, false is returned after replying to
+// recipient in lang if it can't be printed. The number of lines printed is returned as well.
+func (b *Bot) textDocParts(lang i18n.Lang, recipient tb.Recipient, doc *tb.Document) ([]string, int, bool) {
+	kind := textDocKind(doc.FileName, doc.MIME)
+	if kind == "" {
+		b.Send(recipient, catalogs.T(lang, replyDocumentUnsupported))
+		return nil, 0, false
+	}
+	if doc.FileSize > b.DocumentMaxSize {
+		b.Send(recipient, catalogs.T(lang, replyDocumentTooLarge, i18n.Params{"max": formatSize(b.DocumentMaxSize)}))
+		return nil, 0, false
+	}
+
+	data, err := b.downloadFile(&doc.File, b.DocumentMaxSize)
+	if err != nil {
+		log.Warnf("error downloading document[%s]: %s", doc.FileID, err)
+		b.Send(recipient, catalogs.T(lang, replyDocumentFailed, i18n.Params{"error": err}))
+		return nil, 0, false
+	}
+	txt, err := decodeText(data)
+	if err == nil {
+		txt, err = renderTextDoc(kind, txt)
+	}
+	if errors.Is(err, errUnknownEncoding) {
+		b.Send(recipient, catalogs.T(lang, replyDocumentEncoding))
+		return nil, 0, false
+	}
+	if err != nil {
+		b.Send(recipient, catalogs.T(lang, replyDocumentFailed, i18n.Params{"error": err}))
+		return nil, 0, false
+	}
+
+	parts, lines := splitTextDoc(txt, documentPartLines)
+	if len(parts) == 0 {
+		b.Send(recipient, catalogs.T(lang, replyDocumentEmpty))
+		return nil, 0, false
+	}
+	return parts, lines, true
+}
+
+// downloadFile downloads file from telegram, it fails if the file is larger than max bytes.
+func (b *Bot) downloadFile(file *tb.File, max int) ([]byte, error) {
+	r, err := b.GetFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("downloading file: %w", err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(r, int64(max)+1))
+	if err != nil {
+		return nil, fmt.Errorf("downloading file: %w", err)
+	}
+	if len(data) > max {
+		return nil, fmt.Errorf("file larger than %s", formatSize(max))
+	}
+	return data, nil
+}
+
+// handleDocumentPrint prints the document replied to by the confirmation in parts.
+func (b *Bot) handleDocumentPrint(c *callback) {
+	orig := c.Message.ReplyTo
+	if orig == nil || orig.Document == nil {
+		c.Answer(b, replyDocumentGone)
+		return
+	}
+	b.Respond(c.Callback)
+	// the buttons are removed so that it's not printed twice
+	if _, err := b.Edit(c.Message, c.T(replyDocumentPrinting)); err != nil {
+		log.Warnf("error editing confirmation of document[%s]: %s", orig.Document.FileID, err)
+	}
+	parts, _, ok := b.textDocParts(c.Lang, c.Sender, orig.Document)
+	if !ok {
+		return
+	}
+	device, err := b.printableDevice(c.SenderUser)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(c.Sender, c.T(replyBindHelp))
+		return
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		b.Send(c.Sender, c.T(replyFailedGettingData))
+		return
+	}
+
+	reply := b.printTextDocParts(c.Lang, c.SenderUser, device, parts)
+	if _, err := b.Edit(c.Message, reply, tb.ModeMarkdown); err != nil {
+		log.Warnf("error editing confirmation of document[%s]: %s", orig.Document.FileID, err)
+	}
+}
+
+// printTextDocParts prints parts in order as separate jobs until one fails, the reply is returned.
+func (b *Bot) printTextDocParts(lang i18n.Lang, user *model.User, device *model.Device, parts []string) string {
+	for i, part := range parts {
+		reply, outcome := b.printText(lang, user, device, part)
+		if outcome != printSuccess {
+			return catalogs.T(lang, replyDocumentPartFailed, i18n.Params{
+				"part":  i + 1,
+				"count": len(parts),
+				"error": reply,
+			})
+		}
+	}
+	return catalogs.T(lang, replyDocumentPrinted, i18n.Params{"count": len(parts)})
+}
+
+func (b *Bot) handleDocumentCancel(c *callback) {
+	b.Respond(c.Callback)
+	if _, err := b.Edit(c.Message, c.T(replyDocumentCancelled)); err != nil {
+		log.Warnf("error editing confirmation of document: %s", err)
+	}
+}
+
+// formatSize formats n bytes in KB, or MB if larger than one.
+func formatSize(n int) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%dKB", (n+1<<9)>>10)
+	}
+	return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+}
@@ -0,0 +1,278 @@
+package bot
+
+import (
+	"fmt"
+	"image"
+	// decoders of photos
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// draftMaxParts is the number of texts and photos a draft can have.
+const draftMaxParts = 30
+
+func (b *Bot) handleDraft(m *message) {
+	draft, err := b.DraftService.GetOrNew(m.SenderUser.ID)
+	if err != nil {
+		log.Warnf("error creating draft of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	count, err := b.DraftService.CountParts(draft.ID)
+	if err != nil {
+		log.Warnf("error counting parts of draft[%d]: %s", draft.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if count > 0 {
+		b.Send(m.Sender, m.N(replyDraftAlready, count))
+		return
+	}
+	b.Send(m.Sender, m.T(replyDraftStarted))
+}
+
+// userDraft returns the draft of the sender, nil is returned after replying if there's none.
+func (b *Bot) userDraft(m *message) *model.Draft {
+	draft, err := b.DraftService.GetByUserID(m.SenderUser.ID)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyDraftNone))
+		return nil
+	}
+	if err != nil {
+		log.Warnf("error querying draft of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return nil
+	}
+	return draft
+}
+
+// draftParts returns the parts of draft, nil is returned after replying if there's none.
+func (b *Bot) draftParts(m *message, draft *model.Draft) []model.DraftPart {
+	parts, err := b.DraftService.ListParts(draft.ID)
+	if err != nil {
+		log.Warnf("error querying parts of draft[%d]: %s", draft.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return nil
+	}
+	if len(parts) == 0 {
+		b.Send(m.Sender, m.T(replyDraftEmpty))
+		return nil
+	}
+	return parts
+}
+
+// addToDraft adds m to the draft of its sender, false is returned if the sender is not drafting.
+func (b *Bot) addToDraft(m *message) bool {
+	draft, err := b.DraftService.GetByUserID(m.SenderUser.ID)
+	if service.IsRecordNotFoundError(err) {
+		return false
+	}
+	if err != nil {
+		log.Warnf("error querying draft of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return true
+	}
+
+	part := draftPartOf(m.Lang, b.userLocation(m.SenderUser), m)
+	if part.Text == "" && !part.HasImage() {
+		return true
+	}
+	count, err := b.DraftService.CountParts(draft.ID)
+	if err != nil {
+		log.Warnf("error counting parts of draft[%d]: %s", draft.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return true
+	}
+	if count >= draftMaxParts {
+		b.Send(m.Sender, m.N(replyDraftFull, draftMaxParts), &tb.SendOptions{ReplyTo: m.Message})
+		return true
+	}
+	part.DraftID = draft.ID
+	if err := b.DraftService.AddPart(&part); err != nil {
+		log.Warnf("error adding to draft[%d]: %s", draft.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return true
+	}
+	b.Send(m.Sender, m.N(replyDraftAdded, count+1), &tb.SendOptions{ReplyTo: m.Message})
+	return true
+}
+
+// draftPartOf returns the part of m, forwarded ones are attributed to their authors with times in loc.
+func draftPartOf(lang i18n.Lang, loc *time.Location, m *message) model.DraftPart {
+	var lines []string
+	if isForwarded(m.Message) {
+		lines = append(lines, forwardAttribution(lang, loc, m.Message))
+	}
+	if m.Video != nil {
+		lines = append(lines, catalogs.T(lang, replyMediaVideo))
+	}
+	if text := strings.TrimSpace(m.Payload); text != "" {
+		lines = append(lines, text)
+	}
+	var part model.DraftPart
+	if m.Photo != nil {
+		part.ImageFileID = m.Photo.FileID
+	} else if len(lines) == 1 && isForwarded(m.Message) {
+		// nothing but the attribution
+		return part
+	}
+	part.Text = strings.Join(lines, "\n")
+	return part
+}
+
+// renderDraft renders parts as text, photos are replaced by placeholders.
+func renderDraft(lang i18n.Lang, parts []model.DraftPart) string {
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		var lines []string
+		if part.HasImage() {
+			lines = append(lines, catalogs.T(lang, replyMediaPhoto))
+		}
+		if part.Text != "" {
+			lines = append(lines, part.Text)
+		}
+		texts = append(texts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+func (b *Bot) handlePrintDraft(m *message) {
+	draft := b.userDraft(m)
+	if draft == nil {
+		return
+	}
+	parts := b.draftParts(m, draft)
+	if parts == nil {
+		return
+	}
+	device, err := b.printableDevice(m.SenderUser)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyBindHelp))
+		return
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	done, ok := b.jobs.begin(fmt.Sprintf("printing draft[%d] to device[%d]", draft.ID, device.ID))
+	if !ok {
+		b.Send(m.Sender, m.T(replyShuttingDown))
+		return
+	}
+	defer done()
+
+	doc, err := b.draftDocument(parts)
+	if err != nil {
+		log.Warnf("error rendering draft[%d]: %s", draft.ID, err)
+		b.Send(m.Sender, m.T(replyDraftFailed, i18n.Params{"error": err}))
+		return
+	}
+	reply, outcome := b.printDocumentInJob(m.Lang, m.SenderUser, device, doc, draftPhotoFileID(parts), renderDraft(m.Lang, parts))
+	if outcome == printSuccess {
+		if err := b.DraftService.Delete(draft.ID); err != nil {
+			log.Warnf("error deleting draft[%d]: %s", draft.ID, err)
+		}
+	}
+	b.Send(m.Sender, reply, &tb.SendOptions{ParseMode: tb.ModeMarkdown})
+}
+
+// draftPhotoFileID returns the file of the first photo of parts, empty if none has a photo.
+func draftPhotoFileID(parts []model.DraftPart) string {
+	for _, part := range parts {
+		if part.HasImage() {
+			return part.ImageFileID
+		}
+	}
+	return ""
+}
+
+// draftDocument renders parts as one document, photos are downloaded from telegram.
+func (b *Bot) draftDocument(parts []model.DraftPart) (*memobird.Document, error) {
+	doc := new(memobird.Document)
+	for _, part := range parts {
+		if part.HasImage() {
+			img, err := b.downloadImage(part.ImageFileID)
+			if err != nil {
+				return nil, err
+			}
+			if err := doc.AddImage(img); err != nil {
+				return nil, err
+			}
+		}
+		if part.Text != "" {
+			if err := doc.AddText(part.Text); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
+
+// downloadImage downloads and decodes the image of the telegram file.
+func (b *Bot) downloadImage(fileID string) (image.Image, error) {
+	r, err := b.GetFile(&tb.File{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("downloading image: %w", err)
+	}
+	defer r.Close()
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	return img, nil
+}
+
+func (b *Bot) handleDiscard(m *message) {
+	draft := b.userDraft(m)
+	if draft == nil {
+		return
+	}
+	if err := b.DraftService.Delete(draft.ID); err != nil {
+		log.Warnf("error deleting draft[%d]: %s", draft.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	b.Send(m.Sender, m.T(replyDraftDiscarded))
+}
+
+// expireDrafts discards the drafts idle for longer than DraftIdleTimeout at now.
+func (b *Bot) expireDrafts(now time.Time) {
+	if b.DraftIdleTimeout <= 0 {
+		return
+	}
+	drafts, err := b.DraftService.ListIdle(now.Add(-b.DraftIdleTimeout))
+	if err != nil {
+		log.Warn("error querying idle drafts:", err)
+		return
+	}
+	for _, draft := range drafts {
+		if err := b.DraftService.Delete(draft.ID); err != nil {
+			log.Warnf("error deleting draft[%d]: %s", draft.ID, err)
+			continue
+		}
+		user, err := b.UserService.GetByID(draft.UserID)
+		if err != nil {
+			log.Warnf("error querying user[%d]: %s", draft.UserID, err)
+			continue
+		}
+		lang := langOf(user, "")
+		_, err = b.Send(&tb.User{ID: int(user.TelegramID)}, catalogs.T(lang, replyDraftExpired, i18n.Params{
+			"idle": humanDuration(b.DraftIdleTimeout),
+		}))
+		if err != nil {
+			log.Warnf("error notifying user[%d] of expired draft: %s", user.ID, err)
+		}
+	}
+}
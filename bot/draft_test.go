@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestDraftPartOf(t *testing.T) {
+	alice := &tb.User{FirstName: "Alice"}
+	at := 1569918600 // 2019-10-01 08:30 UTC
+	photo := &tb.Photo{File: tb.File{FileID: "photo"}}
+
+	assert.Equal(t, model.DraftPart{Text: "Hi"},
+		draftPartOf(i18n.English, time.UTC, &message{Message: &tb.Message{}, Payload: " Hi "}))
+	assert.Equal(t, model.DraftPart{Text: "Alice (2019-10-01 08:30):\nHi"},
+		draftPartOf(i18n.English, time.UTC, &message{Message: &tb.Message{OriginalSender: alice, OriginalUnixtime: at}, Payload: "Hi"}))
+	assert.Equal(t, model.DraftPart{ImageFileID: "photo", Text: "Sunrise"},
+		draftPartOf(i18n.English, time.UTC, &message{Message: &tb.Message{Photo: photo}, Payload: "Sunrise"}))
+	assert.Equal(t, model.DraftPart{ImageFileID: "photo"},
+		draftPartOf(i18n.English, time.UTC, &message{Message: &tb.Message{Photo: photo}}))
+	assert.Equal(t, model.DraftPart{},
+		draftPartOf(i18n.English, time.UTC, &message{Message: &tb.Message{OriginalSender: alice, OriginalUnixtime: at}}),
+		"nothing but the attribution")
+}
+
+func TestRenderDraft(t *testing.T) {
+	parts := []model.DraftPart{
+		{Text: "Shopping"},
+		{ImageFileID: "photo", Text: "This one"},
+		{ImageFileID: "photo"},
+	}
+	assert.Equal(t, "Shopping\n\n[Photo]\nThis one\n\n[Photo]", renderDraft(i18n.English, parts))
+}
+
+func TestDraftPhotoFileID(t *testing.T) {
+	parts := []model.DraftPart{{Text: "hello"}, {ImageFileID: "first"}, {ImageFileID: "second"}}
+	assert.Equal(t, "first", draftPhotoFileID(parts))
+	assert.Empty(t, draftPhotoFileID(parts[:1]))
+}
@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// jobs tracks in-flight jobs so that they can be waited for when shutting down.
+type jobs struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	closed  bool
+	nextID  int
+	running map[int]string
+}
+
+// begin registers a job described by desc, ok is false if no more jobs are accepted.
+// done must be called once the job is finished.
+func (j *jobs) begin(desc string) (done func(), ok bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return nil, false
+	}
+	if j.running == nil {
+		j.running = make(map[int]string)
+	}
+	id := j.nextID
+	j.nextID++
+	j.running[id] = desc
+	j.wg.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			j.mu.Lock()
+			delete(j.running, id)
+			j.mu.Unlock()
+			j.wg.Done()
+		})
+	}, true
+}
+
+// count returns the number of running jobs.
+func (j *jobs) count() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.running)
+}
+
+// close stops accepting jobs and waits for the running ones until ctx is done,
+// descriptions of the unfinished jobs are returned.
+func (j *jobs) close(ctx context.Context) []string {
+	j.mu.Lock()
+	j.closed = true
+	j.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	ids := make([]int, 0, len(j.running))
+	for id := range j.running {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	abandoned := make([]string, 0, len(ids))
+	for _, id := range ids {
+		abandoned = append(abandoned, j.running[id])
+	}
+	return abandoned
+}
+
+// Drain stops accepting new prints and waits for the in-flight ones until ctx is done,
+// descriptions of the abandoned ones are returned.
+// It should be called after the bot is stopped.
+func (b *Bot) Drain(ctx context.Context) []string {
+	return b.jobs.close(ctx)
+}
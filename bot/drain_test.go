@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobsClose(t *testing.T) {
+	var j jobs
+	done, ok := j.begin("finished")
+	assert.True(t, ok)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done()
+		done()
+	}()
+	assert.Empty(t, j.close(context.Background()))
+
+	_, ok = j.begin("too late")
+	assert.False(t, ok)
+}
+
+func TestJobsCloseAbandoned(t *testing.T) {
+	var j jobs
+	_, ok := j.begin("stuck")
+	assert.True(t, ok)
+	done, ok := j.begin("finished")
+	assert.True(t, ok)
+	done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, []string{"stuck"}, j.close(ctx))
+}
@@ -0,0 +1,333 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/feed"
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+)
+
+const (
+	// feedPollInterval is how often a feed is checked for new items.
+	feedPollInterval = 15 * time.Minute
+	// feedMaxItemsPerPrint is the number of the latest items printed at once, older ones are skipped.
+	feedMaxItemsPerPrint = 10
+	// feedMaxSubscriptions is the number of feeds a user can subscribe to.
+	feedMaxSubscriptions = 20
+)
+
+func (b *Bot) handleSubscribe(m *message) {
+	args := strings.Fields(m.Payload)
+	if len(args) == 0 || len(args) > 2 {
+		b.Send(m.Sender, m.T(replyFeedHelp))
+		return
+	}
+	u, err := url.Parse(args[0])
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		b.Send(m.Sender, m.T(replyFeedHelp))
+		return
+	}
+	var digestTime string
+	if len(args) == 2 {
+		at, err := time.Parse(model.DigestTimeLayout, args[1])
+		if err != nil {
+			b.Send(m.Sender, m.T(replyFeedHelp))
+			return
+		}
+		digestTime = at.Format(model.DigestTimeLayout)
+	}
+
+	count, err := b.FeedService.CountSubscriptionsByUserID(m.SenderUser.ID)
+	if err != nil {
+		log.Warnf("error counting feeds of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if count >= feedMaxSubscriptions {
+		b.Send(m.Sender, m.N(replyFeedTooMany, feedMaxSubscriptions))
+		return
+	}
+
+	f, err := b.FeedService.GetOrNew(u.String())
+	if err != nil {
+		log.Warnf("error creating feed[%s]: %s", u, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if f.CheckedAt == nil || f.Error != "" {
+		// items already in the feed are not printed
+		// the error may tell what's on the network of the bot, it's only logged
+		if err := b.checkFeed(f); err != nil {
+			log.Infof("error checking feed[%d] for user[%d]: %s", f.ID, m.SenderUser.ID, err)
+			b.Send(m.Sender, m.T(replyFeedUnreachable))
+			return
+		}
+	}
+	lastItemID, err := b.FeedService.LastItemID(f.ID)
+	if err != nil {
+		log.Warnf("error querying items of feed[%d]: %s", f.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	sub, err := b.FeedService.Subscribe(&model.FeedSubscription{
+		FeedID:     f.ID,
+		UserID:     m.SenderUser.ID,
+		LastItemID: lastItemID,
+		DigestTime: digestTime,
+	})
+	if err != nil {
+		log.Warnf("error subscribing user[%d] to feed[%d]: %s", m.SenderUser.ID, f.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	b.Send(m.Sender, m.T(replyFeedSubscribed, i18n.Params{
+		"id":       sub.ID,
+		"feed":     f.Name(),
+		"schedule": feedSchedule(m.Lang, sub),
+	}))
+}
+
+func feedSchedule(lang i18n.Lang, sub *model.FeedSubscription) string {
+	if sub.DigestTime == "" {
+		return catalogs.T(lang, replyFeedInstantly)
+	}
+	return catalogs.T(lang, replyFeedDaily, i18n.Params{"time": sub.DigestTime})
+}
+
+func (b *Bot) handleSubscriptions(m *message) {
+	subs, err := b.FeedService.ListSubscriptionsByUserID(m.SenderUser.ID)
+	if err != nil {
+		log.Warnf("error querying feeds of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if len(subs) == 0 {
+		b.Send(m.Sender, m.T(replyFeedsNone))
+		return
+	}
+
+	lines := []string{m.T(replyFeedsHeader)}
+	for i := range subs {
+		sub := &subs[i]
+		f, err := b.FeedService.GetByID(sub.FeedID)
+		if err != nil {
+			log.Warnf("error querying feed[%d]: %s", sub.FeedID, err)
+			continue
+		}
+		line := fmt.Sprintf("#%d %s, %s", sub.ID, f.Name(), feedSchedule(m.Lang, sub))
+		if f.Error != "" {
+			line += "\n  " + m.T(replyFeedUnreachable)
+		}
+		lines = append(lines, line)
+	}
+	b.Send(m.Sender, strings.Join(lines, "\n"))
+}
+
+func (b *Bot) handleUnsubscribe(m *message) {
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(m.Payload), "#"))
+	if err != nil || id <= 0 {
+		b.Send(m.Sender, m.T(replyFeedHelp))
+		return
+	}
+	deleted, err := b.FeedService.DeleteSubscription(uint(id), m.SenderUser.ID)
+	if err != nil {
+		log.Warnf("error unsubscribing user[%d] from feed subscription[%d]: %s", m.SenderUser.ID, id, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if !deleted {
+		b.Send(m.Sender, m.T(replyFeedNotFound))
+		return
+	}
+	b.Send(m.Sender, m.T(replyFeedUnsubscribed, i18n.Params{"id": id}))
+}
+
+// checkFeed fetches f and saves the new items, the error of fetching is saved to f as well.
+func (b *Bot) checkFeed(f *model.Feed) error {
+	result, fetchErr := b.FeedFetcher.Fetch(f.URL, feed.Validators{ETag: f.ETag, LastModified: f.LastModified})
+	now := time.Now()
+	f.CheckedAt = &now
+	f.Error = ""
+	if fetchErr != nil {
+		f.Error = fetchErr.Error()
+	} else {
+		f.ETag, f.LastModified = result.Validators.ETag, result.Validators.LastModified
+	}
+
+	if fetchErr == nil && !result.NotModified {
+		if result.Feed.Title != "" {
+			f.Title = result.Feed.Title
+		}
+		// feeds list the latest items first
+		items := make([]model.FeedItem, 0, len(result.Feed.Items))
+		for i := len(result.Feed.Items) - 1; i >= 0; i-- {
+			items = append(items, newFeedItem(result.Feed.Items[i]))
+		}
+		if _, err := b.FeedService.AddItems(f.ID, items); err != nil {
+			return fmt.Errorf("saving items: %w", err)
+		}
+	}
+	if err := b.FeedService.SaveChecked(f); err != nil {
+		return fmt.Errorf("saving feed: %w", err)
+	}
+	return fetchErr
+}
+
+func newFeedItem(it feed.Item) model.FeedItem {
+	item := model.FeedItem{
+		GUID:    it.GUID,
+		Title:   it.Title,
+		Summary: it.Summary,
+		Link:    it.Link,
+	}
+	if !it.Published.IsZero() {
+		item.PublishedAt = &it.Published
+	}
+	return item
+}
+
+// pollFeeds checks the feeds due at now, new items are printed to the subscriptions without digests,
+// then the digests due are printed.
+func (b *Bot) pollFeeds(now time.Time) {
+	feeds, err := b.FeedService.ListDue(now.Add(-feedPollInterval))
+	if err != nil {
+		log.Warn("error querying feeds:", err)
+		return
+	}
+	for i := range feeds {
+		f := &feeds[i]
+		if err := b.checkFeed(f); err != nil {
+			log.Warnf("error checking feed[%d]: %s", f.ID, err)
+			continue
+		}
+		subs, err := b.FeedService.ListSubscriptionsByFeedID(f.ID)
+		if err != nil {
+			log.Warnf("error querying subscriptions of feed[%d]: %s", f.ID, err)
+			continue
+		}
+		for j := range subs {
+			if subs[j].DigestTime == "" {
+				b.printFeedSubscription(f, &subs[j], nil)
+			}
+		}
+	}
+
+	subs, err := b.FeedService.ListDigestSubscriptions()
+	if err != nil {
+		log.Warn("error querying feed digests:", err)
+		return
+	}
+	for i := range subs {
+		b.printFeedDigestIfDue(&subs[i], now)
+	}
+}
+
+func (b *Bot) printFeedDigestIfDue(sub *model.FeedSubscription, now time.Time) {
+	user, err := b.UserService.GetByID(sub.UserID)
+	if err != nil {
+		log.Warnf("error querying user[%d]: %s", sub.UserID, err)
+		return
+	}
+	if !sub.IsDigestDue(now, b.userLocation(user)) {
+		return
+	}
+	f, err := b.FeedService.GetByID(sub.FeedID)
+	if err != nil {
+		log.Warnf("error querying feed[%d]: %s", sub.FeedID, err)
+		return
+	}
+	b.printFeedSubscription(f, sub, &now)
+}
+
+// printFeedSubscription prints the items of f not printed to sub yet, digestAt is the time of a digest.
+func (b *Bot) printFeedSubscription(f *model.Feed, sub *model.FeedSubscription, digestAt *time.Time) {
+	items, err := b.FeedService.ListItemsAfter(f.ID, sub.LastItemID, feedMaxItemsPerPrint)
+	if err != nil {
+		log.Warnf("error querying items of feed[%d]: %s", f.ID, err)
+		return
+	}
+	lastItemID := sub.LastItemID
+	if len(items) > 0 {
+		user, err := b.UserService.GetByID(sub.UserID)
+		if err != nil {
+			log.Warnf("error querying user[%d]: %s", sub.UserID, err)
+			return
+		}
+		switch b.printFeedItems(user, f, items) {
+		case printRequestError, printShuttingDown:
+			// try again next time
+			return
+		}
+		lastItemID = items[len(items)-1].ID
+	}
+	if lastItemID == sub.LastItemID && digestAt == nil {
+		return
+	}
+	if err := b.FeedService.MarkPrinted(sub.ID, lastItemID, digestAt); err != nil {
+		log.Warnf("error marking feed subscription[%d] printed: %s", sub.ID, err)
+	}
+}
+
+// printFeedItems prints items of f to the device of user as one document and returns the outcome.
+func (b *Bot) printFeedItems(user *model.User, f *model.Feed, items []model.FeedItem) string {
+	device, err := b.printableDevice(user)
+	if service.IsRecordNotFoundError(err) {
+		return printNotSuccessful
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		return printRequestError
+	}
+
+	lang := langOf(user, "")
+	doc, text, err := renderFeedItems(f.Name(), deviceLocation(device), items)
+	if err != nil {
+		log.Warnf("error rendering items of feed[%d]: %s", f.ID, err)
+		return printNotSuccessful
+	}
+	reply, outcome := b.printDocument(lang, user, device, doc, "", text)
+	if outcome != printSuccess {
+		log.Warnf("error printing feed[%d] for user[%d]: %s", f.ID, user.ID, reply)
+	}
+	return outcome
+}
+
+// renderFeedItems renders items of the feed named name with their links as QR codes, the text of the
+// document is returned as well.
+func renderFeedItems(name string, loc *time.Location, items []model.FeedItem) (*memobird.Document, string, error) {
+	doc := new(memobird.Document)
+	texts := []string{name}
+	if err := doc.AddText(name); err != nil {
+		return nil, "", err
+	}
+	for _, item := range items {
+		lines := []string{"", item.Title}
+		if item.PublishedAt != nil {
+			lines = append(lines, item.PublishedAt.In(loc).Format("2006-01-02 15:04"))
+		}
+		if item.Summary != "" {
+			lines = append(lines, item.Summary)
+		}
+		text := strings.Join(lines, "\n")
+		texts = append(texts, text)
+		if err := doc.AddText(text); err != nil {
+			return nil, "", err
+		}
+		if item.Link != "" {
+			if err := doc.AddQRCode(item.Link); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	return doc, strings.Join(texts, "\n"), nil
+}
@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/feed"
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestNewFeedItem(t *testing.T) {
+	item := newFeedItem(feed.Item{GUID: "1", Title: "Hello"})
+	assert.Equal(t, "1", item.GUID)
+	assert.Nil(t, item.PublishedAt)
+
+	at := time.Date(2019, 10, 1, 8, 30, 0, 0, time.UTC)
+	item = newFeedItem(feed.Item{GUID: "2", Published: at})
+	if assert.NotNil(t, item.PublishedAt) {
+		assert.Equal(t, at, *item.PublishedAt)
+	}
+}
+
+func TestFeedSchedule(t *testing.T) {
+	assert.Equal(t, "as they come", feedSchedule(i18n.English, &model.FeedSubscription{}))
+	assert.Equal(t, "daily at 08:00", feedSchedule(i18n.English, &model.FeedSubscription{DigestTime: "08:00"}))
+}
+
+func TestRenderFeedItems(t *testing.T) {
+	at := time.Date(2019, 10, 1, 8, 30, 0, 0, time.UTC)
+	items := []model.FeedItem{
+		{Title: "First", Summary: "Something happened", Link: "https://example.com/1", PublishedAt: &at},
+		{Title: "Second"},
+	}
+	doc, text, err := renderFeedItems("Example", time.UTC, items)
+	assert.NoError(t, err)
+	assert.Equal(t, "Example\n\nFirst\n2019-10-01 08:30\nSomething happened\n\nSecond", text)
+	// name, two items and one QR code
+	assert.Len(t, strings.Split(doc.String(), "|"), 4)
+}
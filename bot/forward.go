@@ -0,0 +1,305 @@
+package bot
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	// forwardBatchWindow is how long to wait for more messages of an album or several forwards,
+	// which are delivered as separate updates.
+	forwardBatchWindow = 2 * time.Second
+	// forwardBatchMax is the number of messages after which a batch is printed without waiting.
+	forwardBatchMax = 50
+)
+
+// isForwarded returns whether msg is forwarded, including ones from users who hide their accounts.
+func isForwarded(msg *tb.Message) bool {
+	return msg.IsForwarded() || msg.OriginalUnixtime != 0
+}
+
+// forwardBatch is the messages to be printed together in a chat.
+type forwardBatch struct {
+	messages []*message
+	timer    *time.Timer
+	done     func()
+}
+
+// forwardBatches collects messages sent together by chat, a batch is flushed once no more messages
+// come within window.
+type forwardBatches struct {
+	window time.Duration
+	flush  func(messages []*message, done func())
+
+	mu      sync.Mutex
+	pending map[int64]*forwardBatch
+}
+
+// has returns whether messages of chat are being collected.
+func (f *forwardBatches) has(chatID int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.pending[chatID]
+	return ok
+}
+
+// add adds m to the batch of its chat, begin is called to start a batch and the batch is dropped if
+// begin returns false.
+func (f *forwardBatches) add(m *message, begin func() (func(), bool)) bool {
+	chatID := m.Chat.ID
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	batch, ok := f.pending[chatID]
+	if !ok {
+		done, ok := begin()
+		if !ok {
+			return false
+		}
+		if f.pending == nil {
+			f.pending = make(map[int64]*forwardBatch)
+		}
+		batch = &forwardBatch{done: done}
+		batch.timer = time.AfterFunc(f.window, func() { f.flushBatch(chatID, batch) })
+		f.pending[chatID] = batch
+	}
+
+	batch.messages = append(batch.messages, m)
+	if len(batch.messages) >= forwardBatchMax {
+		batch.timer.Stop()
+		go f.flushBatch(chatID, batch)
+	} else {
+		batch.timer.Reset(f.window)
+	}
+	return true
+}
+
+func (f *forwardBatches) flushBatch(chatID int64, batch *forwardBatch) {
+	f.mu.Lock()
+	if f.pending[chatID] != batch {
+		// flushed already
+		f.mu.Unlock()
+		return
+	}
+	delete(f.pending, chatID)
+	f.mu.Unlock()
+
+	// updates are handled concurrently so they may arrive out of order
+	sort.SliceStable(batch.messages, func(i, j int) bool {
+		return batch.messages[i].ID < batch.messages[j].ID
+	})
+	f.flush(batch.messages, batch.done)
+}
+
+// batchForward queues m to be printed with the messages sent together.
+func (b *Bot) batchForward(m *message) {
+	ok := b.forwards.add(m, func() (func(), bool) {
+		return b.jobs.begin(fmt.Sprintf("collecting forwards of user[%d]", m.SenderUser.ID))
+	})
+	if !ok {
+		b.Send(m.Sender, m.T(replyShuttingDown))
+	}
+}
+
+// handleMedia handles photos and videos, videos are printed only in drafts, albums or forwarded.
+func (b *Bot) handleMedia(msg *tb.Message) {
+	m, ok := b.receive(msg)
+	if !ok {
+		return
+	}
+	m.Payload = msg.Caption
+	if b.addToDraft(m) {
+		b.metrics.updates.Inc("draft")
+		return
+	}
+	if msg.AlbumID == "" && !isForwarded(msg) && msg.Photo == nil {
+		return
+	}
+	b.metrics.updates.Inc("forward")
+	b.batchForward(m)
+}
+
+// printForwards prints messages as one slip, done is called once printed.
+func (b *Bot) printForwards(messages []*message, done func()) {
+	defer done()
+	first := messages[0]
+
+	device, err := b.printableDevice(first.SenderUser)
+	if err != nil && !service.IsRecordNotFoundError(err) {
+		log.Warn("error querying device:", err)
+		return
+	}
+	reply := first.T(replyBindHelp)
+	if err == nil {
+		reply = b.printForwardParts(first, device, forwardParts(first.Lang, deviceLocation(device), messages))
+	}
+
+	b.Send(first.Sender, reply, &tb.SendOptions{
+		ReplyTo:   first.Message,
+		ParseMode: tb.ModeMarkdown,
+	})
+}
+
+// printForwardParts prints parts sent by m to device and returns the reply. Texts are wrapped with the
+// template, while photos are printed in a document as drafts are.
+func (b *Bot) printForwardParts(m *message, device *model.Device, parts []forwardPart) string {
+	body := renderForwards(parts)
+	photoFileID := forwardPhotoFileID(parts)
+	if photoFileID == "" {
+		reply, _ := b.printSentInJob(m.Lang, m.SenderUser, device, body, m.Time())
+		return reply
+	}
+	doc, err := forwardDocument(parts, b.downloadImage)
+	if err != nil {
+		log.Warnf("error rendering forwards of user[%d]: %s", m.SenderUser.ID, err)
+		return m.T(replyFailedSendingMessage, i18n.Params{"error": err})
+	}
+	reply, _ := b.printDocumentInJob(m.Lang, m.SenderUser, device, doc, photoFileID, body)
+	return reply
+}
+
+// forwardSender returns the name of who wrote the forwarded msg.
+func forwardSender(lang i18n.Lang, msg *tb.Message) string {
+	switch {
+	case msg.OriginalChat != nil:
+		return msg.OriginalChat.Title
+	case msg.OriginalSender != nil:
+		return fullName(msg.OriginalSender)
+	}
+	return catalogs.T(lang, replyForwardHiddenSender)
+}
+
+// forwardAttribution returns who wrote the forwarded msg and when in loc.
+func forwardAttribution(lang i18n.Lang, loc *time.Location, msg *tb.Message) string {
+	return fmt.Sprintf("%s (%s):", forwardSender(lang, msg),
+		time.Unix(int64(msg.OriginalUnixtime), 0).In(loc).Format("2006-01-02 15:04"))
+}
+
+// forwardPart is what a message of forwards prints, empty fields are omitted.
+type forwardPart struct {
+	// attribution is who wrote the message and when, empty if it's the same as the one before.
+	attribution string
+	// photo is printed in place of media if it can be downloaded.
+	photo *tb.Photo
+	// media is the placeholder of the photo or video.
+	media string
+	text  string
+}
+
+// lines returns the lines printed for the part with media as the placeholder.
+func (p forwardPart) lines() []string {
+	var lines []string
+	for _, line := range []string{p.attribution, p.media, p.text} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// forwardParts returns the parts of messages in order, forwarded ones are attributed to their authors with
+// times in loc, the attribution is omitted if it's the same as the one before.
+func forwardParts(lang i18n.Lang, loc *time.Location, messages []*message) []forwardPart {
+	var parts []forwardPart
+	lastAttribution := ""
+	for _, m := range messages {
+		var part forwardPart
+		if isForwarded(m.Message) {
+			attribution := forwardAttribution(lang, loc, m.Message)
+			if attribution != lastAttribution {
+				part.attribution = attribution
+			}
+			lastAttribution = attribution
+		} else {
+			lastAttribution = ""
+		}
+
+		switch {
+		case m.Photo != nil:
+			part.photo = m.Photo
+			part.media = catalogs.T(lang, replyMediaPhoto)
+		case m.Video != nil:
+			part.media = catalogs.T(lang, replyMediaVideo)
+		}
+		part.text = strings.TrimSpace(m.Payload)
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// renderForwards renders the text of parts, photos are placeholders.
+func renderForwards(parts []forwardPart) string {
+	var texts []string
+	for _, part := range parts {
+		if lines := part.lines(); len(lines) > 0 {
+			texts = append(texts, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// forwardPhotoFileID returns the file of the first photo of parts, empty if none has a photo.
+func forwardPhotoFileID(parts []forwardPart) string {
+	for _, part := range parts {
+		if part.photo != nil {
+			return part.photo.FileID
+		}
+	}
+	return ""
+}
+
+// forwardDocument renders parts as renderForwards does with photos downloaded by download, the placeholder
+// of a photo is printed instead if it can't be downloaded.
+func forwardDocument(parts []forwardPart, download func(fileID string) (image.Image, error)) (*memobird.Document, error) {
+	doc := new(memobird.Document)
+	addText := func(lines ...string) error {
+		var nonEmpty []string
+		for _, line := range lines {
+			if line != "" {
+				nonEmpty = append(nonEmpty, line)
+			}
+		}
+		if len(nonEmpty) == 0 {
+			return nil
+		}
+		return doc.AddText(strings.Join(nonEmpty, "\n"))
+	}
+	for _, part := range parts {
+		if part.photo == nil {
+			if err := addText(part.lines()...); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		img, err := download(part.photo.FileID)
+		if err != nil {
+			log.Warnf("error downloading forwarded photo[%s]: %s", part.photo.FileID, err)
+			if err := addText(part.lines()...); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := addText(part.attribution); err != nil {
+			return nil, err
+		}
+		if err := doc.AddImage(img); err != nil {
+			return nil, err
+		}
+		if err := addText(part.text); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"errors"
+	"image"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestIsForwarded(t *testing.T) {
+	assert.False(t, isForwarded(&tb.Message{}))
+	assert.True(t, isForwarded(&tb.Message{OriginalSender: &tb.User{}}))
+	assert.True(t, isForwarded(&tb.Message{OriginalChat: &tb.Chat{}}))
+	// the sender hides the account
+	assert.True(t, isForwarded(&tb.Message{OriginalUnixtime: 1569918600}))
+}
+
+func TestRenderForwards(t *testing.T) {
+	alice := &tb.User{FirstName: "Alice", LastName: "Liddell"}
+	at := 1569918600 // 2019-10-01 08:30 UTC
+	messages := []*message{
+		{Message: &tb.Message{OriginalSender: alice, OriginalUnixtime: at}, Payload: "Hi"},
+		{Message: &tb.Message{OriginalSender: alice, OriginalUnixtime: at + 10}, Payload: "How are you?"},
+		{Message: &tb.Message{OriginalChat: &tb.Chat{Title: "News"}, OriginalUnixtime: at + 3600, Photo: &tb.Photo{}}, Payload: "Sunrise"},
+		{Message: &tb.Message{OriginalUnixtime: at + 7200}, Payload: "Secret"},
+		{Message: &tb.Message{}, Payload: "What a day"},
+	}
+	assert.Equal(t, `Alice Liddell (2019-10-01 08:30):
+Hi
+
+How are you?
+
+News (2019-10-01 09:30):
+[Photo]
+Sunrise
+
+Hidden user (2019-10-01 10:30):
+Secret
+
+What a day`, renderForwards(forwardParts(i18n.English, time.UTC, messages)))
+}
+
+func TestForwardDocument(t *testing.T) {
+	parts := []forwardPart{
+		{attribution: "News (2019-10-01 09:30):", photo: &tb.Photo{File: tb.File{FileID: "sunrise"}}, media: "[Photo]", text: "Sunrise"},
+		{photo: &tb.Photo{File: tb.File{FileID: "gone"}}, media: "[Photo]"},
+		{text: "What a day"},
+	}
+	assert.Equal(t, "sunrise", forwardPhotoFileID(parts))
+	assert.Equal(t, "gone", forwardPhotoFileID(parts[1:]))
+	assert.Empty(t, forwardPhotoFileID(parts[2:]))
+
+	var downloaded []string
+	doc, err := forwardDocument(parts, func(fileID string) (image.Image, error) {
+		downloaded = append(downloaded, fileID)
+		if fileID == "gone" {
+			return nil, errors.New("not found")
+		}
+		return image.NewGray(image.Rect(0, 0, 8, 8)), nil
+	})
+	if assert.NoError(t, err) {
+		// the attribution, photo and caption, the placeholder of the one not downloaded and the text
+		assert.Len(t, strings.Split(doc.String(), "|"), 5)
+		assert.Contains(t, doc.String(), "P:")
+	}
+	assert.Equal(t, []string{"sunrise", "gone"}, downloaded)
+}
+
+func TestForwardBatches(t *testing.T) {
+	flushed := make(chan []*message, 2)
+	var f forwardBatches
+	f.window = 20 * time.Millisecond
+	f.flush = func(messages []*message, done func()) {
+		done()
+		flushed <- messages
+	}
+	var begins, dones int32
+	begin := func() (func(), bool) {
+		atomic.AddInt32(&begins, 1)
+		return func() { atomic.AddInt32(&dones, 1) }, true
+	}
+
+	chat := &tb.Chat{ID: 1}
+	assert.False(t, f.has(chat.ID))
+	assert.True(t, f.add(&message{Message: &tb.Message{ID: 2, Chat: chat}}, begin))
+	assert.True(t, f.add(&message{Message: &tb.Message{ID: 1, Chat: chat}}, begin))
+	assert.True(t, f.add(&message{Message: &tb.Message{ID: 3, Chat: &tb.Chat{ID: 2}}}, begin))
+	assert.True(t, f.has(chat.ID))
+
+	var ids []int
+	for i := 0; i < 2; i++ {
+		select {
+		case messages := <-flushed:
+			for _, m := range messages {
+				ids = append(ids, m.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("batch not flushed")
+		}
+	}
+	assert.ElementsMatch(t, []int{1, 2, 3}, ids)
+	assert.False(t, f.has(chat.ID))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&begins))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&dones))
+
+	assert.False(t, f.add(&message{Message: &tb.Message{ID: 4, Chat: chat}}, func() (func(), bool) {
+		return nil, false
+	}))
+	assert.False(t, f.has(chat.ID))
+}
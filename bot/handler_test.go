@@ -0,0 +1,219 @@
+package bot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	tb "gopkg.in/tucnak/telebot.v2"
+
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/memobird/memobirdtest"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/repository"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+)
+
+// fakeTelegram is a telegram bot API which records the messages sent.
+type fakeTelegram struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var result interface{}
+	switch path.Base(r.URL.Path) {
+	case "getMe":
+		result = tb.User{ID: 1, FirstName: "Memobird", Username: "memobird_bot"}
+	case "sendMessage":
+		var req struct {
+			ChatID string `json:"chat_id"`
+			Text   string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.texts = append(f.texts, req.Text)
+		f.mu.Unlock()
+		chatID, _ := strconv.ParseInt(req.ChatID, 10, 64)
+		result = map[string]interface{}{
+			"message_id": len(f.texts),
+			"date":       time.Now().Unix(),
+			"chat":       map[string]interface{}{"id": chatID, "type": "private"},
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+// lastText returns the last message sent.
+func (f *fakeTelegram) lastText() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.texts) == 0 {
+		return ""
+	}
+	return f.texts[len(f.texts)-1]
+}
+
+// memoryUsages counts usages in memory.
+type memoryUsages struct {
+	mu     sync.Mutex
+	usages []model.Usage
+}
+
+func (u *memoryUsages) New(usage *model.Usage) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.usages = append(u.usages, *usage)
+	return nil
+}
+
+func (u *memoryUsages) ListByDeviceID(deviceID uint, since time.Time) ([]model.Usage, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var usages []model.Usage
+	for _, usage := range u.usages {
+		if usage.DeviceID == deviceID && !usage.CreatedAt.Before(since) {
+			usages = append(usages, usage)
+		}
+	}
+	return usages, nil
+}
+
+// memoryAttempts records print attempts in memory.
+type memoryAttempts struct {
+	mu       sync.Mutex
+	attempts []model.PrintAttempt
+}
+
+func (p *memoryAttempts) New(attempt *model.PrintAttempt) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts = append(p.attempts, *attempt)
+	return nil
+}
+
+func (p *memoryAttempts) ListFailedByUserID(userID uint, limit int) ([]model.PrintAttempt, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var failed []model.PrintAttempt
+	for i := len(p.attempts) - 1; i >= 0 && len(failed) < limit; i-- {
+		if a := p.attempts[i]; a.UserID == userID && a.Error != "" {
+			failed = append(failed, a)
+		}
+	}
+	return failed, nil
+}
+
+func (p *memoryAttempts) DailyStats(since time.Time, loc *time.Location) ([]model.DailyPrintStat, error) {
+	return nil, nil
+}
+
+// recordingBird records the texts printed by a BirdService.
+type recordingBird struct {
+	*service.Bird
+	texts []string
+}
+
+func (r *recordingBird) PrintTextToBird(birdID, text string) (*memobird.PrintResult, error) {
+	r.texts = append(r.texts, text)
+	return r.Bird.PrintTextToBird(birdID, text)
+}
+
+func TestBindVerifySend(t *testing.T) {
+	telegram := &fakeTelegram{}
+	server := httptest.NewServer(telegram)
+	defer server.Close()
+
+	devices := &repository.MemoryDevices{}
+	contents := &repository.MemoryContents{}
+	attempts := &memoryAttempts{}
+	usages := &memoryUsages{}
+	app := memobirdtest.NewApp()
+	bird := &recordingBird{Bird: &service.Bird{BirdApp: app}}
+	b, err := New(&Config{
+		APIURL:              server.URL,
+		Token:               "token",
+		Features:            Features{History: true},
+		UserService:         &service.User{Repo: &repository.MemoryUsers{}},
+		DeviceService:       &service.Device{Repo: devices},
+		BirdService:         bird,
+		ContentService:      &service.Content{Repo: contents},
+		UsageService:        usages,
+		PrintAttemptService: attempts,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	lang := langOf(&model.User{}, "en")
+	sender := &tb.User{ID: 42, FirstName: "Alice", LanguageCode: "en"}
+	messageID := 0
+	handle := func(text string) string {
+		messageID++
+		b.handleText(&tb.Message{
+			ID:     messageID,
+			Sender: sender,
+			Chat:   &tb.Chat{ID: int64(sender.ID), Type: tb.ChatPrivate},
+			Text:   text,
+		})
+		return telegram.lastText()
+	}
+
+	assert.Equal(t, catalogs.T(lang, replyBindHelp), handle("/send hello"))
+	assert.Equal(t, catalogs.T(lang, replyVerificationSent), handle("/bind bird"))
+	assert.True(t, app.IsBound("bird"))
+	device, err := devices.GetByMemobirdID("bird")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, device.IsVerified())
+	assert.Equal(t, catalogs.T(lang, replyBindHelp), handle("/send hello"), "unverified devices are not printable")
+
+	assert.Equal(t, catalogs.T(lang, replyVerificationFailed), handle("/verify 1"))
+	assert.Equal(t, catalogs.T(lang, replyBindComplete), handle("/verify "+strconv.FormatInt(device.VerificationCode, 10)))
+	assert.Equal(t, catalogs.T(lang, replyCheckMemobirdID), handle("/bind bird"))
+
+	assert.Equal(t, catalogs.T(lang, replyTemplateSet), handle("/template set postcard"))
+	assert.Equal(t, catalogs.T(lang, replySent), handle("/send hello"))
+	if assert.NotEmpty(t, bird.texts) {
+		assert.Contains(t, bird.texts[len(bird.texts)-1], "From: Alice")
+	}
+	// the verification instruction is printed by binding
+	prints := app.Prints()
+	if assert.Len(t, prints, 2) {
+		assert.Equal(t, "bird", prints[1].DeviceID)
+	}
+	history, total, err := contents.ListByUserID(device.UserID, 0, 10)
+	if assert.NoError(t, err) && assert.Equal(t, 1, total) {
+		assert.Equal(t, "hello", history[0].Text, "the template is applied when printing only")
+		assert.True(t, history[0].IsPrinted)
+	}
+	if assert.Len(t, usages.usages, 1) {
+		assert.Equal(t, 5, usages.usages[0].Chars)
+	}
+	if assert.Len(t, attempts.attempts, 1) {
+		assert.Empty(t, attempts.attempts[0].Error)
+	}
+
+	owner := &model.User{}
+	owner.ID = device.UserID
+	doc := new(memobird.Document)
+	assert.NoError(t, doc.AddText("photo"))
+	reply, _ := b.printDocument(lang, owner, device, doc, "photo-file", "")
+	assert.Equal(t, catalogs.T(lang, replySent), reply)
+	history, _, err = contents.ListByUserID(device.UserID, 0, 10)
+	if assert.NoError(t, err) && assert.Len(t, history, 2) {
+		assert.Equal(t, "photo-file", history[0].ImageFileID, "the photo is recorded for reprinting")
+	}
+}
@@ -0,0 +1,328 @@
+package bot
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	historyPageSize    = 5
+	historyMaxPageSize = 10
+	historyPreviewLen  = 32
+)
+
+// callback endpoints of the history keyboard.
+var (
+	btnHistoryPage    = tb.InlineButton{Unique: "history_page"}
+	btnHistoryReprint = tb.InlineButton{Unique: "history_reprint"}
+	btnHistoryForget  = tb.InlineButton{Unique: "history_forget"}
+)
+
+func (b *Bot) handleHistory(m *message) {
+	size := historyPageSize
+	if n, err := strconv.Atoi(strings.TrimSpace(m.Payload)); err == nil {
+		size = n
+	}
+
+	b.pruneHistory(m.SenderUser)
+	txt, markup, err := b.renderHistoryPage(m.Lang, m.SenderUser.ID, 0, size)
+	if err != nil {
+		log.Warnf("error rendering history of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	b.Send(m.Sender, txt, markup)
+}
+
+func (b *Bot) handleRetention(m *message) {
+	payload := strings.TrimSpace(m.Payload)
+	if payload == "" {
+		b.Send(m.Sender, retentionReply(m.Lang, m.SenderUser.HistoryRetentionDays))
+		return
+	}
+
+	days, err := strconv.Atoi(payload)
+	if err != nil || days < 0 {
+		b.Send(m.Sender, m.T(replyRetentionHelp))
+		return
+	}
+	if err := b.UserService.SetHistoryRetentionDays(m.SenderUser.ID, days); err != nil {
+		log.Warnf("error setting retention of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	m.SenderUser.HistoryRetentionDays = days
+	b.pruneHistory(m.SenderUser)
+	b.Send(m.Sender, retentionReply(m.Lang, days))
+}
+
+func retentionReply(lang i18n.Lang, days int) string {
+	if days == 0 {
+		return catalogs.T(lang, replyRetentionForever)
+	}
+	return catalogs.N(lang, replyRetention, days)
+}
+
+// recordContent saves the content to the history of user.
+func (b *Bot) recordContent(user *model.User, content *model.Content) {
+	if !b.Features.History {
+		return
+	}
+	if err := b.ContentService.New(content); err != nil {
+		log.Warnf("error recording content of user[%d]: %s", user.ID, err)
+		return
+	}
+	b.pruneHistory(user)
+}
+
+// recordAttempt counts an attempt of user to print on device for stats, errMsg is empty if it succeeded.
+// Unlike contents, attempts are kept regardless of the history.
+func (b *Bot) recordAttempt(user *model.User, device *model.Device, errMsg string) {
+	err := b.PrintAttemptService.New(&model.PrintAttempt{
+		UserID:   user.ID,
+		DeviceID: device.ID,
+		Error:    errMsg,
+	})
+	if err != nil {
+		log.Warnf("error recording print attempt of user[%d]: %s", user.ID, err)
+	}
+}
+
+// pruneHistory deletes contents of user that are older than the retention.
+func (b *Bot) pruneHistory(user *model.User) {
+	if user.HistoryRetentionDays <= 0 {
+		return
+	}
+	before := time.Now().AddDate(0, 0, -user.HistoryRetentionDays)
+	if _, err := b.ContentService.PruneByUserID(user.ID, before); err != nil {
+		log.Warnf("error pruning contents of user[%d]: %s", user.ID, err)
+	}
+}
+
+// clampHistoryPage limits size to 1..historyMaxPageSize and page to the ones of at most maxInt32 contents,
+// as both may come from callback data. Pages after the last one are left to renderHistoryPage.
+func clampHistoryPage(page, size int) (int, int) {
+	if size < 1 {
+		size = 1
+	}
+	if size > historyMaxPageSize {
+		size = historyMaxPageSize
+	}
+	if page < 0 {
+		page = 0
+	}
+	if page > math.MaxInt32/size {
+		page = math.MaxInt32 / size
+	}
+	return page, size
+}
+
+// renderHistoryPage renders the contents of user on page of size, the last page is shown if page is after
+// it, e.g. the last one was emptied.
+func (b *Bot) renderHistoryPage(lang i18n.Lang, userID uint, page, size int) (string, *tb.ReplyMarkup, error) {
+	page, size = clampHistoryPage(page, size)
+	contents, total, err := b.ContentService.ListByUserID(userID, page*size, size)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(contents) == 0 && total > 0 {
+		page = (total - 1) / size
+		contents, total, err = b.ContentService.ListByUserID(userID, page*size, size)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if len(contents) == 0 {
+		return catalogs.T(lang, replyHistoryEmpty), &tb.ReplyMarkup{}, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(catalogs.T(lang, replyHistoryPage, i18n.Params{
+		"from":  page*size + 1,
+		"to":    page*size + len(contents),
+		"total": total,
+	}))
+	var keyboard [][]tb.InlineButton
+	for _, c := range contents {
+		sb.WriteString(fmt.Sprintf("\n#%d %s %s\n%s\n",
+			c.ID, c.CreatedAt.Format("2006-01-02 15:04"), printedMark(c.IsPrinted), contentPreview(lang, c)))
+
+		data := fmt.Sprintf("%d|%d|%d", c.ID, page, size)
+		keyboard = append(keyboard, []tb.InlineButton{
+			{Unique: btnHistoryReprint.Unique, Text: catalogs.T(lang, replyHistoryReprint, i18n.Params{"id": c.ID}), Data: data},
+			{Unique: btnHistoryForget.Unique, Text: catalogs.T(lang, replyHistoryForget, i18n.Params{"id": c.ID}), Data: data},
+		})
+	}
+
+	var nav []tb.InlineButton
+	if page > 0 {
+		nav = append(nav, tb.InlineButton{Unique: btnHistoryPage.Unique, Text: catalogs.T(lang, replyHistoryNewer), Data: fmt.Sprintf("%d|%d", page-1, size)})
+	}
+	if (page+1)*size < total {
+		nav = append(nav, tb.InlineButton{Unique: btnHistoryPage.Unique, Text: catalogs.T(lang, replyHistoryOlder), Data: fmt.Sprintf("%d|%d", page+1, size)})
+	}
+	if len(nav) > 0 {
+		keyboard = append(keyboard, nav)
+	}
+	return sb.String(), &tb.ReplyMarkup{InlineKeyboard: keyboard}, nil
+}
+
+func printedMark(isPrinted bool) string {
+	if isPrinted {
+		return "✅"
+	}
+	return "❔"
+}
+
+func contentPreview(lang i18n.Lang, c model.Content) string {
+	if c.Text == "" && c.HasImage() {
+		return catalogs.T(lang, replyHistoryImage)
+	}
+	txt := []rune(strings.Join(strings.Fields(c.Text), " "))
+	if len(txt) > historyPreviewLen {
+		return string(txt[:historyPreviewLen]) + "…"
+	}
+	return string(txt)
+}
+
+// parseCallbackInts parses n integers separated by "|" from the data of a callback.
+func parseCallbackInts(data string, n int) ([]int, error) {
+	parts := strings.Split(data, "|")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expecting %d values, got %d", n, len(parts))
+	}
+	values := make([]int, n)
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("parsing value[%s]: %w", p, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func (b *Bot) refreshHistoryPage(c *callback, page, size int) {
+	txt, markup, err := b.renderHistoryPage(c.Lang, c.SenderUser.ID, page, size)
+	if err != nil {
+		log.Warnf("error rendering history of user[%d]: %s", c.SenderUser.ID, err)
+		return
+	}
+	if _, err := b.Edit(c.Message, txt, markup); err != nil {
+		log.Warnf("error editing history of user[%d]: %s", c.SenderUser.ID, err)
+	}
+}
+
+func (b *Bot) handleHistoryPage(c *callback) {
+	defer b.Respond(c.Callback)
+	values, err := parseCallbackInts(c.Data, 2)
+	if err != nil {
+		log.Warnf("error parsing callback data[%s]: %s", c.Data, err)
+		return
+	}
+	b.refreshHistoryPage(c, values[0], values[1])
+}
+
+// contentDocument renders the image of content downloaded by download with the text under it, transparent
+// images like stickers are flattened onto white.
+func contentDocument(content *model.Content, download func(fileID string) (image.Image, error)) (*memobird.Document, error) {
+	img, err := download(content.ImageFileID)
+	if err != nil {
+		return nil, err
+	}
+	width := img.Bounds().Dx()
+	if width > memobird.PaperWidth {
+		width = memobird.PaperWidth
+	}
+	doc := new(memobird.Document)
+	if err := doc.AddImage(memobird.Flatten(img, width)); err != nil {
+		return nil, err
+	}
+	if content.Text != "" {
+		if err := doc.AddText(content.Text); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func (b *Bot) handleHistoryReprint(c *callback) {
+	values, err := parseCallbackInts(c.Data, 3)
+	if err != nil {
+		log.Warnf("error parsing callback data[%s]: %s", c.Data, err)
+		b.Respond(c.Callback)
+		return
+	}
+
+	content, err := b.ContentService.GetByIDAndUserID(uint(values[0]), c.SenderUser.ID)
+	if service.IsRecordNotFoundError(err) {
+		c.Answer(b, replyHistoryNotFound)
+		return
+	}
+	if err != nil {
+		log.Warnf("error querying content[%d]: %s", values[0], err)
+		c.Answer(b, replyFailedGettingData)
+		return
+	}
+	device, err := b.printableDevice(c.SenderUser)
+	if service.IsRecordNotFoundError(err) {
+		b.Respond(c.Callback, &tb.CallbackResponse{Text: c.T(replyBindHelp), ShowAlert: true})
+		return
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		c.Answer(b, replyFailedGettingData)
+		return
+	}
+
+	var reply string
+	if content.HasImage() {
+		doc, err := contentDocument(content, b.downloadImage)
+		if err != nil {
+			log.Warnf("error rendering content[%d]: %s", content.ID, err)
+			b.Respond(c.Callback, &tb.CallbackResponse{Text: c.T(replyFailedSendingMessage, i18n.Params{"error": err}), ShowAlert: true})
+			return
+		}
+		reply, _ = b.printDocument(c.Lang, c.SenderUser, device, doc, content.ImageFileID, content.Text)
+	} else {
+		// the template is applied as of now, like sending the text again
+		reply, _ = b.printSent(c.Lang, c.SenderUser, device, content.Text, time.Now())
+	}
+	b.Respond(c.Callback, &tb.CallbackResponse{Text: reply})
+	b.refreshHistoryPage(c, values[1], values[2])
+}
+
+func (b *Bot) handleHistoryForget(c *callback) {
+	values, err := parseCallbackInts(c.Data, 3)
+	if err != nil {
+		log.Warnf("error parsing callback data[%s]: %s", c.Data, err)
+		b.Respond(c.Callback)
+		return
+	}
+
+	deleted, err := b.ContentService.DeleteByIDAndUserID(uint(values[0]), c.SenderUser.ID)
+	if err != nil {
+		log.Warnf("error deleting content[%d]: %s", values[0], err)
+		c.Answer(b, replyFailedGettingData)
+		return
+	}
+	if !deleted {
+		c.Answer(b, replyHistoryNotFound)
+	} else {
+		c.Answer(b, replyHistoryForgotten)
+	}
+
+	b.refreshHistoryPage(c, values[1], values[2])
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"errors"
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/repository"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+)
+
+func TestParseCallbackInts(t *testing.T) {
+	values, err := parseCallbackInts("12|0|5", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{12, 0, 5}, values)
+
+	for _, data := range []string{"", "1|2", "1|2|3|4", "a|b|c"} {
+		_, err := parseCallbackInts(data, 3)
+		assert.Error(t, err, data)
+	}
+}
+
+func TestContentPreview(t *testing.T) {
+	for c, expect := range map[*model.Content]string{
+		{Text: "hello"}:                              "hello",
+		{Text: " new\nline  kept\tshort "}:           "new line kept short",
+		{Text: "0123456789012345678901234567890123"}: "01234567890123456789012345678901…",
+		{ImageFileID: "file"}:                        "[image]",
+	} {
+		assert.Equal(t, expect, contentPreview(i18n.English, *c))
+	}
+}
+
+func TestContentDocument(t *testing.T) {
+	download := func(fileID string) (image.Image, error) {
+		if fileID != "file" {
+			return nil, errors.New("not found")
+		}
+		return image.NewGray(image.Rect(0, 0, 8, 8)), nil
+	}
+
+	doc, err := contentDocument(&model.Content{ImageFileID: "file"}, download)
+	if assert.NoError(t, err) {
+		assert.Len(t, strings.Split(doc.String(), "|"), 1)
+	}
+	doc, err = contentDocument(&model.Content{ImageFileID: "file", Text: "caption"}, download)
+	if assert.NoError(t, err) {
+		assert.Len(t, strings.Split(doc.String(), "|"), 2, "image and text")
+	}
+	_, err = contentDocument(&model.Content{ImageFileID: "gone"}, download)
+	assert.Error(t, err)
+}
+
+func TestClampHistoryPage(t *testing.T) {
+	for _, c := range []struct{ page, size, expectPage, expectSize int }{
+		{0, 5, 0, 5},
+		{-1, 0, 0, 1},
+		{2, -1, 2, 1},
+		{1, 100, 1, historyMaxPageSize},
+		{math.MaxInt64, 1, math.MaxInt32, 1},
+	} {
+		page, size := clampHistoryPage(c.page, c.size)
+		assert.Equal(t, c.expectPage, page)
+		assert.Equal(t, c.expectSize, size)
+	}
+}
+
+func TestRenderHistoryPageAfterLast(t *testing.T) {
+	contents := &repository.MemoryContents{}
+	for _, text := range []string{"a", "b", "c"} {
+		assert.NoError(t, contents.Create(&model.Content{UserID: 1, Text: text}))
+	}
+	b := &Bot{Config: &Config{ContentService: &service.Content{Repo: contents}}}
+	last, _, err := b.renderHistoryPage(i18n.English, 1, 2, 1)
+	assert.NoError(t, err)
+	assert.Contains(t, last, "\na\n")
+
+	txt, _, err := b.renderHistoryPage(i18n.English, 1, 999999, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, last, txt, "the last page is shown instead")
+	txt, _, err = b.renderHistoryPage(i18n.English, 1, -1, 0)
+	assert.NoError(t, err)
+	assert.Contains(t, txt, "\nc\n", "the first page of one is shown")
+
+	txt, _, err = b.renderHistoryPage(i18n.English, 2, 5, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, catalogs.T(i18n.English, replyHistoryEmpty), txt)
+}
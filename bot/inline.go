@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// inlineCacheTime is how long telegram may cache the results of an inline query of a user.
+const inlineCacheTime = 10 * time.Second
+
+// inlineStartParameter is sent with /start when the user switches to the private chat from inline results.
+const inlineStartParameter = "inline"
+
+// handleQuery offers printing the text of q to each device the user can print to.
+func (b *Bot) handleQuery(q *tb.Query) {
+	b.metrics.updates.Inc("inline_query")
+	b.activeUsers.touch(q.From.ID, time.Now())
+	resp := &tb.QueryResponse{
+		CacheTime:  int(inlineCacheTime / time.Second),
+		IsPersonal: true,
+	}
+	defer func() {
+		if err := b.Answer(q, resp); err != nil {
+			log.Warnf("error answering inline query of telegram user[%d]: %s", q.From.ID, err)
+		}
+	}()
+
+	user, err := b.UserService.GetByTelegramID(q.From.ID)
+	if err != nil && !service.IsRecordNotFoundError(err) {
+		log.Warnf("error getting user by telegram ID[%d]: %s", q.From.ID, err)
+		return
+	}
+	if err != nil {
+		// never talked to the bot
+		user = &model.User{}
+	}
+	lang := langOf(user, q.From.LanguageCode)
+	if user.IsBanned() {
+		return
+	}
+
+	var devices []model.Device
+	if user.ID > 0 {
+		devices, err = b.printableDevices(user)
+		if err != nil {
+			log.Warnf("error querying printable devices of user[%d]: %s", user.ID, err)
+			return
+		}
+	}
+	if len(devices) == 0 {
+		resp.SwitchPMText = catalogs.T(lang, replyInlineBindFirst)
+		resp.SwitchPMParameter = inlineStartParameter
+		return
+	}
+	resp.Results = inlineResults(lang, devices, q.Text)
+}
+
+// inlineResults returns a result printing text for each device, none if text is blank.
+func inlineResults(lang i18n.Lang, devices []model.Device, text string) tb.Results {
+	if strings.TrimSpace(text) == "" {
+		return tb.Results{}
+	}
+	results := make(tb.Results, 0, len(devices))
+	for _, device := range devices {
+		result := &tb.ArticleResult{
+			Title:       catalogs.T(lang, replyInlinePrintTo, i18n.Params{"device": device.Name()}),
+			Description: text,
+			Text:        text,
+		}
+		result.SetResultID(strconv.FormatUint(uint64(device.ID), 10))
+		results = append(results, result)
+	}
+	return results
+}
+
+// handleChosenInlineResult prints the query of r to the device chosen and tells the user privately how
+// it went. Telegram only sends chosen results with inline feedback enabled by @BotFather.
+func (b *Bot) handleChosenInlineResult(r *tb.ChosenInlineResult) {
+	b.metrics.updates.Inc("inline_print")
+	b.activeUsers.touch(r.From.ID, time.Now())
+	user, err := b.upsertUser(&r.From)
+	if err != nil {
+		log.Warnf("error upserting telegram user[%d]: %s", r.From.ID, err)
+		return
+	}
+	if user.IsBanned() {
+		return
+	}
+	lang := langOf(user, r.From.LanguageCode)
+	recipient := &tb.User{ID: r.From.ID}
+
+	deviceID, err := strconv.ParseUint(r.ResultID, 10, 64)
+	if err != nil {
+		log.Warnf("invalid inline result ID[%s] chosen by user[%d]", r.ResultID, user.ID)
+		return
+	}
+	devices, err := b.printableDevices(user)
+	if err != nil {
+		log.Warnf("error querying printable devices of user[%d]: %s", user.ID, err)
+		b.Send(recipient, catalogs.T(lang, replyFailedGettingData))
+		return
+	}
+	device := findDevice(devices, uint(deviceID))
+	if device == nil {
+		// unbound or unshared since the query was answered
+		b.Send(recipient, catalogs.T(lang, replyBindHelp))
+		return
+	}
+
+	reply, _ := b.printSent(lang, user, device, r.Query, time.Now())
+	b.Send(recipient, catalogs.T(lang, replyInlinePrinted, i18n.Params{
+		"device": device.Name(),
+		"text":   r.Query,
+		"result": reply,
+	}))
+}
+
+// findDevice returns the device of id in devices, nil if not found.
+func findDevice(devices []model.Device, id uint) *model.Device {
+	for i := range devices {
+		if devices[i].ID == id {
+			return &devices[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestInlineResults(t *testing.T) {
+	devices := []model.Device{
+		{Model: gorm.Model{ID: 3}, MemobirdID: "abc"},
+		{Model: gorm.Model{ID: 7}, MemobirdID: "def", Nickname: "Kitchen"},
+	}
+	results := inlineResults(i18n.English, devices, "Buy milk")
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, &tb.ArticleResult{
+			ResultBase:  tb.ResultBase{ID: "3"},
+			Title:       "Print to abc",
+			Description: "Buy milk",
+			Text:        "Buy milk",
+		}, results[0])
+		assert.Equal(t, "7", results[1].ResultID())
+		assert.Equal(t, "Print to Kitchen", results[1].(*tb.ArticleResult).Title)
+	}
+	assert.Empty(t, inlineResults(i18n.English, devices, "  "))
+}
+
+func TestFindDevice(t *testing.T) {
+	devices := []model.Device{{Model: gorm.Model{ID: 3}}, {Model: gorm.Model{ID: 7}}}
+	assert.Equal(t, &devices[1], findDevice(devices, 7))
+	assert.Nil(t, findDevice(devices, 5))
+}
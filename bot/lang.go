@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// catalogs contains translations of all replies.
+var catalogs = i18n.Bundle{
+	i18n.English:           catalogEN,
+	i18n.ChineseSimplified: catalogZH,
+}
+
+// langAuto clears the language chosen by user.
+const langAuto = "auto"
+
+// langOf returns the language of user, the one chosen by user wins over the telegram language code.
+func langOf(user *model.User, telegramCode string) i18n.Lang {
+	if lang, ok := catalogs.Match(user.Language); ok {
+		return lang
+	}
+	if telegramCode == "" {
+		telegramCode = user.TelegramLanguageCode
+	}
+	lang, _ := catalogs.Match(telegramCode)
+	return lang
+}
+
+// T translates key to the language of message.
+func (m *message) T(key string, params ...i18n.Params) string {
+	return catalogs.T(m.Lang, key, params...)
+}
+
+// N translates key to the language of message with plural form chosen by count.
+func (m *message) N(key string, count int, params ...i18n.Params) string {
+	return catalogs.N(m.Lang, key, count, params...)
+}
+
+// T translates key to the language of callback.
+func (c *callback) T(key string, params ...i18n.Params) string {
+	return catalogs.T(c.Lang, key, params...)
+}
+
+// Answer responds to the callback with the translation of key.
+func (c *callback) Answer(b *Bot, key string, params ...i18n.Params) error {
+	return b.Respond(c.Callback, &tb.CallbackResponse{Text: c.T(key, params...)})
+}
+
+func supportedLangs() []string {
+	var langs []string
+	for lang := range catalogs {
+		langs = append(langs, string(lang))
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+func (b *Bot) handleLang(m *message) {
+	code := strings.TrimSpace(m.Payload)
+	if code == "" {
+		b.Send(m.Sender, m.T(replyLangCurrent, i18n.Params{"lang": m.T(replyLangName)})+"\n"+
+			m.T(replyLangHelp, i18n.Params{"langs": strings.Join(supportedLangs(), "|")}))
+		return
+	}
+
+	var language string
+	if !strings.EqualFold(code, langAuto) {
+		lang, ok := catalogs.Match(code)
+		if !ok {
+			b.Send(m.Sender, m.T(replyLangHelp, i18n.Params{"langs": strings.Join(supportedLangs(), "|")}))
+			return
+		}
+		language = string(lang)
+	}
+
+	if err := b.UserService.SetLanguage(m.SenderUser.ID, language); err != nil {
+		log.Warnf("error setting language of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	m.SenderUser.Language = language
+	m.Lang = langOf(m.SenderUser, m.Sender.LanguageCode)
+	if language == "" {
+		b.Send(m.Sender, m.T(replyLangAuto))
+		return
+	}
+	b.Send(m.Sender, m.T(replyLangSet, i18n.Params{"lang": m.T(replyLangName)}))
+}
@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// osmZoom is the zoom level of the OpenStreetMap linked by location cards, about a street.
+const osmZoom = 17
+
+// handleLocation prints locations and venues as cards. Live locations are printed once when shared,
+// their updates come as edits of the message which are not printed.
+func (b *Bot) handleLocation(msg *tb.Message) {
+	m, ok := b.receive(msg)
+	if !ok {
+		return
+	}
+	b.metrics.updates.Inc("location")
+
+	device, err := b.printableDevice(m.SenderUser)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyBindHelp))
+		return
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	doc, text, err := renderLocationCard(m.Lang, msg.Location, msg.Venue)
+	if err != nil {
+		log.Warnf("error rendering location of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyLocationFailed, i18n.Params{"error": err}))
+		return
+	}
+	reply, _ := b.printDocument(m.Lang, m.SenderUser, device, doc, "", text)
+	b.Send(m.Sender, reply, &tb.SendOptions{
+		ReplyTo:   m.Message,
+		ParseMode: tb.ModeMarkdown,
+	})
+}
+
+// renderLocationCard renders the card of loc, or venue if not nil, with a QR code of the map, the text
+// of the card is returned as well.
+func renderLocationCard(lang i18n.Lang, loc *tb.Location, venue *tb.Venue) (*memobird.Document, string, error) {
+	if venue != nil {
+		loc = &venue.Location
+	}
+	lat, lng := float64(loc.Lat), float64(loc.Lng)
+	var lines []string
+	if venue != nil {
+		lines = append(lines, venue.Title)
+		if venue.Address != "" {
+			lines = append(lines, venue.Address)
+		}
+	} else {
+		lines = append(lines, catalogs.T(lang, replyLocationTitle))
+	}
+	lines = append(lines, fmt.Sprintf("%.6f, %.6f", lat, lng), formatDMS(lat, lng))
+	text := strings.Join(lines, "\n")
+
+	doc := new(memobird.Document)
+	if err := doc.AddText(text); err != nil {
+		return nil, "", err
+	}
+	if err := doc.AddQRCode(osmURL(lat, lng)); err != nil {
+		return nil, "", err
+	}
+	return doc, text, nil
+}
+
+// formatDMS formats the coordinates in degrees, minutes and seconds, e.g. 31°13'49.5"N 121°28'25.3"E.
+func formatDMS(lat, lng float64) string {
+	return dms(lat, "N", "S") + " " + dms(lng, "E", "W")
+}
+
+// dms formats the coordinate v with the hemisphere of its sign.
+func dms(v float64, positive, negative string) string {
+	hemisphere := positive
+	if v < 0 {
+		hemisphere = negative
+	}
+	// in tenths of seconds so that 59.96" rounds up to the next minute
+	tenths := int64(math.Round(math.Abs(v) * 36000))
+	return fmt.Sprintf(`%d°%02d'%02d.%d"%s`,
+		tenths/36000, tenths%36000/600, tenths%600/10, tenths%10, hemisphere)
+}
+
+// osmURL returns the link to the marked coordinates on OpenStreetMap.
+func osmURL(lat, lng float64) string {
+	return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%.6f&mlon=%.6f#map=%d/%.6f/%.6f",
+		lat, lng, osmZoom, lat, lng)
+}
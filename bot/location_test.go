@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestFormatDMS(t *testing.T) {
+	assert.Equal(t, `31°13'49.5"N 121°28'25.3"E`, formatDMS(31.230416, 121.473701))
+	assert.Equal(t, `33°52'07.8"S 151°12'33.5"W`, formatDMS(-33.868820, -151.209296))
+	assert.Equal(t, `0°00'00.0"N 1°00'00.0"E`, formatDMS(0, 0.999999), "seconds rounded up to a degree")
+}
+
+func TestOSMURL(t *testing.T) {
+	assert.Equal(t, "https://www.openstreetmap.org/?mlat=51.500000&mlon=-0.125000#map=17/51.500000/-0.125000",
+		osmURL(51.5, -0.125))
+}
+
+func TestRenderLocationCard(t *testing.T) {
+	loc := &tb.Location{Lat: 51.5, Lng: -0.125}
+	doc, text, err := renderLocationCard(i18n.English, loc, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Location\n51.500000, -0.125000\n51°30'00.0\"N 0°07'30.0\"W", text)
+	assert.Len(t, strings.Split(doc.String(), "|"), 2, "text and QR code")
+
+	venue := &tb.Venue{Location: *loc, Title: "Big Ben", Address: "Westminster, London"}
+	_, text, err = renderLocationCard(i18n.English, nil, venue)
+	assert.NoError(t, err)
+	assert.Equal(t, "Big Ben\nWestminster, London\n51.500000, -0.125000\n51°30'00.0\"N 0°07'30.0\"W", text)
+}
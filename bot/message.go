@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
 	"github.com/awesome-memobird/the-memobird-bot/model"
 	tb "gopkg.in/tucnak/telebot.v2"
 )
@@ -8,8 +9,17 @@ import (
 type message struct {
 	*tb.Message
 	SenderUser *model.User
+	Lang       i18n.Lang
 	Payload    string
 	Command    string
 }
 
 type ctxHandler func(m *message)
+
+type callback struct {
+	*tb.Callback
+	SenderUser *model.User
+	Lang       i18n.Lang
+}
+
+type ctxCallbackHandler func(c *callback)
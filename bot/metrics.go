@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/metrics"
+)
+
+// activeUsersWindow is the period in which users who sent updates are counted as active.
+const activeUsersWindow = 24 * time.Hour
+
+// outcomes of a print attempt.
+const (
+	printSuccess       = "success"
+	printQuotaExceeded = "quota_exceeded"
+	printShuttingDown  = "shutting_down"
+	printRequestError  = "request_error"
+	printNotSuccessful = "not_successful"
+)
+
+// botMetrics are metrics of the bot.
+type botMetrics struct {
+	updates *metrics.CounterVec
+	prints  *metrics.CounterVec
+}
+
+func (b *Bot) registerMetrics(r *metrics.Registry) {
+	b.metrics = botMetrics{
+		updates: r.NewCounterVec("memobird_bot_updates_total",
+			"Telegram updates handled by command.", "command"),
+		prints: r.NewCounterVec("memobird_bot_prints_total",
+			"Print attempts by outcome.", "outcome"),
+	}
+	r.NewGaugeFunc("memobird_bot_queue_depth",
+		"In-flight prints and other jobs waiting for memobird.", func() float64 {
+			return float64(b.jobs.count())
+		})
+	r.NewGaugeFunc("memobird_bot_active_users",
+		"Users who sent updates in the last 24 hours.", func() float64 {
+			return float64(b.activeUsers.count(time.Now()))
+		})
+}
+
+// activeUsers tracks when users sent their last updates.
+type activeUsers struct {
+	mu   sync.Mutex
+	seen map[int]time.Time
+}
+
+func (a *activeUsers) touch(telegramID int, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.seen == nil {
+		a.seen = make(map[int]time.Time)
+	}
+	a.seen[telegramID] = now
+}
+
+// count returns the number of users seen within activeUsersWindow before now, others are forgotten.
+func (a *activeUsers) count(now time.Time) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, t := range a.seen {
+		if now.Sub(t) > activeUsersWindow {
+			delete(a.seen, id)
+		}
+	}
+	return len(a.seen)
+}
+
+// Ping checks the connectivity to telegram.
+func (b *Bot) Ping() error {
+	data, err := b.Raw("getMe", map[string]string{})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Ok          bool
+		Description string
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("api error: %s", resp.Description)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActiveUsers(t *testing.T) {
+	var a activeUsers
+	now := time.Now()
+	a.touch(1, now.Add(-25*time.Hour))
+	a.touch(2, now.Add(-time.Hour))
+	a.touch(3, now)
+	a.touch(2, now)
+	assert.Equal(t, 2, a.count(now))
+	assert.Equal(t, 0, a.count(now.Add(activeUsersWindow+time.Second)))
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tevino/log"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// pollerRetryInterval is how long to wait before polling again after an error.
+const pollerRetryInterval = time.Second
+
+// poller long polls updates like tb.LongPoller, but it really stops when asked to,
+// updates fetched by the abandoned poll are not acknowledged and will be delivered again.
+type poller struct {
+	Timeout      time.Duration
+	lastUpdateID int
+}
+
+// Poll implements tb.Poller.
+func (p *poller) Poll(b *tb.Bot, dest chan tb.Update, stop chan struct{}) {
+	for {
+		fetched := make(chan []tb.Update, 1)
+		go func(offset int) {
+			updates, err := getUpdates(b, offset, p.Timeout)
+			if err != nil {
+				log.Warn("error getting updates:", err)
+				time.Sleep(pollerRetryInterval)
+			}
+			fetched <- updates
+		}(p.lastUpdateID + 1)
+
+		select {
+		case <-stop:
+			close(stop)
+			return
+		case updates := <-fetched:
+			for _, update := range updates {
+				select {
+				case <-stop:
+					close(stop)
+					return
+				case dest <- update:
+					p.lastUpdateID = update.ID
+				}
+			}
+		}
+	}
+}
+
+func getUpdates(b *tb.Bot, offset int, timeout time.Duration) ([]tb.Update, error) {
+	data, err := b.Raw("getUpdates", map[string]string{
+		"offset":  strconv.Itoa(offset),
+		"timeout": strconv.Itoa(int(timeout / time.Second)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Ok          bool
+		Result      []tb.Update
+		Description string
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parsing updates: %w", err)
+	}
+	if !resp.Ok {
+		return nil, fmt.Errorf("api error: %s", resp.Description)
+	}
+	return resp.Result, nil
+}
@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"bytes"
+	"image"
+	"strings"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// photoMaxRatio is the maximum ratio of the sides of a telegram photo, longer strips are sent as files.
+const photoMaxRatio = 20
+
+// handlePreview replies with how the text in payload, or the draft if none, would be printed.
+func (b *Bot) handlePreview(m *message) {
+	if strings.TrimSpace(m.Payload) != "" {
+		b.previewText(m)
+		return
+	}
+
+	draft, err := b.DraftService.GetByUserID(m.SenderUser.ID)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyPreviewHelp))
+		return
+	}
+	if err != nil {
+		log.Warnf("error querying draft of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	parts := b.draftParts(m, draft)
+	if parts == nil {
+		return
+	}
+	for _, part := range parts {
+		if memobird.HasWideRunes(part.Text) {
+			b.Send(m.Sender, m.T(replyPreviewWide))
+			return
+		}
+	}
+	doc, err := b.draftDocument(parts)
+	if err != nil {
+		log.Warnf("error rendering draft[%d]: %s", draft.ID, err)
+		b.Send(m.Sender, m.T(replyDraftFailed, i18n.Params{"error": err}))
+		return
+	}
+	b.sendPreview(m, doc, m.N(replyDraftPreview, len(parts)))
+}
+
+// previewText previews the payload as printed by /send, with the template of the device if any.
+func (b *Bot) previewText(m *message) {
+	txt := m.Payload
+	device, err := b.printableDevice(m.SenderUser)
+	if err != nil && !service.IsRecordNotFoundError(err) {
+		log.Warn("error querying device:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if err == nil {
+		txt = wrapPrint(m.SenderUser, device, txt, m.Time())
+	}
+	if memobird.HasWideRunes(txt) {
+		b.Send(m.Sender, m.T(replyPreviewWide))
+		return
+	}
+
+	doc := new(memobird.Document)
+	if err := doc.AddText(txt); err != nil {
+		b.Send(m.Sender, m.T(replyPreviewFailed, i18n.Params{"error": err}))
+		return
+	}
+	b.sendPreview(m, doc, "")
+}
+
+// sendPreview renders doc and replies with it as a photo, or a file if too long to be a photo.
+func (b *Bot) sendPreview(m *message, doc *memobird.Document, caption string) {
+	buf, err := memobird.RenderPNG(doc)
+	if err != nil {
+		log.Warnf("error rendering preview for user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyPreviewFailed, i18n.Params{"error": err}))
+		return
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(buf))
+	if err != nil {
+		log.Warnf("error decoding preview for user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyPreviewFailed, i18n.Params{"error": err}))
+		return
+	}
+
+	file := tb.FromReader(bytes.NewReader(buf))
+	var what interface{} = &tb.Photo{File: file, Caption: caption}
+	if config.Height > config.Width*photoMaxRatio {
+		what = &tb.Document{File: file, FileName: "preview.png", Caption: caption}
+	}
+	if _, err := b.Send(m.Sender, what, &tb.SendOptions{ReplyTo: m.Message}); err != nil {
+		log.Warnf("error sending preview to user[%d]: %s", m.SenderUser.ID, err)
+	}
+}
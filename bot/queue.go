@@ -0,0 +1,243 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// btnEditReprint reprints the corrected message replied to.
+var btnEditReprint = tb.InlineButton{Unique: "edit_reprint"}
+
+// messageEditWindow is how long telegram allows messages to be edited, printed messages are remembered
+// for as long to offer reprints of corrections.
+const messageEditWindow = 48 * time.Hour
+
+// messageKey identifies a telegram message.
+type messageKey struct {
+	chatID    int64
+	messageID int
+}
+
+func messageKeyOf(msg *tb.Message) messageKey {
+	return messageKey{chatID: msg.Chat.ID, messageID: msg.ID}
+}
+
+// messageJob is the print job of a message, either waiting in the queue or sent to print.
+type messageJob struct {
+	text   string
+	queued bool
+	// dequeuedAt is when the message was sent to print.
+	dequeuedAt time.Time
+	done       func()
+}
+
+// printQueue holds the texts of messages for a while before they are printed so that they can be
+// edited or cancelled, messages sent to print are remembered for messageEditWindow.
+type printQueue struct {
+	// afterFunc calls f after d in its own goroutine, time.AfterFunc is used if nil.
+	afterFunc func(d time.Duration, f func())
+
+	mu   sync.Mutex
+	jobs map[messageKey]*messageJob
+}
+
+// add queues text of the message of key to be passed to print after delay, done is called once it's
+// printed or cancelled.
+func (q *printQueue) add(key messageKey, text string, delay time.Duration, print func(text string), done func()) {
+	job := &messageJob{text: text, queued: true, done: done}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pruneLocked(time.Now())
+	if q.jobs == nil {
+		q.jobs = make(map[messageKey]*messageJob)
+	}
+	q.jobs[key] = job
+
+	afterFunc := q.afterFunc
+	if afterFunc == nil {
+		afterFunc = func(d time.Duration, f func()) { time.AfterFunc(d, f) }
+	}
+	afterFunc(delay, func() {
+		q.mu.Lock()
+		if q.jobs[key] != job || !job.queued {
+			// cancelled
+			q.mu.Unlock()
+			return
+		}
+		job.queued = false
+		job.dequeuedAt = time.Now()
+		text := job.text
+		q.mu.Unlock()
+
+		defer done()
+		print(text)
+	})
+}
+
+// printed remembers the message of key as sent to print at now.
+func (q *printQueue) printed(key messageKey, now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pruneLocked(now)
+	if q.jobs == nil {
+		q.jobs = make(map[messageKey]*messageJob)
+	}
+	q.jobs[key] = &messageJob{dequeuedAt: now}
+}
+
+// replace replaces the text of the message of key, false is returned if it's not queued.
+func (q *printQueue) replace(key messageKey, text string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[key]
+	if !ok || !job.queued {
+		return false
+	}
+	job.text = text
+	return true
+}
+
+// cancel removes the message of key from the queue, false is returned if it's not queued.
+func (q *printQueue) cancel(key messageKey) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[key]
+	if !ok || !job.queued {
+		return false
+	}
+	delete(q.jobs, key)
+	job.done()
+	return true
+}
+
+// isPrinted returns whether the message of key was sent to print within messageEditWindow before now.
+func (q *printQueue) isPrinted(key messageKey, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[key]
+	return ok && !job.queued && now.Sub(job.dequeuedAt) <= messageEditWindow
+}
+
+// pruneLocked forgets messages sent to print longer than messageEditWindow before now.
+func (q *printQueue) pruneLocked(now time.Time) {
+	for key, job := range q.jobs {
+		if !job.queued && now.Sub(job.dequeuedAt) > messageEditWindow {
+			delete(q.jobs, key)
+		}
+	}
+}
+
+// queueSend queues the payload of m to be printed to device after PrintDelay, or prints it right away if
+// there's no delay.
+func (b *Bot) queueSend(m *message, device *model.Device) {
+	print := func(text string) {
+		reply, _ := b.printSentInJob(m.Lang, m.SenderUser, device, text, m.Time())
+		b.Send(m.Sender, reply, &tb.SendOptions{
+			ReplyTo:   m.Message,
+			ParseMode: tb.ModeMarkdown,
+		})
+	}
+
+	done, ok := b.jobs.begin(fmt.Sprintf("printing message[%d] to device[%d] for user[%d]", m.ID, device.ID, m.SenderUser.ID))
+	if !ok {
+		b.metrics.prints.Inc(printShuttingDown)
+		b.Send(m.Sender, m.T(replyShuttingDown), &tb.SendOptions{ReplyTo: m.Message})
+		return
+	}
+	key := messageKeyOf(m.Message)
+	if b.PrintDelay <= 0 {
+		defer done()
+		b.queue.printed(key, time.Now())
+		print(m.Payload)
+		return
+	}
+	seconds := int((b.PrintDelay + time.Second - 1) / time.Second)
+	b.Send(m.Sender, m.N(replyQueued, seconds), &tb.SendOptions{ReplyTo: m.Message})
+	b.queue.add(key, m.Payload, b.PrintDelay, print, done)
+}
+
+// handleEdited replaces the text of queued messages with the edited one, or offers to reprint it if
+// printed already. Other edits such as updates of live locations are ignored.
+func (b *Bot) handleEdited(msg *tb.Message) {
+	if msg.Text == "" {
+		return
+	}
+	m, ok := b.receive(msg)
+	if !ok {
+		return
+	}
+	if m.Command != "" && m.Command != "/send" {
+		return
+	}
+	b.metrics.updates.Inc("edit")
+
+	key := messageKeyOf(msg)
+	if b.queue.replace(key, m.Payload) {
+		b.Send(m.Sender, m.T(replyEditQueued), &tb.SendOptions{ReplyTo: msg})
+		return
+	}
+	if !b.queue.isPrinted(key, time.Now()) {
+		return
+	}
+	reprint := btnEditReprint
+	reprint.Text = m.T(replyEditReprint)
+	b.Send(m.Sender, m.T(replyEditPrinted), &tb.SendOptions{
+		ReplyTo:     msg,
+		ReplyMarkup: &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{reprint}}},
+	})
+}
+
+// handleEditReprint prints the corrected message replied to by the offer.
+func (b *Bot) handleEditReprint(c *callback) {
+	orig := c.Message.ReplyTo
+	if orig == nil || orig.Text == "" {
+		c.Answer(b, replyEditGone)
+		return
+	}
+	device, err := b.printableDevice(c.SenderUser)
+	if service.IsRecordNotFoundError(err) {
+		c.Answer(b, replyBindHelp)
+		return
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		c.Answer(b, replyFailedGettingData)
+		return
+	}
+	b.Respond(c.Callback)
+	// the button is removed so that it's not printed twice
+	if _, err := b.Edit(c.Message, c.T(replyEditReprinting)); err != nil {
+		log.Warnf("error editing reprint offer of message[%d]: %s", orig.ID, err)
+	}
+
+	_, payload := splitCmdNPayload(orig.Text)
+	b.queue.printed(messageKeyOf(orig), time.Now())
+	reply, _ := b.printSent(c.Lang, c.SenderUser, device, payload, orig.Time())
+	if _, err := b.Edit(c.Message, reply, tb.ModeMarkdown); err != nil {
+		log.Warnf("error editing reprint offer of message[%d]: %s", orig.ID, err)
+	}
+}
+
+// handleCancel removes the message replied to from the queue.
+func (b *Bot) handleCancel(m *message) {
+	if m.ReplyTo == nil {
+		b.Send(m.Sender, m.T(replyCancelHelp))
+		return
+	}
+	key := messageKeyOf(m.ReplyTo)
+	reply := replyCancelHelp
+	switch {
+	case b.queue.cancel(key):
+		reply = replyCancelled
+	case b.queue.isPrinted(key, time.Now()):
+		reply = replyCancelTooLate
+	}
+	b.Send(m.Sender, m.T(reply), &tb.SendOptions{ReplyTo: m.ReplyTo})
+}
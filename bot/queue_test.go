@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTimers collects the functions to be called later, which are fired by the test.
+type fakeTimers struct {
+	delays []time.Duration
+	funcs  []func()
+}
+
+func (f *fakeTimers) afterFunc(d time.Duration, fn func()) {
+	f.delays = append(f.delays, d)
+	f.funcs = append(f.funcs, fn)
+}
+
+// fire calls the functions collected so far in order.
+func (f *fakeTimers) fire() {
+	funcs := f.funcs
+	f.funcs = nil
+	for _, fn := range funcs {
+		fn()
+	}
+}
+
+func TestPrintQueue(t *testing.T) {
+	timers := &fakeTimers{}
+	q := printQueue{afterFunc: timers.afterFunc}
+	var printed []string
+	print := func(text string) { printed = append(printed, text) }
+	var done int
+	inc := func() { done++ }
+
+	edited := messageKey{chatID: 1, messageID: 1}
+	q.add(edited, "tpyo", 5*time.Second, print, inc)
+	assert.True(t, q.replace(edited, "typo"))
+	assert.False(t, q.isPrinted(edited, time.Now()))
+
+	cancelled := messageKey{chatID: 1, messageID: 2}
+	q.add(cancelled, "never", 5*time.Second, print, inc)
+	assert.True(t, q.cancel(cancelled))
+	assert.False(t, q.cancel(cancelled))
+	assert.Equal(t, 1, done)
+	assert.Equal(t, []time.Duration{5 * time.Second, 5 * time.Second}, timers.delays)
+
+	timers.fire()
+	assert.Equal(t, []string{"typo"}, printed, "the cancelled one is not printed")
+	assert.Equal(t, 2, done)
+
+	now := time.Now()
+	assert.True(t, q.isPrinted(edited, now))
+	assert.False(t, q.replace(edited, "late"))
+	assert.False(t, q.cancel(edited))
+	assert.False(t, q.isPrinted(edited, now.Add(messageEditWindow+time.Minute)))
+	assert.False(t, q.isPrinted(cancelled, now))
+
+	// printed messages are forgotten after the edit window
+	q.printed(messageKey{chatID: 2, messageID: 1}, now.Add(messageEditWindow+time.Minute))
+	assert.Len(t, q.jobs, 1)
+}
@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+// deviceLocks serializes checking quotas of each device with recording the usage so that concurrent prints
+// never exceed them together.
+type deviceLocks struct {
+	mu    sync.Mutex
+	locks map[uint]*sync.Mutex
+}
+
+// lock locks the device of id, the returned function unlocks it.
+func (d *deviceLocks) lock(id uint) func() {
+	d.mu.Lock()
+	if d.locks == nil {
+		d.locks = make(map[uint]*sync.Mutex)
+	}
+	l, ok := d.locks[id]
+	if !ok {
+		l = &sync.Mutex{}
+		d.locks[id] = l
+	}
+	d.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// checkQuota returns true if user is allowed to print text on device, or the reply in lang otherwise.
+// Owners are never limited on their own devices, though their prints count against the quotas of others.
+func (b *Bot) checkQuota(lang i18n.Lang, user *model.User, device *model.Device, text string) (string, bool) {
+	if device.UserID == user.ID {
+		return "", true
+	}
+	share, err := b.ShareService.Get(device.ID, user.ID)
+	if err != nil {
+		log.Warnf("error querying share of device[%d] with user[%d]: %s", device.ID, user.ID, err)
+		return catalogs.T(lang, replyFailedGettingData), false
+	}
+	userQuota := b.UserQuota.Override(share.Quota)
+	deviceQuota := b.DeviceQuota.Override(device.Quota)
+	if userQuota.IsUnlimited() && deviceQuota.IsUnlimited() {
+		return "", true
+	}
+
+	now := time.Now()
+	usages, err := b.UsageService.ListByDeviceID(device.ID, now.Add(-model.UsageWindow))
+	if err != nil {
+		log.Warnf("error querying usages of device[%d]: %s", device.ID, err)
+		return catalogs.T(lang, replyFailedGettingData), false
+	}
+	var userUsages []model.Usage
+	for _, u := range usages {
+		if u.UserID == user.ID {
+			userUsages = append(userUsages, u)
+		}
+	}
+
+	chars := utf8.RuneCountInString(text)
+	if exceeded, resetAt := userQuota.Check(userUsages, chars, now); exceeded {
+		if resetAt.IsZero() {
+			return catalogs.N(lang, replyQuotaTooLong, userQuota.CharsPerDay), false
+		}
+		return catalogs.T(lang, replyQuotaExceeded, i18n.Params{"wait": humanDuration(resetAt.Sub(now))}), false
+	}
+	if exceeded, resetAt := deviceQuota.Check(usages, chars, now); exceeded {
+		if resetAt.IsZero() {
+			return catalogs.N(lang, replyQuotaTooLong, deviceQuota.CharsPerDay), false
+		}
+		return catalogs.T(lang, replyDeviceQuotaExceeded, i18n.Params{"wait": humanDuration(resetAt.Sub(now))}), false
+	}
+	return "", true
+}
+
+// recordUsage counts the text printed successfully by user on device against quotas.
+func (b *Bot) recordUsage(user *model.User, device *model.Device, text string) {
+	err := b.UsageService.New(&model.Usage{
+		UserID:   user.ID,
+		DeviceID: device.ID,
+		Chars:    utf8.RuneCountInString(text),
+	})
+	if err != nil {
+		log.Warnf("error recording usage of user[%d]: %s", user.ID, err)
+	}
+}
+
+// humanDuration formats d in minutes, rounding up.
+func humanDuration(d time.Duration) string {
+	if d < time.Minute {
+		d = time.Minute
+	}
+	d = (d + time.Minute - 1).Truncate(time.Minute)
+	return strings.TrimSuffix(d.String(), "0s")
+}
+
+func formatQuota(lang i18n.Lang, q model.Quota) string {
+	limit := func(n int, key, unlimitedKey string) string {
+		if n <= 0 {
+			return catalogs.T(lang, unlimitedKey)
+		}
+		return catalogs.N(lang, key, n)
+	}
+	return limit(q.MessagesPerHour, replyQuotaMessages, replyQuotaMessagesUnlimited) + ", " +
+		limit(q.CharsPerDay, replyQuotaChars, replyQuotaCharsUnlimited)
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestHumanDuration(t *testing.T) {
+	for d, expect := range map[time.Duration]string{
+		0:                              "1m",
+		30 * time.Second:               "1m",
+		90 * time.Second:               "2m",
+		time.Hour:                      "1h0m",
+		22*time.Hour + 30*time.Second:  "22h1m",
+		59*time.Minute + 1*time.Second: "1h0m",
+	} {
+		assert.Equal(t, expect, humanDuration(d), d)
+	}
+}
+
+func TestParseQuota(t *testing.T) {
+	q, err := parseQuota([]string{"10", "0"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.Quota{MessagesPerHour: 10}, q)
+	q, err = parseQuota([]string{"Unlimited", "100"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.Quota{MessagesPerHour: model.QuotaUnlimited, CharsPerDay: 100}, q)
+
+	for _, args := range [][]string{nil, {"1"}, {"1", "2", "3"}, {"-1", "2"}, {"a", "2"}} {
+		_, err := parseQuota(args)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestCheckQuotaOwner(t *testing.T) {
+	b := &Bot{Config: &Config{
+		UserQuota:   model.Quota{MessagesPerHour: 1},
+		DeviceQuota: model.Quota{MessagesPerHour: 1},
+		UsageService: &memoryUsages{usages: []model.Usage{
+			{Model: gorm.Model{CreatedAt: time.Now()}, UserID: 1, DeviceID: 1},
+			{Model: gorm.Model{CreatedAt: time.Now()}, UserID: 1, DeviceID: 1},
+		}},
+	}}
+	_, ok := b.checkQuota(i18n.English, &model.User{Model: gorm.Model{ID: 1}}, &model.Device{Model: gorm.Model{ID: 1}, UserID: 1}, "hello")
+	assert.True(t, ok, "owners are not limited on their own devices")
+}
+
+// fixedShare returns share for whatever is shared.
+type fixedShare struct {
+	ShareService
+	share model.Share
+}
+
+func (f *fixedShare) Get(deviceID, userID uint) (*model.Share, error) {
+	return &f.share, nil
+}
+
+func TestCheckQuotaUnlimitedShare(t *testing.T) {
+	shares := &fixedShare{}
+	b := &Bot{Config: &Config{
+		UserQuota:    model.Quota{MessagesPerHour: 1},
+		ShareService: shares,
+		UsageService: &memoryUsages{usages: []model.Usage{
+			{Model: gorm.Model{CreatedAt: time.Now()}, UserID: 2, DeviceID: 1},
+		}},
+	}}
+	sharee := &model.User{Model: gorm.Model{ID: 2}}
+	device := &model.Device{Model: gorm.Model{ID: 1}, UserID: 1}
+	_, ok := b.checkQuota(i18n.English, sharee, device, "hello")
+	assert.False(t, ok, "the default quota applies")
+
+	shares.share.Quota.MessagesPerHour = model.QuotaUnlimited
+	_, ok = b.checkQuota(i18n.English, sharee, device, "hello")
+	assert.True(t, ok, "the default quota is lifted for the sharee")
+}
+
+func TestDeviceLocks(t *testing.T) {
+	var (
+		locks   deviceLocks
+		wg      sync.WaitGroup
+		holding int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer locks.lock(1)()
+			assert.Equal(t, int32(1), atomic.AddInt32(&holding, 1), "the device is locked by one at a time")
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&holding, -1)
+		}()
+	}
+	wg.Wait()
+
+	unlock := locks.lock(1)
+	// other devices are not blocked
+	locks.lock(2)()
+	unlock()
+}
@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"time"
+)
+
+// scheduleInterval is how often scheduled tasks run.
+const scheduleInterval = time.Minute
+
+// task is run every scheduleInterval while the bot is started.
+type task func(now time.Time)
+
+// Start starts the scheduled tasks and the bot, it blocks until Stop is called.
+func (b *Bot) Start() {
+	go b.runTasks()
+	b.Bot.Start()
+}
+
+// Stop stops the bot and the scheduled tasks.
+func (b *Bot) Stop() {
+	b.stopTasks.Do(func() {
+		close(b.tasksStopped)
+	})
+	b.Bot.Stop()
+}
+
+func (b *Bot) runTasks() {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.tasksStopped:
+			return
+		case now := <-ticker.C:
+			for _, t := range b.tasks {
+				t(now)
+			}
+		}
+	}
+}
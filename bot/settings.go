@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"strings"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+// setting is a preference of user chosen from values by /settings.
+type setting struct {
+	name string
+	// description is the key of the description in catalogs.
+	description string
+	values      []string
+	get         func(*model.User) string
+	set         func(s UserService, userID uint, value string) error
+}
+
+// values of settings toggled on and off.
+const (
+	settingOn  = "on"
+	settingOff = "off"
+)
+
+// settings are the ones listed by /settings.
+var settings = []setting{
+	{
+		name:        "contact",
+		description: replySettingContact,
+		values:      []string{model.ContactLayoutCompact, model.ContactLayoutFull},
+		get:         contactLayout,
+		set:         UserService.SetContactLayout,
+	},
+	{
+		name:        "sticker_caption",
+		description: replySettingStickerCaption,
+		values:      []string{settingOn, settingOff},
+		get: func(u *model.User) string {
+			return onOff(u.StickerCaption)
+		},
+		set: func(s UserService, userID uint, value string) error {
+			return s.SetStickerCaption(userID, value == settingOn)
+		},
+	},
+}
+
+// onOff returns the value of a setting toggled by on.
+func onOff(on bool) string {
+	if on {
+		return settingOn
+	}
+	return settingOff
+}
+
+// settingByName returns the setting named name, nil if not found.
+func settingByName(name string) *setting {
+	for i := range settings {
+		if strings.EqualFold(settings[i].name, name) {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+// renderSettings renders the settings of user in lang.
+func renderSettings(lang i18n.Lang, user *model.User) string {
+	lines := make([]string, 0, len(settings))
+	for _, s := range settings {
+		lines = append(lines, catalogs.T(lang, replySettingLine, i18n.Params{
+			"name":        s.name,
+			"value":       s.get(user),
+			"values":      strings.Join(s.values, "|"),
+			"description": catalogs.T(lang, s.description),
+		}))
+	}
+	return catalogs.T(lang, replySettings, i18n.Params{"settings": strings.Join(lines, "\n")})
+}
+
+func (b *Bot) handleSettings(m *message) {
+	args := strings.Fields(m.Payload)
+	if len(args) == 0 {
+		b.Send(m.Sender, renderSettings(m.Lang, m.SenderUser))
+		return
+	}
+
+	s := settingByName(args[0])
+	if s == nil {
+		names := make([]string, 0, len(settings))
+		for _, s := range settings {
+			names = append(names, s.name)
+		}
+		b.Send(m.Sender, m.T(replySettingUnknown, i18n.Params{"names": strings.Join(names, "|")}))
+		return
+	}
+	value := ""
+	if len(args) == 2 {
+		value = strings.ToLower(args[1])
+	}
+	if !containsString(s.values, value) {
+		b.Send(m.Sender, m.T(replySettingInvalid, i18n.Params{"name": s.name, "values": strings.Join(s.values, "|")}))
+		return
+	}
+
+	if err := s.set(b.UserService, m.SenderUser.ID, value); err != nil {
+		log.Warnf("error setting %s of user[%d]: %s", s.name, m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	b.Send(m.Sender, m.T(replySettingSet, i18n.Params{"name": s.name, "value": value}))
+}
+
+// containsString returns whether ss contains s.
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
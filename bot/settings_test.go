@@ -0,0 +1,24 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestSettings(t *testing.T) {
+	assert.Equal(t, "contact", settingByName("Contact").name)
+	assert.Nil(t, settingByName("unknown"))
+
+	assert.Equal(t, "Your settings:\n- contact: full (compact|full)\n"+
+		"  Layout of the contact cards printed, compact puts the name and phone on one line.\n"+
+		"- sticker_caption: off (on|off)\n  Whether stickers are printed with short names of their emoji, the ones without a name are omitted.\n\n"+
+		"Use /settings [name] [value] to change one.", renderSettings(i18n.English, &model.User{}))
+	assert.Contains(t, renderSettings(i18n.English, &model.User{ContactLayout: model.ContactLayoutCompact}),
+		"- contact: compact (compact|full)")
+	assert.Contains(t, renderSettings(i18n.English, &model.User{StickerCaption: true}),
+		"- sticker_caption: on (on|off)")
+}
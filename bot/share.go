@@ -0,0 +1,259 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// printableDevice returns the first of printableDevices, the record is not found if there is none.
+func (b *Bot) printableDevice(user *model.User) (*model.Device, error) {
+	devices, err := b.printableDevices(user)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, service.ErrRecordNotFound
+	}
+	return &devices[0], nil
+}
+
+// printableDevices returns the verified devices user can print to, the owned one first.
+func (b *Bot) printableDevices(user *model.User) ([]model.Device, error) {
+	var devices []model.Device
+	device, err := b.DeviceService.GetByUserID(user.ID)
+	if err != nil && !service.IsRecordNotFoundError(err) {
+		return nil, fmt.Errorf("querying device: %w", err)
+	}
+	if err == nil && device.IsVerified() {
+		devices = append(devices, *device)
+	}
+	if !b.Features.Sharing {
+		return devices, nil
+	}
+
+	shares, err := b.ShareService.ListByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("querying shares: %w", err)
+	}
+	for _, share := range shares {
+		device, err := b.DeviceService.GetByID(share.DeviceID)
+		if service.IsRecordNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("querying device[%d]: %w", share.DeviceID, err)
+		}
+		if device.IsVerified() {
+			devices = append(devices, *device)
+		}
+	}
+	return devices, nil
+}
+
+// ownedVerifiedDevice returns the verified device owned by the sender, or replies otherwise.
+func (b *Bot) ownedVerifiedDevice(m *message) (*model.Device, bool) {
+	device, err := b.DeviceService.GetByUserID(m.SenderUser.ID)
+	if err != nil && !service.IsRecordNotFoundError(err) {
+		log.Warn("error querying device:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return nil, false
+	}
+	if err != nil || !device.IsVerified() {
+		b.Send(m.Sender, m.T(replyNeedVerifiedDevice))
+		return nil, false
+	}
+	return device, true
+}
+
+// userByMention returns the user mentioned as @username, nil if not found.
+func (b *Bot) userByMention(mention string) (*model.User, error) {
+	userName := strings.TrimPrefix(mention, "@")
+	if userName == "" {
+		return nil, nil
+	}
+	user, err := b.UserService.GetByTelegramUserName(userName)
+	if service.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return user, err
+}
+
+func (b *Bot) handleShare(m *message) {
+	device, ok := b.ownedVerifiedDevice(m)
+	if !ok {
+		return
+	}
+
+	mention := strings.TrimSpace(m.Payload)
+	if mention == "" {
+		b.listShares(m, device)
+		return
+	}
+
+	user, err := b.userByMention(mention)
+	if err != nil {
+		log.Warnf("error querying user[%s]: %s", mention, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if user == nil {
+		b.Send(m.Sender, m.T(replyShareUserNotFound, i18n.Params{"user": strings.TrimPrefix(mention, "@")}))
+		return
+	}
+	if user.ID == m.SenderUser.ID {
+		b.Send(m.Sender, m.T(replyShareHelp))
+		return
+	}
+
+	if _, err := b.ShareService.New(device.ID, user.ID); err != nil {
+		log.Warnf("error sharing device[%d] with user[%d]: %s", device.ID, user.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	b.Send(m.Sender, m.T(replyShared, i18n.Params{"user": user.TelegramUserName}))
+	b.Send(&tb.User{ID: int(user.TelegramID)}, catalogs.T(langOf(user, ""), replySharedWithYou, i18n.Params{"name": m.SenderUser.TelegramFullName}))
+}
+
+func (b *Bot) listShares(m *message, device *model.Device) {
+	shares, err := b.ShareService.ListByDeviceID(device.ID)
+	if err != nil {
+		log.Warnf("error querying shares of device[%d]: %s", device.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if len(shares) == 0 {
+		b.Send(m.Sender, m.T(replySharesNone))
+		return
+	}
+
+	lines := []string{m.T(replySharesHeader)}
+	for _, share := range shares {
+		user, err := b.UserService.GetByID(share.UserID)
+		if err != nil {
+			log.Warnf("error querying user[%d]: %s", share.UserID, err)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- @%s: %s", user.TelegramUserName, formatQuota(m.Lang, b.UserQuota.Override(share.Quota))))
+	}
+	b.Send(m.Sender, strings.Join(lines, "\n"))
+}
+
+func (b *Bot) handleUnshare(m *message) {
+	device, ok := b.ownedVerifiedDevice(m)
+	if !ok {
+		return
+	}
+
+	mention := strings.TrimSpace(m.Payload)
+	user, err := b.userByMention(mention)
+	if err != nil {
+		log.Warnf("error querying user[%s]: %s", mention, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if user == nil {
+		b.Send(m.Sender, m.T(replyShareHelp))
+		return
+	}
+
+	deleted, err := b.ShareService.Delete(device.ID, user.ID)
+	if err != nil {
+		log.Warnf("error unsharing device[%d] with user[%d]: %s", device.ID, user.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if !deleted {
+		b.Send(m.Sender, m.T(replyNotShared, i18n.Params{"user": user.TelegramUserName}))
+		return
+	}
+	b.Send(m.Sender, m.T(replyUnshared, i18n.Params{"user": user.TelegramUserName}))
+}
+
+// quotaUnlimited is the limit parsed as model.QuotaUnlimited, while 0 keeps the default.
+const quotaUnlimited = "unlimited"
+
+// parseQuota parses "[messages per hour] [characters per day]".
+func parseQuota(args []string) (model.Quota, error) {
+	if len(args) != 2 {
+		return model.Quota{}, fmt.Errorf("expecting 2 limits, got %d", len(args))
+	}
+	var limits [2]int
+	for i, arg := range args {
+		if strings.EqualFold(arg, quotaUnlimited) {
+			limits[i] = model.QuotaUnlimited
+			continue
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return model.Quota{}, fmt.Errorf("invalid limit[%s]", arg)
+		}
+		limits[i] = n
+	}
+	return model.Quota{MessagesPerHour: limits[0], CharsPerDay: limits[1]}, nil
+}
+
+func (b *Bot) handleLimit(m *message) {
+	device, ok := b.ownedVerifiedDevice(m)
+	if !ok {
+		return
+	}
+
+	args := strings.Fields(m.Payload)
+	if len(args) == 0 {
+		b.Send(m.Sender, m.T(replyLimits, i18n.Params{
+			"device": formatQuota(m.Lang, b.DeviceQuota.Override(device.Quota)),
+			"user":   formatQuota(m.Lang, b.UserQuota),
+		}))
+		return
+	}
+
+	if !strings.HasPrefix(args[0], "@") {
+		quota, err := parseQuota(args)
+		if err != nil {
+			b.Send(m.Sender, m.T(replyLimitHelp))
+			return
+		}
+		if err := b.DeviceService.SetQuota(device.ID, quota); err != nil {
+			log.Warnf("error setting quota of device[%d]: %s", device.ID, err)
+			b.Send(m.Sender, m.T(replyFailedGettingData))
+			return
+		}
+		b.Send(m.Sender, m.T(replyLimitSet))
+		return
+	}
+
+	quota, err := parseQuota(args[1:])
+	if err != nil {
+		b.Send(m.Sender, m.T(replyLimitHelp))
+		return
+	}
+	user, err := b.userByMention(args[0])
+	if err != nil {
+		log.Warnf("error querying user[%s]: %s", args[0], err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if user == nil {
+		b.Send(m.Sender, m.T(replyNotShared, i18n.Params{"user": strings.TrimPrefix(args[0], "@")}))
+		return
+	}
+	updated, err := b.ShareService.SetQuota(device.ID, user.ID, quota)
+	if err != nil {
+		log.Warnf("error setting quota of device[%d] for user[%d]: %s", device.ID, user.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if !updated {
+		b.Send(m.Sender, m.T(replyNotShared, i18n.Params{"user": user.TelegramUserName}))
+		return
+	}
+	b.Send(m.Sender, m.T(replyLimitSet))
+}
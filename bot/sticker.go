@@ -0,0 +1,193 @@
+package bot
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/tevino/log"
+	// decoder of static stickers
+	_ "golang.org/x/image/webp"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// stickerWidth is the width of stickers printed in dots.
+const stickerWidth = memobird.PaperWidth * 2 / 3
+
+// handleSticker prints stickers, animated and video ones are printed by their thumbnails.
+func (b *Bot) handleSticker(msg *tb.Message) {
+	m, ok := b.receive(msg)
+	if !ok {
+		return
+	}
+	b.metrics.updates.Inc("sticker")
+
+	device, err := b.printableDevice(m.SenderUser)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyBindHelp))
+		return
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	img, fileID, err := b.stickerImage(msg.Sticker)
+	if err != nil {
+		log.Warnf("error downloading sticker[%s]: %s", msg.Sticker.FileID, err)
+		b.Send(m.Sender, m.T(replyStickerFailed, i18n.Params{"error": err}))
+		return
+	}
+	caption := ""
+	if m.SenderUser.StickerCaption {
+		caption = emojiCaption(m.Lang, msg.Sticker.Emoji)
+	}
+	doc, err := stickerDocument(img, caption)
+	if err != nil {
+		log.Warnf("error rendering sticker[%s]: %s", msg.Sticker.FileID, err)
+		b.Send(m.Sender, m.T(replyStickerFailed, i18n.Params{"error": err}))
+		return
+	}
+	reply, _ := b.printDocument(m.Lang, m.SenderUser, device, doc, fileID, caption)
+	b.Send(m.Sender, reply, &tb.SendOptions{
+		ReplyTo:   m.Message,
+		ParseMode: tb.ModeMarkdown,
+	})
+}
+
+// stickerImage downloads the image of sticker along with the file it's decoded from, the thumbnail is used
+// if the sticker is animated as the file can't be decoded as an image.
+func (b *Bot) stickerImage(sticker *tb.Sticker) (image.Image, string, error) {
+	img, err := b.downloadImage(sticker.FileID)
+	if err == nil {
+		return img, sticker.FileID, nil
+	}
+	if sticker.Thumbnail == nil {
+		return nil, "", fmt.Errorf("no thumbnail of undecodable sticker: %w", err)
+	}
+	img, err = b.downloadImage(sticker.Thumbnail.FileID)
+	return img, sticker.Thumbnail.FileID, err
+}
+
+// stickerDocument renders img flattened onto white with the caption under it if not empty.
+func stickerDocument(img image.Image, caption string) (*memobird.Document, error) {
+	doc := new(memobird.Document)
+	if err := doc.AddImage(memobird.Flatten(img, stickerWidth)); err != nil {
+		return nil, err
+	}
+	if caption != "" {
+		if err := doc.AddText(caption); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// emojiNames are the short names of the emoji commonly sent with stickers, which can't be printed as they
+// are. Skin tones and variation selectors have no names.
+var emojiNames = i18n.Bundle{
+	i18n.English: {
+		"😀": {Other: "grinning"},
+		"😁": {Other: "beaming"},
+		"😂": {Other: "tears of joy"},
+		"🤣": {Other: "rolling with laughter"},
+		"😊": {Other: "smiling"},
+		"😅": {Other: "sweat smile"},
+		"😉": {Other: "winking"},
+		"😜": {Other: "tongue out"},
+		"😍": {Other: "heart eyes"},
+		"😘": {Other: "blowing a kiss"},
+		"😎": {Other: "cool"},
+		"🤔": {Other: "thinking"},
+		"🙄": {Other: "eye roll"},
+		"😳": {Other: "flushed"},
+		"😴": {Other: "sleeping"},
+		"😢": {Other: "crying"},
+		"😭": {Other: "sobbing"},
+		"😡": {Other: "angry"},
+		"😤": {Other: "huffing"},
+		"😱": {Other: "screaming"},
+		"🥺": {Other: "pleading"},
+		"🥳": {Other: "partying"},
+		"🤗": {Other: "hugging"},
+		"👍": {Other: "thumbs up"},
+		"👎": {Other: "thumbs down"},
+		"👌": {Other: "OK"},
+		"👏": {Other: "clapping"},
+		"🙏": {Other: "please"},
+		"👋": {Other: "waving"},
+		"💪": {Other: "flexing"},
+		"❤": {Other: "heart"},
+		"💔": {Other: "broken heart"},
+		"🔥": {Other: "fire"},
+		"🎉": {Other: "party"},
+		"✨": {Other: "sparkles"},
+		"⭐": {Other: "star"},
+		"🌹": {Other: "rose"},
+		"🎂": {Other: "cake"},
+		"☕": {Other: "coffee"},
+		"🐱": {Other: "cat"},
+		"🐶": {Other: "dog"},
+		"💩": {Other: "poo"},
+	},
+	i18n.ChineseSimplified: {
+		"😀": {Other: "笑"},
+		"😁": {Other: "咧嘴笑"},
+		"😂": {Other: "笑哭"},
+		"🤣": {Other: "笑翻"},
+		"😊": {Other: "微笑"},
+		"😅": {Other: "苦笑"},
+		"😉": {Other: "眨眼"},
+		"😜": {Other: "调皮"},
+		"😍": {Other: "花痴"},
+		"😘": {Other: "飞吻"},
+		"😎": {Other: "酷"},
+		"🤔": {Other: "思考"},
+		"🙄": {Other: "翻白眼"},
+		"😳": {Other: "脸红"},
+		"😴": {Other: "睡觉"},
+		"😢": {Other: "哭泣"},
+		"😭": {Other: "大哭"},
+		"😡": {Other: "生气"},
+		"😤": {Other: "哼"},
+		"😱": {Other: "惊恐"},
+		"🥺": {Other: "可怜"},
+		"🥳": {Other: "庆祝"},
+		"🤗": {Other: "拥抱"},
+		"👍": {Other: "赞"},
+		"👎": {Other: "踩"},
+		"👌": {Other: "好的"},
+		"👏": {Other: "鼓掌"},
+		"🙏": {Other: "拜托"},
+		"👋": {Other: "挥手"},
+		"💪": {Other: "加油"},
+		"❤": {Other: "爱心"},
+		"💔": {Other: "心碎"},
+		"🔥": {Other: "火"},
+		"🎉": {Other: "撒花"},
+		"✨": {Other: "闪亮"},
+		"⭐": {Other: "星星"},
+		"🌹": {Other: "玫瑰"},
+		"🎂": {Other: "蛋糕"},
+		"☕": {Other: "咖啡"},
+		"🐱": {Other: "猫"},
+		"🐶": {Other: "狗"},
+		"💩": {Other: "便便"},
+	},
+}
+
+// emojiCaption returns the short names of emoji in lang, the ones without names are omitted.
+func emojiCaption(lang i18n.Lang, emoji string) string {
+	var names []string
+	for _, r := range emoji {
+		if _, ok := emojiNames[i18n.Default][string(r)]; ok {
+			names = append(names, emojiNames.T(lang, string(r)))
+		}
+	}
+	return strings.Join(names, " ")
+}
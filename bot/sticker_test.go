@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"image"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+)
+
+func TestEmojiNames(t *testing.T) {
+	for lang, names := range emojiNames {
+		assert.Len(t, names, len(emojiNames[i18n.Default]), lang)
+		for emoji, name := range names {
+			assert.Equal(t, 1, utf8.RuneCountInString(emoji), "%s of %s", emoji, lang)
+			_, err := memobird.UTF8ToGBK([]byte(name.Other))
+			assert.NoError(t, err, "%s of %s is printable", emoji, lang)
+		}
+	}
+}
+
+func TestEmojiCaption(t *testing.T) {
+	assert.Equal(t, "grinning", emojiCaption(i18n.English, "😀"))
+	assert.Equal(t, "笑", emojiCaption(i18n.ChineseSimplified, "😀"))
+	assert.Equal(t, "thumbs up", emojiCaption(i18n.English, "👍🏽"), "skin tone omitted")
+	assert.Equal(t, "heart", emojiCaption(i18n.English, "❤️"), "variation selector omitted")
+	assert.Equal(t, "cat fire", emojiCaption(i18n.English, "🐱🦄🔥"), "the ones without names omitted")
+	assert.Equal(t, "", emojiCaption(i18n.English, ""))
+}
+
+func TestStickerDocument(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 512, 512))
+	doc, err := stickerDocument(img, "")
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(doc.String(), "|"), 1)
+
+	doc, err = stickerDocument(img, "grinning")
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(doc.String(), "|"), 2)
+}
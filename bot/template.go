@@ -0,0 +1,245 @@
+package bot
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+const printTemplateMaxLen = 1000
+
+// presetPlain prints the body as-is, which is used if no template is chosen.
+const presetPlain = "plain"
+
+// printTemplatePresets are the built-in print templates by name.
+var printTemplatePresets = map[string]string{
+	presetPlain: `{{.Body}}`,
+	"postcard": `From: {{.Sender}}
+{{.Time.Format "2006-01-02 15:04"}}
+--------------------------------
+{{.Body}}
+--------------------------------
+To: {{.Device}}`,
+	"receipt": `================================
+{{.Device}}
+{{.Time.Format "2006-01-02 15:04:05"}}
+--------------------------------
+{{.Body}}
+--------------------------------
+Signed: {{.Sender}}
+================================`,
+}
+
+// printData is what print templates can access.
+type printData struct {
+	// Sender is the full name of the sender.
+	Sender string
+	// Time is when the message was sent, in the time zone of the device.
+	Time time.Time
+	// Device is the nickname of the device.
+	Device string
+	Body   string
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(printTemplatePresets))
+	for name := range printTemplatePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// deviceLocation returns the time zone of device.
+func deviceLocation(device *model.Device) *time.Location {
+	if device.Timezone != "" {
+		if loc, err := time.LoadLocation(device.Timezone); err == nil {
+			return loc
+		}
+	}
+	return memobird.TZShanghai
+}
+
+// userLocation returns the time zone of the device user prints to, the default one if there's none.
+func (b *Bot) userLocation(user *model.User) *time.Location {
+	if device, err := b.printableDevice(user); err == nil {
+		return deviceLocation(device)
+	}
+	return memobird.TZShanghai
+}
+
+// activeTemplate returns the template wrapping prints of user to device and the key of where it's from. The
+// template of the user wins on the devices of the user, while the one the owner set on a device wins for
+// users it's shared with.
+func activeTemplate(user *model.User, device *model.Device) (string, string) {
+	if device.UserID != user.ID && device.PrintTemplate != "" {
+		return device.PrintTemplate, replyTemplateSourceDevice
+	}
+	if user.PrintTemplate != "" {
+		return user.PrintTemplate, replyTemplateSourceUser
+	}
+	if device.PrintTemplate != "" {
+		return device.PrintTemplate, replyTemplateSourceDevice
+	}
+	return presetPlain, replyTemplateSourceDefault
+}
+
+// renderPrint renders data with tmpl, which is either a preset name or a text/template.
+func renderPrint(tmpl string, data printData) (string, error) {
+	if preset, ok := printTemplatePresets[tmpl]; ok {
+		tmpl = preset
+	}
+	t, err := template.New("print").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// wrapPrint wraps body sent by user at sentAt with the active template, body is returned as-is on errors.
+func wrapPrint(user *model.User, device *model.Device, body string, sentAt time.Time) string {
+	tmpl, _ := activeTemplate(user, device)
+	txt, err := renderPrint(tmpl, printData{
+		Sender: user.TelegramFullName,
+		Time:   sentAt.In(deviceLocation(device)),
+		Device: device.Name(),
+		Body:   body,
+	})
+	if err != nil {
+		log.Warnf("error rendering template of user[%d] on device[%d]: %s", user.ID, device.ID, err)
+		return body
+	}
+	return txt
+}
+
+// validateTemplate returns an error if tmpl can not render.
+func validateTemplate(tmpl string) error {
+	_, err := renderPrint(tmpl, printData{Sender: "Sender", Time: time.Now(), Device: "Device", Body: "Body"})
+	return err
+}
+
+func (b *Bot) handleTemplate(m *message) {
+	action, rest := cutWord(m.Payload)
+	onDevice := false
+	if target, value := cutWord(rest); target == "device" {
+		onDevice = true
+		rest = value
+	}
+
+	switch action {
+	case "", "show":
+		b.showTemplate(m)
+	case "set":
+		b.setTemplate(m, onDevice, strings.TrimSpace(rest))
+	case "reset":
+		b.setTemplate(m, onDevice, "")
+	default:
+		b.Send(m.Sender, m.T(replyTemplateHelp, i18n.Params{"presets": strings.Join(presetNames(), ", ")}))
+	}
+}
+
+func (b *Bot) showTemplate(m *message) {
+	device, err := b.printableDevice(m.SenderUser)
+	if err != nil {
+		// without a device, only the template of the user matters
+		device = &model.Device{}
+	}
+	tmpl, source := activeTemplate(m.SenderUser, device)
+	b.Send(m.Sender, m.T(replyTemplateShow, i18n.Params{
+		"source":   m.T(source),
+		"template": tmpl,
+		"presets":  strings.Join(presetNames(), ", "),
+	}))
+}
+
+func (b *Bot) setTemplate(m *message, onDevice bool, tmpl string) {
+	if len([]rune(tmpl)) > printTemplateMaxLen {
+		b.Send(m.Sender, m.T(replyTemplateInvalid, i18n.Params{"error": m.N(replyTemplateTooLong, printTemplateMaxLen)}))
+		return
+	}
+	if tmpl != "" {
+		if err := validateTemplate(tmpl); err != nil {
+			b.Send(m.Sender, m.T(replyTemplateInvalid, i18n.Params{"error": err}))
+			return
+		}
+	}
+
+	if onDevice {
+		device, ok := b.ownedVerifiedDevice(m)
+		if !ok {
+			return
+		}
+		if err := b.DeviceService.SetPrintTemplate(device.ID, tmpl); err != nil {
+			log.Warnf("error setting template of device[%d]: %s", device.ID, err)
+			b.Send(m.Sender, m.T(replyFailedGettingData))
+			return
+		}
+	} else {
+		if err := b.UserService.SetPrintTemplate(m.SenderUser.ID, tmpl); err != nil {
+			log.Warnf("error setting template of user[%d]: %s", m.SenderUser.ID, err)
+			b.Send(m.Sender, m.T(replyFailedGettingData))
+			return
+		}
+	}
+
+	if tmpl == "" {
+		b.Send(m.Sender, m.T(replyTemplateReset))
+	} else {
+		b.Send(m.Sender, m.T(replyTemplateSet))
+	}
+}
+
+func (b *Bot) handleDevice(m *message) {
+	device, ok := b.ownedVerifiedDevice(m)
+	if !ok {
+		return
+	}
+
+	action, value := cutWord(m.Payload)
+	value = strings.TrimSpace(value)
+	switch {
+	case action == "":
+		nickname := device.Nickname
+		if nickname == "" {
+			nickname = m.T(replyNone)
+		}
+		b.Send(m.Sender, m.T(replyDeviceInfo, i18n.Params{
+			"memobird": device.MemobirdID,
+			"nickname": nickname,
+			"timezone": deviceLocation(device),
+		}))
+	case action == "name" && value != "":
+		if err := b.DeviceService.SetNickname(device.ID, value); err != nil {
+			log.Warnf("error setting nickname of device[%d]: %s", device.ID, err)
+			b.Send(m.Sender, m.T(replyFailedGettingData))
+			return
+		}
+		b.Send(m.Sender, m.T(replyDeviceNicknameSet, i18n.Params{"nickname": value}))
+	case action == "tz" && value != "":
+		loc, err := time.LoadLocation(value)
+		if err != nil {
+			b.Send(m.Sender, m.T(replyDeviceTimezoneInvalid, i18n.Params{"timezone": value}))
+			return
+		}
+		if err := b.DeviceService.SetTimezone(device.ID, loc.String()); err != nil {
+			log.Warnf("error setting timezone of device[%d]: %s", device.ID, err)
+			b.Send(m.Sender, m.T(replyFailedGettingData))
+			return
+		}
+		b.Send(m.Sender, m.T(replyDeviceTimezoneSet, i18n.Params{"timezone": loc}))
+	default:
+		b.Send(m.Sender, m.T(replyDeviceHelp))
+	}
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestActiveTemplate(t *testing.T) {
+	user := &model.User{}
+	device := &model.Device{}
+	tmpl, source := activeTemplate(user, device)
+	assert.Equal(t, presetPlain, tmpl)
+	assert.Equal(t, replyTemplateSourceDefault, source)
+
+	device.PrintTemplate = "receipt"
+	tmpl, source = activeTemplate(user, device)
+	assert.Equal(t, "receipt", tmpl)
+	assert.Equal(t, replyTemplateSourceDevice, source)
+
+	user.PrintTemplate = "postcard"
+	tmpl, source = activeTemplate(user, device)
+	assert.Equal(t, "postcard", tmpl)
+	assert.Equal(t, replyTemplateSourceUser, source)
+
+	sharee := &model.User{PrintTemplate: "postcard"}
+	sharee.ID = 2
+	tmpl, source = activeTemplate(sharee, device)
+	assert.Equal(t, "receipt", tmpl, "the template of the owner wins on shared devices")
+	assert.Equal(t, replyTemplateSourceDevice, source)
+
+	device.PrintTemplate = ""
+	tmpl, source = activeTemplate(sharee, device)
+	assert.Equal(t, "postcard", tmpl)
+	assert.Equal(t, replyTemplateSourceUser, source)
+}
+
+func TestRenderPrint(t *testing.T) {
+	data := printData{
+		Sender: "Alice",
+		Time:   time.Date(2019, 10, 1, 8, 30, 0, 0, time.UTC),
+		Device: "Kitchen",
+		Body:   "hello",
+	}
+	for name := range printTemplatePresets {
+		txt, err := renderPrint(name, data)
+		assert.NoError(t, err, name)
+		assert.Contains(t, txt, "hello", name)
+	}
+
+	txt, err := renderPrint(presetPlain, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", txt)
+
+	txt, err = renderPrint(`{{.Sender}}@{{.Time.Format "15:04"}}: {{.Body}}`, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice@08:30: hello", txt)
+
+	_, err = renderPrint(`{{.Body`, data)
+	assert.Error(t, err)
+	_, err = renderPrint(`{{.Missing}}`, data)
+	assert.Error(t, err)
+}
+
+func TestWrapPrint(t *testing.T) {
+	user := &model.User{TelegramFullName: "Alice", PrintTemplate: `{{.Time.Format "15:04"}} {{.Device}}: {{.Body}}`}
+	device := &model.Device{MemobirdID: "abc", Timezone: "Europe/Berlin"}
+	sentAt := time.Date(2019, 10, 1, 8, 30, 0, 0, time.UTC)
+	assert.Equal(t, "10:30 abc: hi", wrapPrint(user, device, "hi", sentAt))
+
+	device.Nickname = "Kitchen"
+	device.Timezone = ""
+	assert.Equal(t, "16:30 Kitchen: hi", wrapPrint(user, device, "hi", sentAt))
+
+	// broken templates don't stop printing
+	user.PrintTemplate = `{{.Missing}}`
+	assert.Equal(t, "hi", wrapPrint(user, device, "hi", sentAt))
+}
@@ -0,0 +1,380 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+)
+
+// kinds of text documents, rendered differently.
+const (
+	textDocPlain    = "plain"
+	textDocMarkdown = "markdown"
+	textDocCSV      = "csv"
+)
+
+// textDocExts are the kinds of text documents by extension.
+var textDocExts = map[string]string{
+	".txt":      textDocPlain,
+	".log":      textDocPlain,
+	".md":       textDocMarkdown,
+	".markdown": textDocMarkdown,
+	".csv":      textDocCSV,
+}
+
+// textDocMIMEs are the kinds of text documents by MIME type, for files sent without names.
+var textDocMIMEs = map[string]string{
+	"text/plain":    textDocPlain,
+	"text/markdown": textDocMarkdown,
+	"text/csv":      textDocCSV,
+}
+
+// textDocTabWidth is the number of spaces a tab is expanded to.
+const textDocTabWidth = 4
+
+// csvMinColumnCells is the narrowest a column of a CSV table can be, records are listed field by
+// field if the paper is too narrow for the columns.
+const csvMinColumnCells = 3
+
+var errUnknownEncoding = errors.New("unknown encoding")
+
+var (
+	reMarkdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)(\s+#+)?\s*$`)
+	reMarkdownRule    = regexp.MustCompile(`^ {0,3}((\* *){3,}|(- *){3,}|(_ *){3,})$`)
+	reMarkdownItem    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	reMarkdownFence   = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// textDocKind returns the kind of the document named fileName of mime, empty if it's not a text document.
+func textDocKind(fileName, mime string) string {
+	if kind, ok := textDocExts[strings.ToLower(path.Ext(fileName))]; ok {
+		return kind
+	}
+	if fileName == "" {
+		return textDocMIMEs[mime]
+	}
+	return ""
+}
+
+// decodeText decodes data in UTF-8, GBK, or UTF-16 with a BOM, newlines are normalized to \n.
+func decodeText(data []byte) (string, error) {
+	var decoded []byte
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}),
+		bytes.HasPrefix(data, []byte{0xff, 0xfe}),
+		bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		var err error
+		decoded, _, err = transform.Bytes(unicode.BOMOverride(transform.Nop), data)
+		if err != nil {
+			return "", fmt.Errorf("decoding text: %w", err)
+		}
+	case utf8.Valid(data):
+		decoded = data
+	default:
+		var err error
+		decoded, err = simplifiedchinese.GBK.NewDecoder().Bytes(data)
+		// invalid bytes are decoded as U+FFFD, they are neither UTF-8 nor GBK
+		if err != nil || bytes.ContainsRune(decoded, utf8.RuneError) {
+			return "", errUnknownEncoding
+		}
+	}
+	txt := string(decoded)
+	// binary files
+	if !utf8.ValidString(txt) || strings.ContainsRune(txt, 0) {
+		return "", errUnknownEncoding
+	}
+	txt = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(txt)
+	return txt, nil
+}
+
+// renderTextDoc renders txt of kind to be printed.
+func renderTextDoc(kind, txt string) (string, error) {
+	switch kind {
+	case textDocCSV:
+		return renderCSV(txt)
+	case textDocMarkdown:
+		return renderMarkdown(expandTabs(txt)), nil
+	}
+	return expandTabs(txt), nil
+}
+
+// expandTabs replaces tabs in txt with spaces up to the next tab stop.
+func expandTabs(txt string) string {
+	if !strings.Contains(txt, "\t") {
+		return txt
+	}
+	var sb strings.Builder
+	col := 0
+	for _, r := range txt {
+		switch r {
+		case '\t':
+			n := textDocTabWidth - col%textDocTabWidth
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+		case '\n':
+			sb.WriteRune(r)
+			col = 0
+		default:
+			sb.WriteRune(r)
+			col++
+		}
+	}
+	return sb.String()
+}
+
+// renderMarkdown underlines headings of the first two levels, bullets list items and draws rules,
+// code blocks are kept as they are.
+func renderMarkdown(txt string) string {
+	var lines []string
+	inCode := false
+	for _, line := range strings.Split(txt, "\n") {
+		if reMarkdownFence.MatchString(line) {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			lines = append(lines, line)
+			continue
+		}
+
+		if m := reMarkdownHeading.FindStringSubmatch(line); m != nil {
+			if len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
+			lines = append(lines, m[2])
+			switch len(m[1]) {
+			case 1:
+				lines = append(lines, underline(m[2], "="))
+			case 2:
+				lines = append(lines, underline(m[2], "-"))
+			}
+			continue
+		}
+		if reMarkdownRule.MatchString(line) {
+			lines = append(lines, strings.Repeat("-", memobird.LineCells))
+			continue
+		}
+		if m := reMarkdownItem.FindStringSubmatch(line); m != nil {
+			lines = append(lines, m[1]+"· "+m[2])
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// underline returns a line of c as wide as txt, at most as wide as the paper.
+func underline(txt, c string) string {
+	n := memobird.TextCells(txt)
+	if n > memobird.LineCells {
+		n = memobird.LineCells
+	}
+	return strings.Repeat(c, n)
+}
+
+// renderCSV renders the records of txt as a table as wide as the paper, the first record is the header.
+func renderCSV(txt string) (string, error) {
+	r := csv.NewReader(strings.NewReader(txt))
+	r.Comma = guessCSVComma(txt)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("parsing CSV: %w", err)
+	}
+	return formatTable(records, memobird.LineCells), nil
+}
+
+// guessCSVComma returns the most frequent one of comma, semicolon and tab in the first line of txt.
+func guessCSVComma(txt string) rune {
+	first := txt
+	if i := strings.IndexByte(txt, '\n'); i >= 0 {
+		first = txt[:i]
+	}
+	comma, count := ',', strings.Count(first, ",")
+	for _, c := range []rune{';', '\t'} {
+		if n := strings.Count(first, string(c)); n > count {
+			comma, count = c, n
+		}
+	}
+	return comma
+}
+
+// formatTable formats records as a table of at most lineCells cells wide, columns are separated by a
+// space and long fields are wrapped. Records are listed field by field if there are too many columns.
+func formatTable(records [][]string, lineCells int) string {
+	columns := 0
+	for i, record := range records {
+		for j, field := range record {
+			records[i][j] = strings.TrimSpace(strings.Replace(field, "\n", " ", -1))
+		}
+		if len(record) > columns {
+			columns = len(record)
+		}
+	}
+	if columns == 0 {
+		return ""
+	}
+	available := lineCells - (columns - 1)
+	if available < columns*csvMinColumnCells {
+		return formatRecords(records)
+	}
+
+	widths := make([]int, columns)
+	for _, record := range records {
+		for j, field := range record {
+			if n := memobird.TextCells(field); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+	fitWidths(widths, available)
+
+	var lines []string
+	for i, record := range records {
+		cells := make([][]string, columns)
+		height := 1
+		for j := range cells {
+			field := ""
+			if j < len(record) {
+				field = record[j]
+			}
+			cells[j] = memobird.WrapText(field, widths[j])
+			if len(cells[j]) > height {
+				height = len(cells[j])
+			}
+		}
+		for k := 0; k < height; k++ {
+			var sb strings.Builder
+			for j, cell := range cells {
+				if j > 0 {
+					sb.WriteByte(' ')
+				}
+				part := ""
+				if k < len(cell) {
+					part = cell[k]
+				}
+				sb.WriteString(part)
+				sb.WriteString(strings.Repeat(" ", widths[j]-memobird.TextCells(part)))
+			}
+			lines = append(lines, strings.TrimRight(sb.String(), " "))
+		}
+		if i == 0 && len(records) > 1 {
+			rules := make([]string, columns)
+			for j, w := range widths {
+				rules[j] = strings.Repeat("-", w)
+			}
+			lines = append(lines, strings.Join(rules, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fitWidths narrows widths to fit in available cells, narrow columns are kept and the others share
+// what's left evenly.
+func fitWidths(widths []int, available int) {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	if total <= available {
+		return
+	}
+
+	wide := make([]int, 0, len(widths))
+	for j := range widths {
+		wide = append(wide, j)
+	}
+	for {
+		share := available / len(wide)
+		var wider []int
+		for _, j := range wide {
+			if widths[j] <= share {
+				available -= widths[j]
+			} else {
+				wider = append(wider, j)
+			}
+		}
+		if len(wider) == len(wide) {
+			break
+		}
+		wide = wider
+	}
+	share, rest := available/len(wide), available%len(wide)
+	for k, j := range wide {
+		widths[j] = share
+		if k < rest {
+			widths[j]++
+		}
+	}
+}
+
+// formatRecords lists records after the header field by field, each prefixed by its name.
+func formatRecords(records [][]string) string {
+	header := records[0]
+	var blocks []string
+	for _, record := range records[1:] {
+		lines := make([]string, 0, len(record))
+		for j, field := range record {
+			name := fmt.Sprintf("#%d", j+1)
+			if j < len(header) && header[j] != "" {
+				name = header[j]
+			}
+			lines = append(lines, name+": "+field)
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// splitTextDoc splits txt into parts of at most maxLines printed lines, breaking at newlines only.
+// The total number of printed lines is returned as well.
+func splitTextDoc(txt string, maxLines int) ([]string, int) {
+	var parts, lines []string
+	count, total := 0, 0
+	flush := func() {
+		if part := strings.TrimRight(strings.Join(lines, "\n"), "\n "); part != "" {
+			parts = append(parts, part)
+		}
+		lines, count = nil, 0
+	}
+	for _, line := range strings.Split(strings.TrimRight(txt, "\n"), "\n") {
+		n := len(memobird.WrapText(line, memobird.LineCells))
+		if count+n > maxLines && count > 0 {
+			flush()
+		}
+		if count == 0 && strings.TrimSpace(line) == "" {
+			// parts don't start with blank lines
+			continue
+		}
+		lines = append(lines, line)
+		count += n
+		total += n
+	}
+	flush()
+	return parts, total
+}
+
+// paperLength returns the length of paper taken by lines of text in millimeters.
+func paperLength(lines int) int {
+	return lines * memobird.LineHeight / memobird.DotsPerMM
+}
+
+// formatLength formats mm in centimeters, or meters if longer than one.
+func formatLength(mm int) string {
+	if mm < 1000 {
+		return fmt.Sprintf("%dcm", (mm+5)/10)
+	}
+	return fmt.Sprintf("%.1fm", float64(mm)/1000)
+}
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextDocKind(t *testing.T) {
+	assert.Equal(t, textDocPlain, textDocKind("server.LOG", "application/octet-stream"))
+	assert.Equal(t, textDocMarkdown, textDocKind("README.md", ""))
+	assert.Equal(t, textDocCSV, textDocKind("", "text/csv"))
+	assert.Equal(t, "", textDocKind("photo.jpg", "text/plain"))
+	assert.Equal(t, "", textDocKind("", "image/png"))
+}
+
+func TestDecodeText(t *testing.T) {
+	txt, err := decodeText([]byte("你好\r\nworld"))
+	assert.NoError(t, err)
+	assert.Equal(t, "你好\nworld", txt)
+
+	txt, err = decodeText([]byte{0xc4, 0xe3, 0xba, 0xc3, '\n'})
+	assert.NoError(t, err)
+	assert.Equal(t, "你好\n", txt, "GBK")
+
+	txt, err = decodeText([]byte{0xef, 0xbb, 0xbf, 'h', 'i'})
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", txt, "UTF-8 with a BOM")
+
+	txt, err = decodeText([]byte{0xff, 0xfe, 0x60, 0x4f, 'h', 0})
+	assert.NoError(t, err)
+	assert.Equal(t, "你h", txt, "UTF-16LE")
+
+	txt, err = decodeText([]byte{0xfe, 0xff, 0x4f, 0x60, 0, 'h'})
+	assert.NoError(t, err)
+	assert.Equal(t, "你h", txt, "UTF-16BE")
+
+	_, err = decodeText([]byte{0x89, 'P', 'N', 'G', 0, 0, 0xff, 0xff})
+	assert.Equal(t, errUnknownEncoding, err)
+}
+
+func TestExpandTabs(t *testing.T) {
+	assert.Equal(t, "a   b\n    c", expandTabs("a\tb\n\tc"))
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	md := "# Title\nIntro\n## Section\n- one\n  * nested\n### Small ###\n***\n```\n- code\n```"
+	assert.Equal(t, "Title\n=====\nIntro\n\nSection\n-------\n· one\n  · nested\n\nSmall\n"+
+		"--------------------------------\n- code", renderMarkdown(md))
+}
+
+func TestRenderCSV(t *testing.T) {
+	txt, err := renderCSV("name,qty\napple,3\n\"banana, ripe\",12\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "name         qty\n------------ ---\napple        3\nbanana, ripe 12", txt)
+
+	txt, err = renderCSV("a;b\n1;2\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "a b\n- -\n1 2", txt, "semicolon separated")
+}
+
+func TestFormatTable(t *testing.T) {
+	records := [][]string{{"id", "description"}, {"1", "a very long description"}}
+	assert.Equal(t, "id descriptio\n   n\n-- ----------\n1  a very lon\n   g descript\n   ion",
+		formatTable(records, 13))
+
+	wide := [][]string{{"a", "b", "c", "d"}, {"1", "2", "3", "4"}}
+	assert.Equal(t, "a: 1\nb: 2\nc: 3\nd: 4", formatTable(wide, 10), "too many columns")
+}
+
+func TestFitWidths(t *testing.T) {
+	widths := []int{2, 30, 10}
+	fitWidths(widths, 20)
+	assert.Equal(t, []int{2, 9, 9}, widths)
+
+	widths = []int{2, 3}
+	fitWidths(widths, 20)
+	assert.Equal(t, []int{2, 3}, widths)
+}
+
+func TestSplitTextDoc(t *testing.T) {
+	parts, lines := splitTextDoc("a\nb\n\nc\nd\n", 2)
+	assert.Equal(t, []string{"a\nb", "c\nd"}, parts)
+	assert.Equal(t, 4, lines)
+
+	parts, lines = splitTextDoc("\n\n", 2)
+	assert.Empty(t, parts)
+	assert.Equal(t, 0, lines)
+}
+
+func TestFormatLength(t *testing.T) {
+	assert.Equal(t, "30cm", formatLength(paperLength(100)))
+	assert.Equal(t, "1.5m", formatLength(paperLength(500)))
+	assert.Equal(t, "1KB", formatSize(1000))
+	assert.Equal(t, "1.0MB", formatSize(1<<20))
+}
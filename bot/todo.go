@@ -0,0 +1,388 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+)
+
+const (
+	// todoDefaultList is the name of the list used if none is given.
+	todoDefaultList = "todo"
+	// todoMaxLists is the number of lists a user can have.
+	todoMaxLists = 10
+	// todoMaxItems is the number of items a list can have.
+	todoMaxItems = 50
+	// todoMaxNameLen and todoMaxItemLen are the maximum numbers of characters in names and items.
+	todoMaxNameLen = 32
+	todoMaxItemLen = 200
+)
+
+// splitTodoList returns the list named by the leading "#name" of s and the rest of it, todoDefaultList
+// is returned if s doesn't start with one.
+func splitTodoList(s string) (name, rest string) {
+	word, rest := cutWord(s)
+	if len(word) < 2 || word[0] != '#' {
+		return todoDefaultList, strings.TrimSpace(s)
+	}
+	return strings.ToLower(word[1:]), rest
+}
+
+// parseTodo splits payload into the action, the list named and the rest of it, the action is empty if
+// payload starts with the list, e.g. "#groceries" shows that list.
+func parseTodo(payload string) (action, name, rest string) {
+	action, rest = cutWord(payload)
+	if strings.HasPrefix(action, "#") {
+		action, rest = "", payload
+	}
+	name, rest = splitTodoList(rest)
+	return action, name, rest
+}
+
+func (b *Bot) handleTodo(m *message) {
+	action, name, rest := parseTodo(m.Payload)
+	if utf8.RuneCountInString(name) > todoMaxNameLen {
+		b.Send(m.Sender, m.T(replyTodoHelp))
+		return
+	}
+	switch {
+	case action == "lists" && rest == "":
+		b.listTodoLists(m)
+	case action == "add" && rest != "":
+		b.addTodo(m, name, rest)
+	case (action == "" || action == "list") && rest == "":
+		b.showTodoList(m, name)
+	case (action == "done" || action == "undo") && rest != "":
+		b.checkTodo(m, name, rest, action == "done")
+	case action == "print" && rest == "":
+		b.printTodoList(m, name)
+	case action == "clear" && rest == "":
+		b.clearTodoList(m, name)
+	case action == "delete" && rest == "":
+		b.deleteTodoList(m, name)
+	case action == "morning" && rest != "":
+		b.setTodoMorning(m, name, rest)
+	default:
+		b.Send(m.Sender, m.T(replyTodoHelp))
+	}
+}
+
+// todoList returns the list of the sender named name, false is returned after replying if not found.
+func (b *Bot) todoList(m *message, name string) (*model.TodoList, bool) {
+	list, err := b.TodoService.GetList(m.SenderUser.ID, name)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyTodoListNotFound, i18n.Params{"list": name}))
+		return nil, false
+	}
+	if err != nil {
+		log.Warnf("error querying todo list[%s] of user[%d]: %s", name, m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return nil, false
+	}
+	return list, true
+}
+
+// todoItems returns the items of list, false is returned after replying on errors.
+func (b *Bot) todoItems(m *message, list *model.TodoList) ([]model.TodoItem, bool) {
+	items, err := b.TodoService.ListItems(list.ID)
+	if err != nil {
+		log.Warnf("error querying items of todo list[%d]: %s", list.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return nil, false
+	}
+	return items, true
+}
+
+func (b *Bot) listTodoLists(m *message) {
+	lists, err := b.TodoService.ListLists(m.SenderUser.ID)
+	if err != nil {
+		log.Warnf("error querying todo lists of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if len(lists) == 0 {
+		b.Send(m.Sender, m.T(replyTodoListsNone))
+		return
+	}
+
+	lines := []string{m.T(replyTodoListsHeader)}
+	for _, list := range lists {
+		items, err := b.TodoService.ListItems(list.ID)
+		if err != nil {
+			log.Warnf("error querying items of todo list[%d]: %s", list.ID, err)
+			continue
+		}
+		line := fmt.Sprintf("#%s: %s", list.Name, m.N(replyTodoOpenItems, len(openTodoItems(items))))
+		if list.MorningTime != "" {
+			line += ", " + m.T(replyTodoMorningAt, i18n.Params{"time": list.MorningTime})
+		}
+		lines = append(lines, line)
+	}
+	b.Send(m.Sender, strings.Join(lines, "\n"))
+}
+
+func (b *Bot) addTodo(m *message, name, text string) {
+	if utf8.RuneCountInString(text) > todoMaxItemLen {
+		b.Send(m.Sender, m.N(replyTodoItemTooLong, todoMaxItemLen))
+		return
+	}
+	_, err := b.TodoService.GetList(m.SenderUser.ID, name)
+	if service.IsRecordNotFoundError(err) {
+		var lists []model.TodoList
+		lists, err = b.TodoService.ListLists(m.SenderUser.ID)
+		if err == nil && len(lists) >= todoMaxLists {
+			b.Send(m.Sender, m.N(replyTodoTooManyLists, todoMaxLists))
+			return
+		}
+	}
+	var list *model.TodoList
+	if err == nil {
+		list, err = b.TodoService.GetOrNewList(m.SenderUser.ID, name)
+	}
+	if err != nil {
+		log.Warnf("error creating todo list[%s] of user[%d]: %s", name, m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+
+	items, ok := b.todoItems(m, list)
+	if !ok {
+		return
+	}
+	if len(items) >= todoMaxItems {
+		b.Send(m.Sender, m.N(replyTodoTooManyItems, todoMaxItems))
+		return
+	}
+	if _, err := b.TodoService.AddItem(list.ID, text); err != nil {
+		log.Warnf("error adding item to todo list[%d]: %s", list.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	b.Send(m.Sender, m.T(replyTodoAdded, i18n.Params{"list": list.Name, "n": len(items) + 1}))
+}
+
+func (b *Bot) showTodoList(m *message, name string) {
+	list, ok := b.todoList(m, name)
+	if !ok {
+		return
+	}
+	items, ok := b.todoItems(m, list)
+	if !ok {
+		return
+	}
+	if len(items) == 0 {
+		b.Send(m.Sender, m.T(replyTodoEmpty, i18n.Params{"list": list.Name}))
+		return
+	}
+	b.Send(m.Sender, describeTodoList(list.Name, items))
+}
+
+// describeTodoList lists items numbered as they are referred to in commands.
+func describeTodoList(name string, items []model.TodoItem) string {
+	lines := []string{"#" + name}
+	for i, item := range items {
+		box := "☐"
+		if item.IsDone() {
+			box = "☑"
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s %s", i+1, box, item.Text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *Bot) checkTodo(m *message, name, arg string, done bool) {
+	list, ok := b.todoList(m, name)
+	if !ok {
+		return
+	}
+	items, ok := b.todoItems(m, list)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(items) {
+		b.Send(m.Sender, m.T(replyTodoItemNotFound, i18n.Params{"list": list.Name}))
+		return
+	}
+
+	item := &items[n-1]
+	var at *time.Time
+	if done {
+		now := time.Now()
+		at = &now
+	}
+	if err := b.TodoService.SetDone(item.ID, at); err != nil {
+		log.Warnf("error checking todo item[%d]: %s", item.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	item.DoneAt = at
+	b.Send(m.Sender, describeTodoList(list.Name, items))
+}
+
+func (b *Bot) printTodoList(m *message, name string) {
+	list, ok := b.todoList(m, name)
+	if !ok {
+		return
+	}
+	items, ok := b.todoItems(m, list)
+	if !ok {
+		return
+	}
+	open := openTodoItems(items)
+	if len(open) == 0 {
+		b.Send(m.Sender, m.T(replyTodoNothingToPrint, i18n.Params{"list": list.Name}))
+		return
+	}
+
+	device, err := b.printableDevice(m.SenderUser)
+	if service.IsRecordNotFoundError(err) {
+		b.Send(m.Sender, m.T(replyBindHelp))
+		return
+	}
+	if err != nil {
+		log.Warn("error querying device:", err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	text := renderTodoSlip(m.Lang, list.Name, time.Now().In(deviceLocation(device)), open)
+	reply, _ := b.printText(m.Lang, m.SenderUser, device, text)
+	b.Send(m.Sender, reply)
+}
+
+func (b *Bot) clearTodoList(m *message, name string) {
+	list, ok := b.todoList(m, name)
+	if !ok {
+		return
+	}
+	n, err := b.TodoService.DeleteDone(list.ID)
+	if err != nil {
+		log.Warnf("error clearing todo list[%d]: %s", list.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	b.Send(m.Sender, m.N(replyTodoCleared, int(n), i18n.Params{"list": list.Name}))
+}
+
+func (b *Bot) deleteTodoList(m *message, name string) {
+	list, ok := b.todoList(m, name)
+	if !ok {
+		return
+	}
+	if err := b.TodoService.DeleteList(list.ID); err != nil {
+		log.Warnf("error deleting todo list[%d]: %s", list.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	b.Send(m.Sender, m.T(replyTodoDeleted, i18n.Params{"list": list.Name}))
+}
+
+// setTodoMorning handles "morning [#list] HH:MM|off".
+func (b *Bot) setTodoMorning(m *message, name, arg string) {
+	var morningTime string
+	if arg != "off" {
+		at, err := time.Parse(model.DigestTimeLayout, arg)
+		if err != nil {
+			b.Send(m.Sender, m.T(replyTodoHelp))
+			return
+		}
+		morningTime = at.Format(model.DigestTimeLayout)
+	}
+	list, ok := b.todoList(m, name)
+	if !ok {
+		return
+	}
+	if err := b.TodoService.SetMorningTime(list.ID, morningTime); err != nil {
+		log.Warnf("error setting morning time of todo list[%d]: %s", list.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if morningTime == "" {
+		b.Send(m.Sender, m.T(replyTodoMorningOff, i18n.Params{"list": list.Name}))
+		return
+	}
+	b.Send(m.Sender, m.T(replyTodoMorningSet, i18n.Params{"list": list.Name, "time": morningTime}))
+}
+
+func openTodoItems(items []model.TodoItem) []model.TodoItem {
+	var open []model.TodoItem
+	for _, item := range items {
+		if !item.IsDone() {
+			open = append(open, item)
+		}
+	}
+	return open
+}
+
+// renderTodoSlip renders items as a slip of checkboxes titled by the list name and the date of now.
+func renderTodoSlip(lang i18n.Lang, name string, now time.Time, items []model.TodoItem) string {
+	lines := []string{
+		catalogs.T(lang, replyTodoSlipTitle, i18n.Params{"list": name}),
+		now.Format("2006-01-02"),
+		"",
+	}
+	for _, item := range items {
+		// ☐ is not in GBK
+		lines = append(lines, "□ "+item.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// printTodoMornings prints the lists due at now with items left to do.
+func (b *Bot) printTodoMornings(now time.Time) {
+	lists, err := b.TodoService.ListMorningLists()
+	if err != nil {
+		log.Warn("error querying todo lists:", err)
+		return
+	}
+	for i := range lists {
+		b.printTodoMorningIfDue(&lists[i], now)
+	}
+}
+
+func (b *Bot) printTodoMorningIfDue(list *model.TodoList, now time.Time) {
+	user, err := b.UserService.GetByID(list.UserID)
+	if err != nil {
+		log.Warnf("error querying user[%d]: %s", list.UserID, err)
+		return
+	}
+	device, err := b.printableDevice(user)
+	if err != nil {
+		if !service.IsRecordNotFoundError(err) {
+			log.Warn("error querying device:", err)
+		}
+		return
+	}
+	loc := deviceLocation(device)
+	if !list.IsMorningDue(now, loc) {
+		return
+	}
+	items, err := b.TodoService.ListItems(list.ID)
+	if err != nil {
+		log.Warnf("error querying items of todo list[%d]: %s", list.ID, err)
+		return
+	}
+	if open := openTodoItems(items); len(open) > 0 {
+		lang := langOf(user, "")
+		reply, outcome := b.printText(lang, user, device, renderTodoSlip(lang, list.Name, now.In(loc), open))
+		switch outcome {
+		case printRequestError, printShuttingDown:
+			// try again next time
+			return
+		case printSuccess:
+		default:
+			log.Warnf("error printing todo list[%d] for user[%d]: %s", list.ID, user.ID, reply)
+		}
+	}
+	if err := b.TodoService.MarkMorningPrinted(list.ID, now); err != nil {
+		log.Warnf("error marking todo list[%d] printed: %s", list.ID, err)
+	}
+}
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestSplitTodoList(t *testing.T) {
+	for _, c := range []struct {
+		s, name, rest string
+	}{
+		{"", todoDefaultList, ""},
+		{"milk", todoDefaultList, "milk"},
+		{"#Groceries milk and eggs", "groceries", "milk and eggs"},
+		{"#work", "work", ""},
+		{"# milk", todoDefaultList, "# milk"},
+	} {
+		name, rest := splitTodoList(c.s)
+		assert.Equal(t, c.name, name, c.s)
+		assert.Equal(t, c.rest, rest, c.s)
+	}
+}
+
+func TestParseTodo(t *testing.T) {
+	for _, c := range []struct {
+		payload, action, name, rest string
+	}{
+		{"", "", todoDefaultList, ""},
+		{"#groceries", "", "groceries", ""},
+		{"list #groceries", "list", "groceries", ""},
+		{"add #groceries milk", "add", "groceries", "milk"},
+		{"add milk", "add", todoDefaultList, "milk"},
+	} {
+		action, name, rest := parseTodo(c.payload)
+		assert.Equal(t, c.action, action, c.payload)
+		assert.Equal(t, c.name, name, c.payload)
+		assert.Equal(t, c.rest, rest, c.payload)
+	}
+}
+
+func TestRenderTodoSlip(t *testing.T) {
+	now := time.Date(2019, 10, 1, 7, 0, 0, 0, time.UTC)
+	done := now.Add(-time.Hour)
+	items := []model.TodoItem{{Text: "Milk"}, {Text: "Eggs", DoneAt: &done}, {Text: "Bread"}}
+
+	assert.Equal(t, "#groceries\n1. ☐ Milk\n2. ☑ Eggs\n3. ☐ Bread", describeTodoList("groceries", items))
+	assert.Equal(t, "To-do: groceries\n2019-10-01\n\n□ Milk\n□ Bread",
+		renderTodoSlip(i18n.English, "groceries", now, openTodoItems(items)))
+}
@@ -0,0 +1,246 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/i18n"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+	"github.com/awesome-memobird/the-memobird-bot/webhook"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// WebhookPath is the path of incoming webhooks on the HTTP server, followed by secrets of webhooks.
+const WebhookPath = "/webhook/"
+
+const (
+	webhookMaxBodySize = 1 << 20
+	// webhookDedupWindow is how long a repeated payload like a still firing alert is not printed again.
+	webhookDedupWindow     = 12 * time.Hour
+	webhookTemplateExample = "{{.message}}"
+)
+
+// webhookURL returns the URL of the webhook of secret.
+func (b *Bot) webhookURL(secret string) string {
+	return strings.TrimSuffix(b.WebhookBaseURL, "/") + WebhookPath + secret
+}
+
+// cutWord splits the first word from s.
+func cutWord(s string) (word, rest string) {
+	s = strings.TrimLeft(s, " \t\n")
+	if i := strings.IndexAny(s, " \t\n"); i >= 0 {
+		return s[:i], strings.TrimLeft(s[i:], " \t\n")
+	}
+	return s, ""
+}
+
+func (b *Bot) handleWebhook(m *message) {
+	action, rest := cutWord(m.Payload)
+	switch action {
+	case "", "list":
+		b.listWebhooks(m)
+	case "new":
+		b.newWebhook(m, strings.TrimSpace(rest))
+	case "delete":
+		b.deleteWebhook(m, strings.TrimSpace(rest))
+	case "template":
+		id, tmpl := cutWord(rest)
+		b.setWebhookTemplate(m, id, strings.TrimSpace(tmpl))
+	default:
+		b.Send(m.Sender, m.T(replyWebhookHelp, i18n.Params{"kinds": strings.Join(webhook.Kinds, "|")}))
+	}
+}
+
+func (b *Bot) listWebhooks(m *message) {
+	webhooks, err := b.WebhookService.ListByUserID(m.SenderUser.ID)
+	if err != nil {
+		log.Warnf("error querying webhooks of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if len(webhooks) == 0 {
+		b.Send(m.Sender, m.T(replyWebhookHelp, i18n.Params{"kinds": strings.Join(webhook.Kinds, "|")}))
+		return
+	}
+
+	lines := []string{m.T(replyWebhooksHeader)}
+	for _, w := range webhooks {
+		lines = append(lines, fmt.Sprintf("#%d %s\n%s", w.ID, w.Kind, b.webhookURL(w.Secret)))
+		if webhook.IsSigned(w.Kind) {
+			lines = append(lines, m.T(replyWebhookSigningSecret, i18n.Params{"secret": w.SigningSecret}))
+		}
+	}
+	b.Send(m.Sender, strings.Join(lines, "\n"), &tb.SendOptions{DisableWebPagePreview: true})
+}
+
+func (b *Bot) newWebhook(m *message, kind string) {
+	if !webhook.IsKind(kind) {
+		b.Send(m.Sender, m.T(replyWebhookHelp, i18n.Params{"kinds": strings.Join(webhook.Kinds, "|")}))
+		return
+	}
+	// the URL is useless without the host
+	if b.WebhookBaseURL == "" {
+		b.Send(m.Sender, m.T(replyWebhookNoPublicURL))
+		return
+	}
+	device, ok := b.ownedVerifiedDevice(m)
+	if !ok {
+		return
+	}
+
+	w, err := b.WebhookService.New(&model.Webhook{
+		UserID:   m.SenderUser.ID,
+		DeviceID: device.ID,
+		Kind:     kind,
+	})
+	if err != nil {
+		log.Warnf("error creating webhook of user[%d]: %s", m.SenderUser.ID, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	reply := m.T(replyWebhookCreated, i18n.Params{"id": w.ID, "kind": w.Kind, "url": b.webhookURL(w.Secret)})
+	if kind == webhook.KindJSON {
+		reply += "\n" + m.T(replyWebhookTemplateNeeded, i18n.Params{"id": w.ID, "example": webhookTemplateExample})
+	}
+	if webhook.IsSigned(kind) {
+		reply += "\n" + m.T(replyWebhookSigningSecret, i18n.Params{"secret": w.SigningSecret})
+	}
+	b.Send(m.Sender, reply, &tb.SendOptions{DisableWebPagePreview: true})
+}
+
+func (b *Bot) deleteWebhook(m *message, arg string) {
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil {
+		b.Send(m.Sender, m.T(replyWebhookHelp, i18n.Params{"kinds": strings.Join(webhook.Kinds, "|")}))
+		return
+	}
+	deleted, err := b.WebhookService.DeleteByIDAndUserID(uint(id), m.SenderUser.ID)
+	if err != nil {
+		log.Warnf("error deleting webhook[%d]: %s", id, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if !deleted {
+		b.Send(m.Sender, m.T(replyWebhookNotFound))
+		return
+	}
+	b.Send(m.Sender, m.T(replyWebhookDeleted, i18n.Params{"id": id}))
+}
+
+func (b *Bot) setWebhookTemplate(m *message, arg, tmpl string) {
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || tmpl == "" {
+		b.Send(m.Sender, m.T(replyWebhookHelp, i18n.Params{"kinds": strings.Join(webhook.Kinds, "|")}))
+		return
+	}
+	if _, err := webhook.ParseTemplate(tmpl); err != nil {
+		b.Send(m.Sender, m.T(replyWebhookInvalidTemplate, i18n.Params{"error": err}))
+		return
+	}
+	updated, err := b.WebhookService.SetTemplate(uint(id), m.SenderUser.ID, tmpl)
+	if err != nil {
+		log.Warnf("error setting template of webhook[%d]: %s", id, err)
+		b.Send(m.Sender, m.T(replyFailedGettingData))
+		return
+	}
+	if !updated {
+		b.Send(m.Sender, m.T(replyWebhookNotFound))
+		return
+	}
+	b.Send(m.Sender, m.T(replyWebhookTemplateSet, i18n.Params{"id": id}))
+}
+
+// printStatus maps outcomes of printing to HTTP status codes.
+var printStatus = map[string]int{
+	printSuccess:       http.StatusOK,
+	printQuotaExceeded: http.StatusTooManyRequests,
+	printShuttingDown:  http.StatusServiceUnavailable,
+	printRequestError:  http.StatusBadGateway,
+	printNotSuccessful: http.StatusBadGateway,
+}
+
+// ServeWebhook prints payloads posted to webhooks.
+func (b *Bot) ServeWebhook(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hook, err := b.WebhookService.GetBySecret(strings.TrimPrefix(req.URL.Path, WebhookPath))
+	if service.IsRecordNotFoundError(err) {
+		http.NotFound(w, req)
+		return
+	}
+	if err != nil {
+		log.Warn("error querying webhook:", err)
+		http.Error(w, "failed getting data", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := b.UserService.GetByID(hook.UserID)
+	if err != nil {
+		log.Warnf("error querying user[%d]: %s", hook.UserID, err)
+		http.Error(w, "failed getting data", http.StatusInternalServerError)
+		return
+	}
+	if user.IsBanned() {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	device, err := b.DeviceService.GetByID(hook.DeviceID)
+	if err != nil && !service.IsRecordNotFoundError(err) {
+		log.Warnf("error querying device[%d]: %s", hook.DeviceID, err)
+		http.Error(w, "failed getting data", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || !device.IsVerified() || device.UserID != user.ID {
+		http.Error(w, "device is no longer available", http.StatusGone)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, webhookMaxBodySize+1))
+	if err != nil {
+		http.Error(w, "failed reading body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > webhookMaxBodySize {
+		http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := webhook.Verify(hook.Kind, req.Header, body, hook.SigningSecret); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	summary, err := webhook.Render(hook.Kind, req.Header, body, hook.Template)
+	if err == webhook.ErrIgnored {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := fmt.Sprintf("%d:%s", hook.ID, summary.Key)
+	if b.webhookDedup.Seen(key, time.Now()) {
+		io.WriteString(w, "duplicate payload ignored\n")
+		return
+	}
+
+	reply, outcome := b.printText(langOf(user, ""), user, device, summary.Text)
+	if outcome != printSuccess {
+		// let the sender retry
+		b.webhookDedup.Forget(key)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(printStatus[outcome])
+	io.WriteString(w, reply+"\n")
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"  // Image Decoder
+	_ "image/jpeg" // Image Decoder
+	_ "image/png"  // Image Decoder
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/repository"
+	"github.com/awesome-memobird/the-memobird-bot/service"
+)
+
+// command is a subcommand of the CLI.
+type command struct {
+	// Name is the words to invoke the command, e.g. "devices list".
+	Name    string
+	Args    string
+	Summary string
+	Run     func(name string, args []string) error
+}
+
+var commands []command
+
+func init() {
+	// assigned in init as usage refers to commands
+	commands = []command{
+		{"serve", "", "serve the bot, the default command", runServe},
+		{"print", "-device <memobirdID> [-file f | -image img.png] text...", "print to a memobird directly", runPrint},
+		{"bind", "<memobirdID>", "bind a memobird to the access key", runBind},
+		{"status", "<contentID>", "query whether a content is printed", runStatus},
+		{"devices list", "", "list devices in the database", runDevicesList},
+		{"users list", "", "list users in the database", runUsersList},
+		{"doctor", "", "check the access key, the database and the telegram token", runDoctor},
+		{"config print", "", "print the effective config with secrets redacted", runConfigPrint},
+		{"keys rotate", "[-batch n]", "reseal stored texts, verification codes and webhook secrets by the current encryption key", runKeysRotate},
+	}
+}
+
+// findCommand returns the command invoked by args and the rest arguments, nil if not found.
+func findCommand(args []string) (*command, []string) {
+	for i, cmd := range commands {
+		words := strings.Fields(cmd.Name)
+		if len(args) < len(words) {
+			continue
+		}
+		matched := true
+		for j, w := range words {
+			if args[j] != w {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return &commands[i], args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+func usage() {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(os.Stderr, "Usage:\n")
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s [flags] %s\t%s\n", name, cmd.Name, cmd.Args, cmd.Summary)
+	}
+	w.Flush()
+	fmt.Fprintf(os.Stderr, "\nRun a command with -h to list its flags.\n")
+}
+
+// newFlagSet returns a flag set of the command with flags of configurations registered.
+func newFlagSet(name string) (*flag.FlagSet, *configFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return fs, addConfigFlags(fs, os.Getenv)
+}
+
+// parseConfig parses args and loads the config, nargs is the number of expected positional arguments,
+// negative means any.
+func parseConfig(fs *flag.FlagSet, flags *configFlags, args []string, nargs int) (*Config, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if nargs >= 0 && fs.NArg() != nargs {
+		return nil, fmt.Errorf("expecting %d arguments, got %d", nargs, fs.NArg())
+	}
+	return flags.load(os.Getenv)
+}
+
+func runServe(name string, args []string) error {
+	fs, flags := newFlagSet(name)
+	config, err := parseConfig(fs, flags, args, 0)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	serve(config)
+	return nil
+}
+
+func runConfigPrint(name string, args []string) error {
+	fs, flags := newFlagSet(name)
+	config, err := parseConfig(fs, flags, args, 0)
+	if err != nil {
+		return err
+	}
+	out, err := yaml.Marshal(config.Redacted())
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	os.Stdout.Write(out)
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding image %s: %w", path, err)
+	}
+	return img, nil
+}
+
+func runPrint(name string, args []string) error {
+	fs, flags := newFlagSet(name)
+	deviceID := fs.String("device", "", "memobird ID of the device to print to")
+	file := fs.String("file", "", "path of a text file to print")
+	imagePath := fs.String("image", "", "path of a PNG, JPEG or GIF image to print")
+	config, err := parseConfig(fs, flags, args, -1)
+	if err != nil {
+		return err
+	}
+	if err := validate(config.checkMemobird); err != nil {
+		return err
+	}
+	if *deviceID == "" {
+		return fmt.Errorf("-device is required")
+	}
+	if *file != "" && *imagePath != "" {
+		return fmt.Errorf("-file and -image can not be used together")
+	}
+
+	doc := new(memobird.Document)
+	if text := strings.Join(fs.Args(), " "); text != "" {
+		if err := doc.AddText(text); err != nil {
+			return err
+		}
+	}
+	if *file != "" {
+		buf, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		if err := doc.AddText(string(buf)); err != nil {
+			return err
+		}
+	}
+	if *imagePath != "" {
+		img, err := decodeImage(*imagePath)
+		if err != nil {
+			return err
+		}
+		if err := doc.AddImage(img); err != nil {
+			return err
+		}
+	}
+	if doc.IsEmpty() {
+		return fmt.Errorf("nothing to print")
+	}
+
+	result, err := newBirdApp(&config.Memobird).Print(doc, *deviceID)
+	if err != nil {
+		return fmt.Errorf("printing: %w", err)
+	}
+	if !result.IsSuccess {
+		return fmt.Errorf("printing: %v", result.Err)
+	}
+	fmt.Printf("Content ID: %d\nPrinted: %t\n", result.ContentID, result.IsPrinted)
+	return nil
+}
+
+func runBind(name string, args []string) error {
+	fs, flags := newFlagSet(name)
+	config, err := parseConfig(fs, flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := validate(config.checkMemobird); err != nil {
+		return err
+	}
+
+	result, err := newBirdApp(&config.Memobird).BindDevice(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("binding: %w", err)
+	}
+	if !result.IsSuccess {
+		return fmt.Errorf("binding: %v", result.Err)
+	}
+	fmt.Printf("User ID: %d\n", result.UserID)
+	return nil
+}
+
+func runStatus(name string, args []string) error {
+	fs, flags := newFlagSet(name)
+	config, err := parseConfig(fs, flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := validate(config.checkMemobird); err != nil {
+		return err
+	}
+	contentID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid content ID: %s", fs.Arg(0))
+	}
+
+	result, err := newBirdApp(&config.Memobird).PrintStatus(contentID)
+	if err != nil {
+		return fmt.Errorf("querying status: %w", err)
+	}
+	if !result.IsSuccess {
+		return fmt.Errorf("querying status: %v", result.Err)
+	}
+	fmt.Printf("Content ID: %d\nPrinted: %t\n", contentID, result.IsPrinted)
+	return nil
+}
+
+func runDevicesList(name string, args []string) error {
+	fs, flags := newFlagSet(name)
+	config, err := parseConfig(fs, flags, args, 0)
+	if err != nil {
+		return err
+	}
+	if err := validate(config.checkDB); err != nil {
+		return err
+	}
+	db, err := openDB(&config.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	devices, err := (&service.Device{Repo: &repository.GormDevices{DB: db}}).List()
+	if err != nil {
+		return fmt.Errorf("querying devices: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMEMOBIRD ID\tUSER ID\tVERIFIED\tCREATED")
+	for _, d := range devices {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%t\t%s\n", d.ID, d.MemobirdID, d.UserID, d.IsVerified(), d.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+func runUsersList(name string, args []string) error {
+	fs, flags := newFlagSet(name)
+	config, err := parseConfig(fs, flags, args, 0)
+	if err != nil {
+		return err
+	}
+	if err := validate(config.checkDB); err != nil {
+		return err
+	}
+	db, err := openDB(&config.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	users, err := (&service.User{Repo: &repository.GormUsers{DB: db}}).List()
+	if err != nil {
+		return fmt.Errorf("querying users: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTELEGRAM ID\tUSERNAME\tNAME\tBANNED\tJOINED")
+	for _, u := range users {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%t\t%s\n",
+			u.ID, u.TelegramID, u.TelegramUserName, u.TelegramFullName, u.IsBanned(), u.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// checkAccessKey queries a content that doesn't exist, which succeeds only for a valid access key.
+func checkAccessKey(config *Config) (string, error) {
+	if err := validate(config.checkMemobird); err != nil {
+		return "", err
+	}
+	result, err := newBirdApp(&config.Memobird).PrintStatus(0)
+	if err != nil {
+		return "", fmt.Errorf("requesting API: %w", err)
+	}
+	if !result.IsSuccess {
+		if result.Err != nil {
+			return "", result.Err
+		}
+		return "", errors.New("access key rejected")
+	}
+	return "access key accepted", nil
+}
+
+func checkDB(config *Config) (string, error) {
+	if err := validate(config.checkDB); err != nil {
+		return "", err
+	}
+	db, err := openDB(&config.DB)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+	if err := db.DB().Ping(); err != nil {
+		return "", err
+	}
+	return config.DB.Driver, nil
+}
+
+func checkTelegram(config *Config) (string, error) {
+	if err := validate(config.checkTelegram); err != nil {
+		return "", err
+	}
+	b, err := tb.NewBot(tb.Settings{Token: config.Telegram.Token})
+	if err != nil {
+		return "", err
+	}
+	return "@" + b.Me.Username, nil
+}
+
+func runDoctor(name string, args []string) error {
+	fs, flags := newFlagSet(name)
+	config, err := parseConfig(fs, flags, args, 0)
+	if err != nil {
+		return err
+	}
+
+	checks := []struct {
+		name  string
+		check func(*Config) (string, error)
+	}{
+		{"memobird access key", checkAccessKey},
+		{"database", checkDB},
+		{"telegram token", checkTelegram},
+	}
+	var failed int
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, c := range checks {
+		detail, err := c.check(config)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\tFAIL\t%s\n", c.name, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\tOK\t%s\n", c.name, detail)
+	}
+	w.Flush()
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// resealer reseals a batch of rows after afterID by the current key, lastID is 0 if no rows are left.
+type resealer func(afterID uint, limit int) (lastID uint, n int, err error)
+
+// reseal reseals all rows in batches of size, the number of rows resealed is returned.
+func reseal(r resealer, size int) (int, error) {
+	var afterID uint
+	total := 0
+	for {
+		lastID, n, err := r(afterID, size)
+		if err != nil {
+			return total, fmt.Errorf("resealing rows after ID %d: %w", afterID, err)
+		}
+		total += n
+		if lastID == 0 {
+			return total, nil
+		}
+		afterID = lastID
+	}
+}
+
+func runKeysRotate(name string, args []string) error {
+	fs, flags := newFlagSet(name)
+	batch := fs.Int("batch", 500, "number of rows resealed in a transaction")
+	config, err := parseConfig(fs, flags, args, 0)
+	if err != nil {
+		return err
+	}
+	if err := validate(config.checkDB, config.checkEncryption); err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		return fmt.Errorf("-batch must be positive")
+	}
+	keyring, err := config.Encryption.Keyring()
+	if err != nil {
+		return err
+	}
+	if keyring == nil {
+		return fmt.Errorf("encryption keys are required, please specify them via environment variable %s", EnvEncryptionKeys)
+	}
+	db, err := openDB(&config.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tables := []struct {
+		name string
+		r    resealer
+	}{
+		{"contents", (&service.Content{Repo: &repository.GormContents{DB: db}, Keyring: keyring}).Reseal},
+		{"devices", (&service.Device{Repo: &repository.GormDevices{DB: db}, Keyring: keyring}).Reseal},
+		{"draft parts", (&service.Draft{DB: db, Keyring: keyring}).Reseal},
+		{"to-do items", (&service.Todo{DB: db, Keyring: keyring}).Reseal},
+		{"channel posts", (&service.Channel{DB: db, Keyring: keyring}).Reseal},
+		{"webhooks", (&service.Webhook{DB: db, Keyring: keyring}).Reseal},
+	}
+	for _, t := range tables {
+		n, err := reseal(t.r, *batch)
+		fmt.Printf("%s: %d resealed by key %s\n", t.name, n, keyring.Current())
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestFindCommand(t *testing.T) {
+	cmd, rest := findCommand([]string{"devices", "list", "-db-dsn", "x.db"})
+	if assert.NotNil(t, cmd) {
+		assert.Equal(t, "devices list", cmd.Name)
+	}
+	assert.Equal(t, []string{"-db-dsn", "x.db"}, rest)
+
+	cmd, rest = findCommand([]string{"print", "-device", "abc", "hello"})
+	if assert.NotNil(t, cmd) {
+		assert.Equal(t, "print", cmd.Name)
+	}
+	assert.Equal(t, []string{"-device", "abc", "hello"}, rest)
+
+	cmd, _ = findCommand([]string{"devices"})
+	assert.Nil(t, cmd)
+	cmd, _ = findCommand([]string{"unknown"})
+	assert.Nil(t, cmd)
+}
+
+func TestCheckAccessKey(t *testing.T) {
+	reply := `{"showapi_res_code": 1}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, reply)
+	}))
+	defer server.Close()
+	config := defaultConfig()
+	config.Memobird.AccessKey = "key"
+	config.Memobird.APIPrefix = server.URL
+
+	_, err := checkAccessKey(config)
+	assert.NoError(t, err)
+
+	for _, reply = range []string{
+		`{"showapi_res_code": 0, "showapi_res_error": "invalid key"}`,
+		`{"showapi_res_code": 0}`,
+	} {
+		_, err = checkAccessKey(config)
+		assert.Error(t, err, reply)
+	}
+}
+
+func TestCheckDBNotMigrating(t *testing.T) {
+	dir, err := ioutil.TempDir("", "commands")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config := defaultConfig()
+	config.DB.DSN = filepath.Join(dir, "test.db")
+
+	_, err = checkDB(config)
+	assert.NoError(t, err)
+	db, err := openDB(&config.DB)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	assert.False(t, db.HasTable(&model.User{}), "only serving migrates the schema")
+}
@@ -0,0 +1,547 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
+)
+
+// names of environment variables.
+const (
+	// the path of the YAML configuration file.
+	EnvConfigFile = "CONFIG_FILE"
+
+	// the token of telegram bot.
+	EnvToken = "TELEGRAM_TOKEN"
+	// the timeout of long polling telegram updates.
+	EnvPollerTimeout = "TELEGRAM_POLLER_TIMEOUT"
+	// the optional comma separated telegram IDs of administrators.
+	EnvAdminIDs = "ADMIN_IDS"
+
+	// the access key of memobird application.
+	EnvAccessKey = "MEMOBIRD_AK"
+	// the alternative prefix of memobird API.
+	EnvAPIPrefix = "MEMOBIRD_API_PREFIX"
+	// the timeout of memobird API requests.
+	EnvMemobirdTimeout = "MEMOBIRD_TIMEOUT"
+
+	// the postgres URL as provided by Heroku, sqlite is used if absent.
+	EnvDatabaseURL = "DATABASE_URL"
+	// the database driver, either sqlite3 or postgres.
+	EnvDBDriver = "DB_DRIVER"
+
+	// the port to listen as required by Heroku.
+	EnvPort = "PORT"
+	// the address to listen, overrides EnvPort.
+	EnvHTTPAddr = "HTTP_ADDR"
+	// the public URL of the HTTP server, used in URLs of webhooks.
+	EnvPublicURL = "PUBLIC_URL"
+
+	// the optional default quotas, 0 means unlimited.
+	EnvUserMessagesPerHour   = "QUOTA_USER_MESSAGES_PER_HOUR"
+	EnvUserCharsPerDay       = "QUOTA_USER_CHARS_PER_DAY"
+	EnvDeviceMessagesPerHour = "QUOTA_DEVICE_MESSAGES_PER_HOUR"
+	EnvDeviceCharsPerDay     = "QUOTA_DEVICE_CHARS_PER_DAY"
+
+	// the feature toggles.
+	EnvFeatureHistory = "FEATURE_HISTORY"
+	EnvFeatureSharing = "FEATURE_SHARING"
+
+	// how long to wait for in-flight work when shutting down.
+	EnvShutdownTimeout = "SHUTDOWN_TIMEOUT"
+	// how long a draft is kept without being added to.
+	EnvDraftIdleTimeout = "DRAFT_IDLE_TIMEOUT"
+	// the size in bytes of the largest text document printed.
+	EnvDocumentMaxSize = "DOCUMENT_MAX_SIZE"
+	// how long texts wait before being printed so that they can be edited or cancelled.
+	EnvPrintDelay = "PRINT_DELAY"
+
+	// the comma separated id:key pairs of master keys sealing data at rest, keys are base64 encoded.
+	EnvEncryptionKeys = "ENCRYPTION_KEYS"
+	// the ID of the master key sealing new data.
+	EnvEncryptionCurrentKey = "ENCRYPTION_CURRENT_KEY"
+)
+
+// supported database drivers.
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+const redacted = "******"
+
+// telegramMaxDownloadSize is the size in bytes of the largest file bots can download from telegram.
+const telegramMaxDownloadSize = 20 << 20
+
+// Config contains all configurations of the bot, which are loaded from defaults,
+// a YAML file, environment variables and flags, each overriding the former.
+type Config struct {
+	Telegram        TelegramConfig `yaml:"telegram"`
+	Memobird        MemobirdConfig `yaml:"memobird"`
+	DB              DBConfig       `yaml:"db"`
+	HTTP            HTTPConfig     `yaml:"http"`
+	Quota           QuotaConfig    `yaml:"quota"`
+	Features        FeaturesConfig `yaml:"features"`
+	ShutdownTimeout time.Duration  `yaml:"shutdown_timeout"`
+	// DraftIdleTimeout is how long a draft is kept without being added to, 0 means forever.
+	DraftIdleTimeout time.Duration `yaml:"draft_idle_timeout"`
+	// DocumentMaxSize is the size in bytes of the largest text document printed.
+	DocumentMaxSize int `yaml:"document_max_size"`
+	// PrintDelay is how long texts wait before being printed so that they can be edited or cancelled,
+	// 0 prints them right away.
+	PrintDelay time.Duration    `yaml:"print_delay"`
+	Encryption EncryptionConfig `yaml:"encryption"`
+}
+
+// TelegramConfig contains configurations of the telegram bot.
+type TelegramConfig struct {
+	Token         string        `yaml:"token"`
+	PollerTimeout time.Duration `yaml:"poller_timeout"`
+	AdminIDs      []int         `yaml:"admin_ids"`
+}
+
+// MemobirdConfig contains configurations of the memobird application.
+type MemobirdConfig struct {
+	AccessKey string        `yaml:"access_key"`
+	APIPrefix string        `yaml:"api_prefix"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+// DBConfig contains configurations of the database.
+type DBConfig struct {
+	Driver string `yaml:"driver"`
+	DSN    string `yaml:"dsn"`
+}
+
+// HTTPConfig contains configurations of the HTTP server.
+type HTTPConfig struct {
+	// Addr is the address to listen, the server is disabled if empty.
+	Addr string `yaml:"addr"`
+	// PublicURL is the URL the server can be reached at, used in URLs of webhooks.
+	PublicURL string `yaml:"public_url"`
+}
+
+// QuotaConfig contains the default quotas.
+type QuotaConfig struct {
+	User   LimitConfig `yaml:"user"`
+	Device LimitConfig `yaml:"device"`
+}
+
+// LimitConfig contains limits of a quota, 0 means unlimited.
+type LimitConfig struct {
+	MessagesPerHour int `yaml:"messages_per_hour"`
+	CharsPerDay     int `yaml:"chars_per_day"`
+}
+
+// EncryptionConfig contains the master keys sealing private texts, verification codes and webhook secrets
+// in the database.
+type EncryptionConfig struct {
+	// Keys are base64 encoded AES keys by ID, nothing is sealed if empty.
+	Keys map[string]string `yaml:"keys"`
+	// CurrentKey is the ID of the key sealing new data, optional if there's only one key.
+	CurrentKey string `yaml:"current_key"`
+}
+
+// Keyring returns the keyring of the keys, nil if there's none.
+func (c *EncryptionConfig) Keyring() (*envelope.Keyring, error) {
+	if len(c.Keys) == 0 {
+		return nil, nil
+	}
+	current := c.CurrentKey
+	keys := make(map[string][]byte, len(c.Keys))
+	for id, key := range c.Keys {
+		buf, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("decoding encryption key %q: %w", id, err)
+		}
+		keys[id] = buf
+		if len(c.Keys) == 1 && current == "" {
+			current = id
+		}
+	}
+	return envelope.NewKeyring(current, keys)
+}
+
+// FeaturesConfig toggles optional features.
+type FeaturesConfig struct {
+	History bool `yaml:"history"`
+	Sharing bool `yaml:"sharing"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Telegram: TelegramConfig{
+			PollerTimeout: 10 * time.Second,
+		},
+		Memobird: MemobirdConfig{
+			Timeout: 30 * time.Second,
+		},
+		DB: DBConfig{
+			Driver: DriverSQLite,
+			DSN:    "test.db",
+		},
+		Quota: QuotaConfig{
+			User:   LimitConfig{MessagesPerHour: 30, CharsPerDay: 5000},
+			Device: LimitConfig{MessagesPerHour: 60, CharsPerDay: 10000},
+		},
+		Features: FeaturesConfig{
+			History: true,
+			Sharing: true,
+		},
+		ShutdownTimeout:  20 * time.Second,
+		DraftIdleTimeout: 24 * time.Hour,
+		DocumentMaxSize:  1 << 20,
+		PrintDelay:       5 * time.Second,
+	}
+}
+
+// Validate returns an error if any configuration is invalid.
+func (c *Config) Validate() error {
+	return validate(c.checkTelegram, c.checkMemobird, c.checkDB, c.checkEncryption, c.checkOthers)
+}
+
+// validate runs checks and joins the problems found into an error.
+func validate(checks ...func() []string) error {
+	var errs []string
+	for _, check := range checks {
+		errs = append(errs, check()...)
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *Config) checkTelegram() []string {
+	var errs []string
+	if c.Telegram.Token == "" {
+		errs = append(errs, fmt.Sprintf("telegram token is required, please specify it via environment variable %s", EnvToken))
+	}
+	if c.Telegram.PollerTimeout < time.Second {
+		errs = append(errs, "telegram poller timeout must be at least 1s")
+	}
+	return errs
+}
+
+func (c *Config) checkMemobird() []string {
+	var errs []string
+	if c.Memobird.AccessKey == "" {
+		errs = append(errs, fmt.Sprintf("memobird access key is required, please specify it via environment variable %s", EnvAccessKey))
+	}
+	if c.Memobird.Timeout <= 0 {
+		errs = append(errs, "memobird timeout must be positive")
+	}
+	if c.Memobird.APIPrefix != "" {
+		if u, err := url.Parse(c.Memobird.APIPrefix); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("invalid memobird API prefix: %s", c.Memobird.APIPrefix))
+		}
+	}
+	return errs
+}
+
+func (c *Config) checkDB() []string {
+	var errs []string
+	if c.DB.Driver != DriverSQLite && c.DB.Driver != DriverPostgres {
+		errs = append(errs, fmt.Sprintf("unsupported database driver %q, expecting %s or %s", c.DB.Driver, DriverSQLite, DriverPostgres))
+	}
+	if c.DB.DSN == "" {
+		errs = append(errs, "database DSN is required")
+	}
+	return errs
+}
+
+func (c *Config) checkEncryption() []string {
+	if _, err := c.Encryption.Keyring(); err != nil {
+		return []string{fmt.Sprintf("invalid encryption keys: %s", err)}
+	}
+	return nil
+}
+
+func (c *Config) checkOthers() []string {
+	var errs []string
+	if c.HTTP.PublicURL != "" {
+		if u, err := url.Parse(c.HTTP.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("invalid public URL: %s", c.HTTP.PublicURL))
+		}
+	}
+	for _, l := range []LimitConfig{c.Quota.User, c.Quota.Device} {
+		if l.MessagesPerHour < 0 || l.CharsPerDay < 0 {
+			errs = append(errs, "quota limits must not be negative")
+			break
+		}
+	}
+	if c.ShutdownTimeout < 0 {
+		errs = append(errs, "shutdown timeout must not be negative")
+	}
+	if c.DraftIdleTimeout < 0 {
+		errs = append(errs, "draft idle timeout must not be negative")
+	}
+	if c.PrintDelay < 0 {
+		errs = append(errs, "print delay must not be negative")
+	}
+	if c.DocumentMaxSize <= 0 || c.DocumentMaxSize > telegramMaxDownloadSize {
+		errs = append(errs, fmt.Sprintf("document max size must be positive and at most %d", telegramMaxDownloadSize))
+	}
+	return errs
+}
+
+// Redacted returns a copy of the config with secrets hidden.
+func (c Config) Redacted() Config {
+	if c.Telegram.Token != "" {
+		c.Telegram.Token = redacted
+	}
+	if c.Memobird.AccessKey != "" {
+		c.Memobird.AccessKey = redacted
+	}
+	c.DB.DSN = redactDSN(c.DB.DSN)
+	if len(c.Encryption.Keys) > 0 {
+		keys := make(map[string]string, len(c.Encryption.Keys))
+		for id := range c.Encryption.Keys {
+			keys[id] = redacted
+		}
+		c.Encryption.Keys = keys
+	}
+	return c
+}
+
+var reDSNPassword = regexp.MustCompile(`(password=)[^\s]+`)
+
+// redactDSN hides the password in either a URL or a key=value DSN.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			return strings.Replace(u.String(), url.QueryEscape(redacted), redacted, 1)
+		}
+	}
+	return reDSNPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
+
+// knob is a configuration that can be set by an environment variable or a flag.
+type knob struct {
+	Flag  string
+	Env   string
+	Usage string
+	Set   func(c *Config, v string) error
+}
+
+func stringKnob(field func(c *Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func intKnob(field func(c *Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func boolKnob(field func(c *Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func durationKnob(field func(c *Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// parseKeys parses comma separated id:key pairs.
+func parseKeys(v string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, s := range strings.Split(v, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		i := strings.IndexByte(s, ':')
+		if i <= 0 {
+			return nil, errors.New("expecting comma separated id:key pairs")
+		}
+		keys[s[:i]] = s[i+1:]
+	}
+	return keys, nil
+}
+
+func parseIDs(v string) ([]int, error) {
+	var ids []int
+	for _, s := range strings.Split(v, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid telegram ID: %s", s)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+var knobs = []knob{
+	{"telegram-token", EnvToken, "token of the telegram bot",
+		stringKnob(func(c *Config) *string { return &c.Telegram.Token })},
+	{"telegram-poller-timeout", EnvPollerTimeout, "timeout of long polling telegram updates",
+		durationKnob(func(c *Config) *time.Duration { return &c.Telegram.PollerTimeout })},
+	{"admin-ids", EnvAdminIDs, "comma separated telegram IDs of administrators",
+		func(c *Config, v string) (err error) {
+			c.Telegram.AdminIDs, err = parseIDs(v)
+			return err
+		}},
+	{"memobird-ak", EnvAccessKey, "access key of the memobird application",
+		stringKnob(func(c *Config) *string { return &c.Memobird.AccessKey })},
+	{"memobird-api-prefix", EnvAPIPrefix, "alternative prefix of memobird API",
+		stringKnob(func(c *Config) *string { return &c.Memobird.APIPrefix })},
+	{"memobird-timeout", EnvMemobirdTimeout, "timeout of memobird API requests",
+		durationKnob(func(c *Config) *time.Duration { return &c.Memobird.Timeout })},
+	{"", EnvDatabaseURL, "",
+		func(c *Config, v string) error {
+			c.DB.Driver = DriverPostgres
+			c.DB.DSN = v
+			return nil
+		}},
+	{"db-driver", EnvDBDriver, "database driver, sqlite3 or postgres",
+		stringKnob(func(c *Config) *string { return &c.DB.Driver })},
+	{"db-dsn", "", "database DSN, a file path for sqlite3 or a URL for postgres",
+		stringKnob(func(c *Config) *string { return &c.DB.DSN })},
+	{"", EnvPort, "",
+		func(c *Config, v string) error {
+			c.HTTP.Addr = ":" + v
+			return nil
+		}},
+	{"http-addr", EnvHTTPAddr, "address of the HTTP server, disabled if empty",
+		stringKnob(func(c *Config) *string { return &c.HTTP.Addr })},
+	{"http-public-url", EnvPublicURL, "public URL of the HTTP server, used in URLs of webhooks",
+		stringKnob(func(c *Config) *string { return &c.HTTP.PublicURL })},
+	{"quota-user-messages-per-hour", EnvUserMessagesPerHour, "default messages per hour of a user on a device, 0 means unlimited",
+		intKnob(func(c *Config) *int { return &c.Quota.User.MessagesPerHour })},
+	{"quota-user-chars-per-day", EnvUserCharsPerDay, "default characters per day of a user on a device, 0 means unlimited",
+		intKnob(func(c *Config) *int { return &c.Quota.User.CharsPerDay })},
+	{"quota-device-messages-per-hour", EnvDeviceMessagesPerHour, "default messages per hour of a device, 0 means unlimited",
+		intKnob(func(c *Config) *int { return &c.Quota.Device.MessagesPerHour })},
+	{"quota-device-chars-per-day", EnvDeviceCharsPerDay, "default characters per day of a device, 0 means unlimited",
+		intKnob(func(c *Config) *int { return &c.Quota.Device.CharsPerDay })},
+	{"feature-history", EnvFeatureHistory, "whether printed contents are kept as history",
+		boolKnob(func(c *Config) *bool { return &c.Features.History })},
+	{"feature-sharing", EnvFeatureSharing, "whether devices can be shared",
+		boolKnob(func(c *Config) *bool { return &c.Features.Sharing })},
+	{"shutdown-timeout", EnvShutdownTimeout, "how long to wait for in-flight work when shutting down",
+		durationKnob(func(c *Config) *time.Duration { return &c.ShutdownTimeout })},
+	{"draft-idle-timeout", EnvDraftIdleTimeout, "how long a draft is kept without being added to, 0 means forever",
+		durationKnob(func(c *Config) *time.Duration { return &c.DraftIdleTimeout })},
+	{"document-max-size", EnvDocumentMaxSize, "size in bytes of the largest text document printed",
+		intKnob(func(c *Config) *int { return &c.DocumentMaxSize })},
+	{"print-delay", EnvPrintDelay, "how long texts wait before being printed so that they can be edited or cancelled, 0 prints them right away",
+		durationKnob(func(c *Config) *time.Duration { return &c.PrintDelay })},
+	{"encryption-keys", EnvEncryptionKeys, "comma separated id:key pairs of base64 encoded AES keys sealing data at rest, e.g. from openssl rand -base64 32",
+		func(c *Config, v string) (err error) {
+			c.Encryption.Keys, err = parseKeys(v)
+			return err
+		}},
+	{"encryption-current-key", EnvEncryptionCurrentKey, "ID of the encryption key sealing new data, optional if there's only one key",
+		stringKnob(func(c *Config) *string { return &c.Encryption.CurrentKey })},
+}
+
+// configFlags are the flags of configurations registered on a flag set.
+type configFlags struct {
+	fs   *flag.FlagSet
+	path *string
+}
+
+// addConfigFlags registers the flags of configurations on fs.
+func addConfigFlags(fs *flag.FlagSet, getenv func(string) string) *configFlags {
+	f := &configFlags{
+		fs:   fs,
+		path: fs.String("config", getenv(EnvConfigFile), "path of the YAML configuration file"),
+	}
+	for _, k := range knobs {
+		if k.Flag != "" {
+			fs.String(k.Flag, "", k.Usage)
+		}
+	}
+	return f
+}
+
+// load loads the config from defaults, the YAML file, environment variables and the parsed flags.
+func (f *configFlags) load(getenv func(string) string) (*Config, error) {
+	config := defaultConfig()
+	if *f.path != "" {
+		buf, err := ioutil.ReadFile(*f.path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+		if err := yaml.UnmarshalStrict(buf, config); err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", *f.path, err)
+		}
+	}
+
+	for _, k := range knobs {
+		if k.Env == "" {
+			continue
+		}
+		if v := getenv(k.Env); v != "" {
+			if err := k.Set(config, v); err != nil {
+				return nil, fmt.Errorf("invalid environment variable %s: %w", k.Env, err)
+			}
+		}
+	}
+
+	var err error
+	f.fs.Visit(func(fl *flag.Flag) {
+		for _, k := range knobs {
+			if err == nil && k.Flag == fl.Name {
+				if e := k.Set(config, fl.Value.String()); e != nil {
+					err = fmt.Errorf("invalid flag -%s: %w", k.Flag, e)
+				}
+			}
+		}
+	})
+	return config, err
+}
+
+// loadConfig loads the config from defaults, the YAML file, environment variables and flags in args,
+// no positional arguments are allowed.
+func loadConfig(name string, args []string, getenv func(string) string, output io.Writer) (*Config, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	flags := addConfigFlags(fs, getenv)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return flags.load(getenv)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// readinessCacheTTL is how long results of readiness checks are reused.
+const readinessCacheTTL = 30 * time.Second
+
+// readinessCheck checks whether a dependency is ready.
+type readinessCheck struct {
+	Name  string
+	Check func() error
+}
+
+type checkResult struct {
+	name string
+	err  error
+}
+
+// readiness runs checks of dependencies and caches the results to avoid hammering them.
+type readiness struct {
+	checks []readinessCheck
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	results   []checkResult
+}
+
+func newReadiness(checks ...readinessCheck) *readiness {
+	return &readiness{checks: checks, ttl: readinessCacheTTL}
+}
+
+// check returns the cached results, dependencies are checked again if the results are expired.
+func (r *readiness) check(now time.Time) []checkResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.results != nil && now.Sub(r.checkedAt) < r.ttl {
+		return r.results
+	}
+
+	results := make([]checkResult, len(r.checks))
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Add(1)
+		go func(i int, c readinessCheck) {
+			defer wg.Done()
+			results[i] = checkResult{name: c.Name, err: c.Check()}
+		}(i, c)
+	}
+	wg.Wait()
+	r.results = results
+	r.checkedAt = now
+	return results
+}
+
+func (r *readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	results := r.check(time.Now())
+	status := http.StatusOK
+	for _, result := range results {
+		if result.err != nil {
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(w, "%s: %s\n", result.name, result.err)
+		} else {
+			fmt.Fprintf(w, "%s: ok\n", result.name)
+		}
+	}
+}
+
+func handleHealthz(w http.ResponseWriter, req *http.Request) {
+	io.WriteString(w, "ok\n")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadiness(t *testing.T) {
+	var calls int
+	failing := true
+	r := newReadiness(
+		readinessCheck{"db", func() error { return nil }},
+		readinessCheck{"telegram", func() error {
+			calls++
+			if failing {
+				return errors.New("unreachable")
+			}
+			return nil
+		}},
+	)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "db: ok\ntelegram: unreachable\n", rec.Body.String())
+
+	// cached
+	failing = false
+	r.check(time.Now())
+	assert.Equal(t, 1, calls)
+
+	r.check(time.Now().Add(readinessCacheTTL))
+	assert.Equal(t, 2, calls)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
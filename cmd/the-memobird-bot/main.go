@@ -1,17 +1,27 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/tevino/log"
 
 	"github.com/awesome-memobird/the-memobird-bot/bot"
+	"github.com/awesome-memobird/the-memobird-bot/feed"
 	"github.com/awesome-memobird/the-memobird-bot/memobird"
+	"github.com/awesome-memobird/the-memobird-bot/metrics"
 	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/repository"
 	"github.com/awesome-memobird/the-memobird-bot/service"
 
 	"github.com/jinzhu/gorm"
@@ -19,40 +29,40 @@ import (
 	_ "github.com/jinzhu/gorm/dialects/sqlite"   // Database Driver
 )
 
-// names of environment variables.
-const (
-	// the token of telegram bot.
-	EnvToken = "TELEGRAM_TOKEN"
-	// the access key of memobird application.
-	EnvAccessKey = "MEMOBIRD_AK"
-	// the port to listen as required by Heroku.
-	EnvPort = "PORT"
-)
+// feedFetchTimeout is the timeout of fetching a feed.
+const feedFetchTimeout = 20 * time.Second
 
-func newDB() *gorm.DB {
-	var driver string
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		driver = "sqlite3"
-		dbURL = "test.db"
-	} else {
-		driver = "postgres"
-	}
-	log.Infof("Using %s: %s", driver, dbURL)
+// openDB connects to the database, the schema is migrated only by serving.
+func openDB(config *DBConfig) (*gorm.DB, error) {
+	log.Infof("Using %s: %s", config.Driver, redactDSN(config.DSN))
 
-	db, err := gorm.Open(driver, dbURL)
+	db, err := gorm.Open(config.Driver, config.DSN)
 	if err != nil {
-		log.Fatal("Failed to connect to database")
+		return nil, fmt.Errorf("connecting to database: %w", err)
 	}
+	return db, nil
+}
 
-	// Migrate the schema
-	db.AutoMigrate(&model.User{})
-	db.AutoMigrate(&model.Device{})
-	db.AutoMigrate(&model.Content{})
-
+// newDB connects to the database and migrates the schema.
+func newDB(config *DBConfig) *gorm.DB {
+	db, err := openDB(config)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := migrate(db); err != nil {
+		log.Fatal("Failed to migrate schema:", err)
+	}
 	return db
 }
 
+func newBirdApp(config *MemobirdConfig) *memobird.App {
+	return memobird.NewApp(&memobird.AppConfig{
+		AccessKey:           config.AccessKey,
+		Timeout:             config.Timeout,
+		CustomizedAPIPrefix: config.APIPrefix,
+	})
+}
+
 func newBot(config *bot.Config) *bot.Bot {
 	b, err := bot.New(config)
 	if err != nil {
@@ -61,55 +71,172 @@ func newBot(config *bot.Config) *bot.Bot {
 	return b
 }
 
-func listenHTTPIfRequired() {
-	port := os.Getenv(EnvPort)
-
-	if port != "" {
-		pingHandler := func(w http.ResponseWriter, req *http.Request) {
-			io.WriteString(w, "I'm alive!\n")
+// observeAPILatency records latencies of memobird API requests to registry.
+func observeAPILatency(app *memobird.App, registry *metrics.Registry) {
+	latency := registry.NewHistogramVec("memobird_api_request_duration_seconds",
+		"Latencies of memobird API requests by function and outcome.", metrics.DefaultBuckets, "fn", "outcome")
+	app.Observer = func(fn string, duration time.Duration, err error) {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
 		}
-		http.HandleFunc("/ping", pingHandler)
-		go http.ListenAndServe(":"+port, nil)
+		latency.Observe(duration.Seconds(), fn, outcome)
 	}
 }
 
-func main() {
-	// Check mandantory environment variables.
-	accessKey := os.Getenv(EnvAccessKey)
-	if accessKey == "" {
-		log.Fatalf("Please specify access key for memobird application via environment variable %s", EnvAccessKey)
-	}
-	token := os.Getenv(EnvToken)
-	if token == "" {
-		log.Fatalf("Please specify token for telegram bot via environment variable %s", EnvToken)
+// listenHTTPIfRequired starts the HTTP server if addr is not empty, nil is returned otherwise.
+func listenHTTPIfRequired(addr string, b *bot.Bot, ready http.Handler, registry *metrics.Registry) *http.Server {
+	if addr == "" {
+		return nil
 	}
 
-	listenHTTPIfRequired()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "I'm alive!\n")
+	})
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.Handle("/readyz", ready)
+	mux.Handle("/metrics", registry)
+	mux.HandleFunc(bot.WebhookPath, b.ServeWebhook)
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Error listening HTTP:", err)
+		}
+	}()
+	return srv
+}
 
+func serve(config *Config) {
 	// initialization
 	rand.Seed(time.Now().UnixNano())
-	db := newDB()
-	defer db.Close()
+	db := newDB(&config.DB)
 
-	birdApp := memobird.NewApp(&memobird.AppConfig{
-		AccessKey: accessKey,
-		Timeout:   30 * time.Second,
-	})
+	registry := metrics.NewRegistry()
+	birdApp := newBirdApp(&config.Memobird)
+	observeAPILatency(birdApp, registry)
+
+	keyring, err := config.Encryption.Keyring()
+	if err != nil {
+		log.Fatal("Invalid encryption keys:", err)
+	}
+	if keyring != nil {
+		log.Infof("Sealing private texts and secrets by encryption key %s", keyring.Current())
+	}
 
 	// services
-	deviceService := &service.Device{DB: db}
-	userService := &service.User{DB: db}
+	deviceService := &service.Device{Repo: &repository.GormDevices{DB: db}, Keyring: keyring}
+	userService := &service.User{Repo: &repository.GormUsers{DB: db}}
 	birdService := &service.Bird{BirdApp: birdApp}
+	contentService := &service.Content{Repo: &repository.GormContents{DB: db}, Keyring: keyring}
+	shareService := &service.Share{DB: db}
+	usageService := &service.Usage{DB: db}
+	printAttemptService := &service.PrintAttempt{DB: db}
+	webhookService := &service.Webhook{DB: db, Keyring: keyring}
+	channelService := &service.Channel{DB: db, Keyring: keyring}
+	feedService := &service.Feed{DB: db}
+	todoService := &service.Todo{DB: db, Keyring: keyring}
+	draftService := &service.Draft{DB: db, Keyring: keyring}
+	accountService := &service.Account{DB: db, Keyring: keyring}
 
 	b := newBot(&bot.Config{
-		Token:         token,
-		PollerTimeout: time.Second * 10,
-
-		UserService:   userService,
-		DeviceService: deviceService,
-		BirdService:   birdService,
+		Token:         config.Telegram.Token,
+		PollerTimeout: config.Telegram.PollerTimeout,
+		AdminIDs:      config.Telegram.AdminIDs,
+		UserQuota: model.Quota{
+			MessagesPerHour: config.Quota.User.MessagesPerHour,
+			CharsPerDay:     config.Quota.User.CharsPerDay,
+		},
+		DeviceQuota: model.Quota{
+			MessagesPerHour: config.Quota.Device.MessagesPerHour,
+			CharsPerDay:     config.Quota.Device.CharsPerDay,
+		},
+		Features: bot.Features{
+			History: config.Features.History,
+			Sharing: config.Features.Sharing,
+		},
+		Metrics:          registry,
+		WebhookBaseURL:   config.HTTP.PublicURL,
+		DraftIdleTimeout: config.DraftIdleTimeout,
+		DocumentMaxSize:  config.DocumentMaxSize,
+		PrintDelay:       config.PrintDelay,
+
+		UserService:         userService,
+		DeviceService:       deviceService,
+		BirdService:         birdService,
+		ContentService:      contentService,
+		ShareService:        shareService,
+		UsageService:        usageService,
+		PrintAttemptService: printAttemptService,
+		WebhookService:      webhookService,
+		ChannelService:      channelService,
+		FeedService:         feedService,
+		FeedFetcher:         feed.NewFetcher(feedFetchTimeout),
+		TodoService:         todoService,
+		DraftService:        draftService,
+		AccountService:      accountService,
 	})
 
+	srv := listenHTTPIfRequired(config.HTTP.Addr, b, newReadiness(
+		readinessCheck{"database", func() error { return db.DB().Ping() }},
+		readinessCheck{"telegram", b.Ping},
+		readinessCheck{"memobird", func() error {
+			// any reply means the API is reachable
+			_, err := birdApp.PrintStatus(0)
+			return err
+		}},
+	), registry)
+
 	// Starting the bot
-	b.Start()
+	go b.Start()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	log.Infof("Received %s, shutting down", sig)
+	signal.Stop(signals)
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	// stop receiving updates, then wait for the in-flight prints
+	b.Stop()
+	abandoned := b.Drain(ctx)
+
+	if srv != nil {
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Warn("Error shutting down HTTP server:", err)
+			srv.Close()
+		}
+	}
+	if err := db.Close(); err != nil {
+		log.Warn("Error closing database:", err)
+	}
+
+	for _, job := range abandoned {
+		log.Warn("Abandoned:", job)
+	}
+	log.Infof("Shut down, %d jobs abandoned", len(abandoned))
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		// serve by default
+		args = append([]string{"serve"}, args...)
+	}
+	cmd, rest := findCommand(args)
+	if cmd == nil {
+		usage()
+		os.Exit(2)
+	}
+
+	err := cmd.Run(filepath.Base(os.Args[0])+" "+cmd.Name, rest)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tevino/log"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+
+	"github.com/jinzhu/gorm"
+)
+
+// models are what the schema is migrated for.
+var models = []interface{}{
+	&model.User{},
+	&model.Device{},
+	&model.Content{},
+	&model.Share{},
+	&model.Usage{},
+	&model.PrintAttempt{},
+	&model.Webhook{},
+	&model.ChannelSubscription{},
+	&model.ChannelPost{},
+	&model.Feed{},
+	&model.FeedItem{},
+	&model.FeedSubscription{},
+	&model.TodoList{},
+	&model.TodoItem{},
+	&model.Draft{},
+	&model.DraftPart{},
+}
+
+// userColumns are the columns referring to users by table.
+var userColumns = []struct{ table, column string }{
+	{"devices", "user_id"},
+	{"contents", "user_id"},
+	{"shares", "user_id"},
+	{"usages", "user_id"},
+	{"print_attempts", "user_id"},
+	{"webhooks", "user_id"},
+	{"channel_subscriptions", "requester_id"},
+	{"feed_subscriptions", "user_id"},
+	{"todo_lists", "user_id"},
+	{"drafts", "user_id"},
+}
+
+// migrate migrates the schema of db.
+func migrate(db *gorm.DB) error {
+	if err := dedupeUsers(db); err != nil {
+		return fmt.Errorf("deduplicating users: %w", err)
+	}
+	for _, m := range models {
+		if err := db.AutoMigrate(m).Error; err != nil {
+			return fmt.Errorf("migrating %T: %w", m, err)
+		}
+	}
+	return nil
+}
+
+// dedupeUsers merges the users of the same telegram ID into the first one, which is what they were found
+// as, so that telegram IDs can be indexed uniquely. Users used to be created twice by concurrent updates.
+func dedupeUsers(db *gorm.DB) error {
+	if !db.HasTable("users") {
+		return nil
+	}
+	var dups []struct {
+		TelegramID int64
+		KeepID     uint
+	}
+	err := db.Raw("select telegram_id, min(id) as keep_id from users group by telegram_id having count(*) > 1").
+		Scan(&dups).Error
+	if err != nil {
+		return err
+	}
+
+	for _, dup := range dups {
+		tx := db.Begin()
+		others := tx.Table("users").Select("id").Where("telegram_id = ? and id <> ?", dup.TelegramID, dup.KeepID).QueryExpr()
+		for _, c := range userColumns {
+			if !tx.HasTable(c.table) {
+				continue
+			}
+			err := tx.Table(c.table).Where(c.column+" in (?)", others).UpdateColumn(c.column, dup.KeepID).Error
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("merging %s of telegram user[%d]: %w", c.table, dup.TelegramID, err)
+			}
+		}
+		r := tx.Exec("delete from users where telegram_id = ? and id <> ?", dup.TelegramID, dup.KeepID)
+		if r.Error != nil {
+			tx.Rollback()
+			return fmt.Errorf("deleting duplicates of telegram user[%d]: %w", dup.TelegramID, r.Error)
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+		log.Infof("merged %d duplicates of telegram user[%d] into user[%d]", r.RowsAffected, dup.TelegramID, dup.KeepID)
+	}
+	return nil
+}
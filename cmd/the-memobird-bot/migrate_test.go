@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestMigrateDuplicateUsers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := gorm.Open(DriverSQLite, filepath.Join(dir, "test.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	db.LogMode(false)
+
+	// users were created twice by concurrent updates before telegram IDs were indexed
+	type user struct {
+		gorm.Model
+		TelegramID int64
+	}
+	assert.NoError(t, db.AutoMigrate(&user{}, &model.Device{}).Error)
+	for _, id := range []int64{1, 1, 2, 1} {
+		assert.NoError(t, db.Create(&user{TelegramID: id}).Error)
+	}
+	assert.NoError(t, db.Create(&model.Device{UserID: 4, MemobirdID: "bird"}).Error)
+
+	assert.NoError(t, migrate(db))
+	var users []model.User
+	assert.NoError(t, db.Order("id").Find(&users).Error)
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, uint(1), users[0].ID)
+		assert.Equal(t, uint(3), users[1].ID)
+	}
+	var device model.Device
+	assert.NoError(t, db.First(&device, "memobird_id = ?", "bird").Error)
+	assert.Equal(t, uint(1), device.UserID)
+	assert.Error(t, db.Create(&model.User{TelegramID: 1}).Error, "telegram IDs are unique")
+
+	// nothing to do the next time
+	assert.NoError(t, migrate(db))
+}
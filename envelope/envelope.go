@@ -0,0 +1,137 @@
+// Package envelope encrypts data at rest with envelope encryption: each payload is encrypted by a random
+// data key with AES-GCM, which is in turn encrypted by a master key identified by an ID.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// version is the first byte of sealed payloads, the layout is
+// version | nonce of data key | encrypted data key | nonce of payload | encrypted payload.
+const version = 1
+
+// dataKeySize is the size in bytes of data keys, which are AES-256 keys.
+const dataKeySize = 32
+
+var errMalformed = errors.New("malformed sealed payload")
+
+// Keyring holds the master keys by ID, payloads are sealed with the current one.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring returns a keyring of the AES keys by ID, which must be 16, 24 or 32 bytes long. Payloads
+// are sealed by the key of current, the others are kept to open payloads sealed before rotation.
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current key %q not found", current)
+	}
+	k := &Keyring{current: current, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" {
+			return nil, errors.New("empty key ID")
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		k.keys[id] = aead
+	}
+	return k, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Current returns the ID of the key payloads are sealed with.
+func (k *Keyring) Current() string {
+	return k.current
+}
+
+// IDs returns the IDs of all keys in order.
+func (k *Keyring) IDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Seal encrypts plaintext with a new data key sealed by the current key, whose ID is returned.
+func (k *Keyring) Seal(plaintext []byte) (string, []byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", nil, err
+	}
+	master := k.keys[k.current]
+	sealed := []byte{version}
+	sealed, err := seal(sealed, master, dataKey, []byte(k.current))
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", nil, err
+	}
+	sealed, err = seal(sealed, data, plaintext, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return k.current, sealed, nil
+}
+
+// seal appends a random nonce and plaintext encrypted by aead to dst.
+func seal(dst []byte, aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	dst = append(dst, nonce...)
+	return aead.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts the payload sealed by the key of keyID.
+func (k *Keyring) Open(keyID string, sealed []byte) ([]byte, error) {
+	master, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found", keyID)
+	}
+	if len(sealed) == 0 || sealed[0] != version {
+		return nil, errMalformed
+	}
+	sealed = sealed[1:]
+
+	n := master.NonceSize() + dataKeySize + master.Overhead()
+	if len(sealed) < n {
+		return nil, errMalformed
+	}
+	dataKey, err := master.Open(nil, sealed[:master.NonceSize()], sealed[master.NonceSize():n], []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("opening data key: %w", err)
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[n:]
+	if len(sealed) < data.NonceSize()+data.Overhead() {
+		return nil, errMalformed
+	}
+	plaintext, err := data.Open(nil, sealed[:data.NonceSize()], sealed[data.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("opening payload: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,57 @@
+package envelope
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyring(t *testing.T) {
+	old := bytes.Repeat([]byte{1}, 32)
+	k, err := NewKeyring("2020", map[string][]byte{"2020": old})
+	if !assert.NoError(t, err) {
+		return
+	}
+	id, sealed, err := k.Seal([]byte("secret note"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2020", id)
+		assert.NotContains(t, string(sealed), "secret note")
+	}
+	_, again, _ := k.Seal([]byte("secret note"))
+	assert.NotEqual(t, sealed, again)
+
+	rotated, err := NewKeyring("2021", map[string][]byte{"2020": old, "2021": bytes.Repeat([]byte{2}, 16)})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"2020", "2021"}, rotated.IDs())
+	plaintext, err := rotated.Open(id, sealed)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "secret note", string(plaintext))
+	}
+	id, sealed, _ = rotated.Seal(nil)
+	assert.Equal(t, "2021", id)
+	plaintext, err = rotated.Open(id, sealed)
+	assert.NoError(t, err)
+	assert.Empty(t, plaintext)
+
+	_, err = k.Open("2021", sealed)
+	assert.Error(t, err)
+	// the key ID is authenticated along with the data key
+	_, err = rotated.Open("2020", sealed)
+	assert.Error(t, err)
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = rotated.Open("2021", tampered)
+	assert.Error(t, err)
+	_, err = rotated.Open("2021", sealed[:10])
+	assert.Error(t, err)
+}
+
+func TestNewKeyring(t *testing.T) {
+	_, err := NewKeyring("a", map[string][]byte{"b": make([]byte, 32)})
+	assert.Error(t, err)
+	_, err = NewKeyring("a", map[string][]byte{"a": make([]byte, 10)})
+	assert.Error(t, err)
+}
@@ -0,0 +1,237 @@
+// Package feed fetches and parses RSS 2.0 and Atom feeds.
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// MaxSummaryLen is the maximum number of characters in the summary of an item.
+const MaxSummaryLen = 280
+
+// ErrUnknownFormat is returned if the document is neither RSS nor Atom.
+var ErrUnknownFormat = errors.New("neither RSS nor Atom")
+
+// Feed is a parsed feed.
+type Feed struct {
+	Title string
+	Items []Item
+}
+
+// Item is an entry of a feed.
+type Item struct {
+	// GUID identifies the item in its feed.
+	GUID    string
+	Title   string
+	Summary string
+	Link    string
+	// Published is zero if unknown.
+	Published time.Time
+}
+
+type rssDoc struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			GUID        string `xml:"guid"`
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",innerxml"`
+}
+
+// String returns the text with markup removed.
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return stripHTML(t.Body)
+	}
+	// text and html are escaped in the document
+	var s string
+	if err := xml.Unmarshal([]byte("<t>"+t.Body+"</t>"), &s); err != nil {
+		return stripHTML(t.Body)
+	}
+	if t.Type == "html" {
+		return stripHTML(s)
+	}
+	return strings.TrimSpace(s)
+}
+
+type atomDoc struct {
+	Title   atomText `xml:"title"`
+	Entries []struct {
+		ID      string   `xml:"id"`
+		Title   atomText `xml:"title"`
+		Summary atomText `xml:"summary"`
+		Content atomText `xml:"content"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// Parse parses an RSS 2.0 or Atom document.
+func Parse(body []byte) (*Feed, error) {
+	root, err := rootElement(body)
+	if err != nil {
+		return nil, err
+	}
+	switch root {
+	case "rss":
+		var doc rssDoc
+		if err := decode(body, &doc); err != nil {
+			return nil, err
+		}
+		return doc.feed(), nil
+	case "feed":
+		var doc atomDoc
+		if err := decode(body, &doc); err != nil {
+			return nil, err
+		}
+		return doc.feed(), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+func newDecoder(body []byte) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	d.Strict = false
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "gbk", "gb2312", "gb18030":
+			return simplifiedchinese.GB18030.NewDecoder().Reader(input), nil
+		}
+		return nil, fmt.Errorf("unsupported charset: %s", charset)
+	}
+	return d
+}
+
+func decode(body []byte, v interface{}) error {
+	if err := newDecoder(body).Decode(v); err != nil {
+		return fmt.Errorf("decoding feed: %w", err)
+	}
+	return nil
+}
+
+// rootElement returns the local name of the root element.
+func rootElement(body []byte) (string, error) {
+	d := newDecoder(body)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return "", ErrUnknownFormat
+		}
+		if err != nil {
+			return "", fmt.Errorf("decoding feed: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func (doc *rssDoc) feed() *Feed {
+	f := &Feed{Title: strings.TrimSpace(doc.Channel.Title)}
+	for _, it := range doc.Channel.Items {
+		item := Item{
+			GUID:      strings.TrimSpace(it.GUID),
+			Title:     stripHTML(it.Title),
+			Summary:   truncate(stripHTML(it.Description)),
+			Link:      strings.TrimSpace(it.Link),
+			Published: parseTime(it.PubDate),
+		}
+		f.Items = append(f.Items, item.withGUID())
+	}
+	return f
+}
+
+func (doc *atomDoc) feed() *Feed {
+	f := &Feed{Title: doc.Title.String()}
+	for _, e := range doc.Entries {
+		item := Item{
+			GUID:      strings.TrimSpace(e.ID),
+			Title:     e.Title.String(),
+			Summary:   e.Summary.String(),
+			Published: parseTime(e.Published),
+		}
+		if item.Summary == "" {
+			item.Summary = e.Content.String()
+		}
+		item.Summary = truncate(item.Summary)
+		if item.Published.IsZero() {
+			item.Published = parseTime(e.Updated)
+		}
+		for _, link := range e.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				item.Link = strings.TrimSpace(link.Href)
+				break
+			}
+		}
+		f.Items = append(f.Items, item.withGUID())
+	}
+	return f
+}
+
+// withGUID falls back to the link or the title for items without GUIDs.
+func (it Item) withGUID() Item {
+	if it.GUID == "" {
+		it.GUID = it.Link
+	}
+	if it.GUID == "" {
+		it.GUID = it.Title
+	}
+	return it
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var reTag = regexp.MustCompile(`(?s)<[^>]*>`)
+
+// stripHTML returns the text of s without tags on one line.
+func stripHTML(s string) string {
+	s = reTag.ReplaceAllString(s, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= MaxSummaryLen {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:MaxSummaryLen])) + "…"
+}
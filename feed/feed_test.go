@@ -0,0 +1,179 @@
+package feed
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const rssDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+  <title>Comics</title>
+  <atom:link href="https://example.com/rss" rel="self"/>
+  <item>
+    <title>Strip &amp; more</title>
+    <link>https://example.com/2</link>
+    <guid isPermaLink="false">strip-2</guid>
+    <description><![CDATA[<p>A <b>new</b> strip&nbsp;is out.</p>]]></description>
+    <pubDate>Tue, 01 Oct 2019 08:30:00 +0000</pubDate>
+  </item>
+  <item>
+    <title>Strip 1</title>
+    <link>https://example.com/1</link>
+  </item>
+</channel>
+</rss>`
+
+const atomDocument = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="text">Blog</title>
+  <entry>
+    <id>tag:example.com,2019:1</id>
+    <title type="html">Hello &lt;em&gt;world&lt;/em&gt;</title>
+    <link rel="self" href="https://example.com/self"/>
+    <link href="https://example.com/hello"/>
+    <updated>2019-10-01T08:30:00Z</updated>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>First post</p></div></content>
+  </entry>
+</feed>`
+
+func TestParseRSS(t *testing.T) {
+	f, err := Parse([]byte(rssDocument))
+	assert.NoError(t, err)
+	assert.Equal(t, "Comics", f.Title)
+	assert.Equal(t, []Item{
+		{
+			GUID:      "strip-2",
+			Title:     "Strip & more",
+			Summary:   "A new strip is out.",
+			Link:      "https://example.com/2",
+			Published: time.Date(2019, 10, 1, 8, 30, 0, 0, time.UTC),
+		},
+		{GUID: "https://example.com/1", Title: "Strip 1", Link: "https://example.com/1"},
+	}, normalize(f.Items))
+}
+
+func TestParseAtom(t *testing.T) {
+	f, err := Parse([]byte(atomDocument))
+	assert.NoError(t, err)
+	assert.Equal(t, "Blog", f.Title)
+	assert.Equal(t, []Item{{
+		GUID:      "tag:example.com,2019:1",
+		Title:     "Hello world",
+		Summary:   "First post",
+		Link:      "https://example.com/hello",
+		Published: time.Date(2019, 10, 1, 8, 30, 0, 0, time.UTC),
+	}}, normalize(f.Items))
+}
+
+func TestParseUnknown(t *testing.T) {
+	_, err := Parse([]byte(`<html><body>not a feed</body></html>`))
+	assert.Equal(t, ErrUnknownFormat, err)
+	_, err = Parse([]byte(`{}`))
+	assert.Error(t, err)
+}
+
+// normalize converts times to UTC so that they can be compared.
+func normalize(items []Item) []Item {
+	for i := range items {
+		if !items[i].Published.IsZero() {
+			items[i].Published = items[i].Published.UTC()
+		}
+	}
+	return items
+}
+
+func TestFetchConditional(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Tue, 01 Oct 2019 08:30:00 GMT")
+		w.Write([]byte(rssDocument))
+	}))
+	defer srv.Close()
+
+	f := newFetcher(time.Second, allowAll)
+	result, err := f.Fetch(srv.URL, Validators{})
+	assert.NoError(t, err)
+	assert.False(t, result.NotModified)
+	assert.Len(t, result.Feed.Items, 2)
+	assert.Equal(t, Validators{ETag: `"v1"`, LastModified: "Tue, 01 Oct 2019 08:30:00 GMT"}, result.Validators)
+
+	result, err = f.Fetch(srv.URL, result.Validators)
+	assert.NoError(t, err)
+	assert.True(t, result.NotModified)
+	assert.Nil(t, result.Feed)
+	assert.Equal(t, `"v1"`, result.Validators.ETag)
+	assert.Equal(t, 2, requests)
+}
+
+func TestFetchError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := newFetcher(time.Second, allowAll).Fetch(srv.URL, Validators{})
+	assert.Error(t, err)
+}
+
+func allowAll(net.IP) bool { return true }
+
+func TestFetchNotPublic(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Write([]byte(rssDocument))
+	}))
+	defer srv.Close()
+
+	_, err := NewFetcher(time.Second).Fetch(srv.URL, Validators{})
+	assert.True(t, errors.Is(err, ErrNotPublic))
+	assert.Zero(t, requests)
+
+	// redirects are checked as well
+	redirect := httptest.NewServer(http.RedirectHandler("http://169.254.169.254/latest/meta-data/", http.StatusFound))
+	defer redirect.Close()
+	_, err = newFetcher(time.Second, func(ip net.IP) bool { return ip.IsLoopback() }).Fetch(redirect.URL, Validators{})
+	assert.True(t, errors.Is(err, ErrNotPublic))
+}
+
+func TestFetchRedirects(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, srv.URL+req.URL.Path+"x", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	_, err := newFetcher(time.Second, allowAll).Fetch(srv.URL+"/", Validators{})
+	assert.Error(t, err)
+}
+
+func TestIsPublic(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"1.1.1.1":          true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.20.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+		"::ffff:127.0.0.1": false,
+	} {
+		assert.Equal(t, public, IsPublic(net.ParseIP(ip)), ip)
+	}
+}
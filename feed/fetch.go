@@ -0,0 +1,179 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// MaxBodySize is the maximum size of a feed document.
+const MaxBodySize = 4 << 20
+
+// maxRedirects is the number of redirects followed when fetching a feed.
+const maxRedirects = 5
+
+// ErrNotPublic is returned when a feed resolves to an address which is not on the public internet.
+var ErrNotPublic = errors.New("address is not public")
+
+// nonPublicNets are the networks feeds are never fetched from, e.g. loopback, private and cloud metadata.
+var nonPublicNets = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// IsPublic returns true if ip is an address on the public internet.
+func IsPublic(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validators are what conditional GETs are made with, from the last response of a feed.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Result is the outcome of fetching a feed.
+type Result struct {
+	// Feed is nil if NotModified.
+	Feed        *Feed
+	NotModified bool
+	Validators  Validators
+}
+
+// Fetcher fetches feeds with conditional GET.
+type Fetcher struct {
+	Client    *http.Client
+	UserAgent string
+}
+
+// NewFetcher creates a fetcher of which requests time out after timeout, feeds are only fetched from
+// public addresses, including the ones redirected to.
+func NewFetcher(timeout time.Duration) *Fetcher {
+	return newFetcher(timeout, IsPublic)
+}
+
+// newFetcher creates a fetcher which only connects to the addresses allowed after resolving.
+func newFetcher(timeout time.Duration, allowed func(net.IP) bool) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowed(ip) {
+				return fmt.Errorf("connecting to %s: %w", host, ErrNotPublic)
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &Fetcher{
+		Client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("redirected to unsupported scheme %q", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+		UserAgent: "the-memobird-bot",
+	}
+}
+
+// Fetch fetches and parses the feed at url, it's not modified if the validators still match.
+func (f *Fetcher) Fetch(url string, v Validators) (*Result, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.UserAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.1")
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &Result{NotModified: true, Validators: v}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading feed: %w", err)
+	}
+	if len(body) > MaxBodySize {
+		return nil, fmt.Errorf("feed larger than %d bytes", MaxBodySize)
+	}
+	parsed, err := Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Feed: parsed,
+		Validators: Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}, nil
+}
@@ -4,8 +4,11 @@ go 1.13
 
 require (
 	github.com/jinzhu/gorm v1.9.10
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/stretchr/testify v1.4.0
 	github.com/tevino/log v0.0.0-20181029032942-846f2b36e71a
+	golang.org/x/image v0.0.0-20190802002840-cff245a6509b
 	golang.org/x/text v0.3.2
 	gopkg.in/tucnak/telebot.v2 v2.0.0-20190415090633-8c1c512262f2
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,165 @@
+// Package i18n provides message catalogs with placeholders and plural forms.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lang is a language tag of catalogs.
+type Lang string
+
+// Supported languages.
+const (
+	English           Lang = "en"
+	ChineseSimplified Lang = "zh-CN"
+)
+
+// Default is the language used when no catalog matches.
+const Default = English
+
+// Params contains values of placeholders like {name} in a message.
+type Params map[string]interface{}
+
+// Message is a translation, One is used for a count of 1 in languages with plural forms.
+type Message struct {
+	One   string
+	Other string
+}
+
+// Catalog maps message keys to translations of a language.
+type Catalog map[string]Message
+
+// Bundle holds catalogs of all languages.
+type Bundle map[Lang]Catalog
+
+// Match returns the language of bundle for given IETF language code like "zh-hans" or "en-US".
+func (b Bundle) Match(code string) (Lang, bool) {
+	code = strings.ToLower(strings.Replace(code, "_", "-", -1))
+	if code == "" {
+		return Default, false
+	}
+	for lang := range b {
+		if strings.ToLower(string(lang)) == code {
+			return lang, true
+		}
+	}
+	if isTraditionalChinese(code) {
+		// the catalogs of Simplified Chinese don't serve the readers of Traditional
+		return Default, false
+	}
+	base := strings.SplitN(code, "-", 2)[0]
+	for lang := range b {
+		if strings.SplitN(strings.ToLower(string(lang)), "-", 2)[0] == base {
+			return lang, true
+		}
+	}
+	return Default, false
+}
+
+// isTraditionalChinese returns true if code is a lower-cased language code of Chinese written in Traditional
+// characters, i.e. "zh-hant" or the ones of Taiwan, Hong Kong and Macau without the script "hans".
+func isTraditionalChinese(code string) bool {
+	subtags := strings.Split(code, "-")
+	if subtags[0] != "zh" {
+		return false
+	}
+	traditional := false
+	for _, subtag := range subtags[1:] {
+		switch subtag {
+		case "hans":
+			return false
+		case "hant", "tw", "hk", "mo":
+			traditional = true
+		}
+	}
+	return traditional
+}
+
+// T returns the message of key in lang with placeholders replaced by params.
+func (b Bundle) T(lang Lang, key string, params ...Params) string {
+	return b.render(b.lookup(lang, key).Other, params)
+}
+
+// N is like T but chooses the plural form by count, which is available as {count}.
+func (b Bundle) N(lang Lang, key string, count int, params ...Params) string {
+	msg := b.lookup(lang, key)
+	text := msg.Other
+	if count == 1 && msg.One != "" && hasPluralForms(lang) {
+		text = msg.One
+	}
+	return b.render(text, append(params, Params{"count": count}))
+}
+
+func (b Bundle) lookup(lang Lang, key string) Message {
+	if msg, ok := b[lang][key]; ok {
+		return msg
+	}
+	if msg, ok := b[Default][key]; ok {
+		return msg
+	}
+	return Message{Other: key}
+}
+
+// hasPluralForms returns false for languages that do not inflect nouns by count.
+func hasPluralForms(lang Lang) bool {
+	switch strings.SplitN(string(lang), "-", 2)[0] {
+	case "zh", "ja", "ko":
+		return false
+	}
+	return true
+}
+
+func (b Bundle) render(text string, params []Params) string {
+	if len(params) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(text, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		sb.WriteString(text[:start])
+		name := text[start+1 : end]
+		if v, ok := lookupParam(params, name); ok {
+			sb.WriteString(fmt.Sprint(v))
+		} else {
+			sb.WriteString(text[start : end+1])
+		}
+		text = text[end+1:]
+	}
+	sb.WriteString(text)
+	return sb.String()
+}
+
+func lookupParam(params []Params, name string) (interface{}, bool) {
+	for _, p := range params {
+		if v, ok := p[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Placeholders returns names of the placeholders in text.
+func Placeholders(text string) []string {
+	var names []string
+	for {
+		start := strings.IndexByte(text, '{')
+		if start < 0 {
+			return names
+		}
+		end := strings.IndexByte(text[start:], '}')
+		if end < 0 {
+			return names
+		}
+		names = append(names, text[start+1:start+end])
+		text = text[start+end+1:]
+	}
+}
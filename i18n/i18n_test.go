@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testBundle = Bundle{
+	English: {
+		"hello": {Other: "Hello {name}!"},
+		"days":  {One: "{count} day", Other: "{count} days"},
+		"only":  {Other: "English only"},
+	},
+	ChineseSimplified: {
+		"hello": {Other: "你好 {name}！"},
+		"days":  {Other: "{count} 天"},
+	},
+}
+
+func TestBundleMatch(t *testing.T) {
+	for code, expect := range map[string]Lang{
+		"en":         English,
+		"en-US":      English,
+		"zh-hans":    ChineseSimplified,
+		"zh_CN":      ChineseSimplified,
+		"ZH-cn":      ChineseSimplified,
+		"zh":         ChineseSimplified,
+		"zh-Hans-HK": ChineseSimplified,
+	} {
+		lang, ok := testBundle.Match(code)
+		assert.True(t, ok, code)
+		assert.Equal(t, expect, lang, code)
+	}
+
+	for _, code := range []string{"", "fr", "de-DE", "zh-TW", "zh_HK", "zh-Hant", "zh-Hant-CN"} {
+		lang, ok := testBundle.Match(code)
+		assert.False(t, ok, code)
+		assert.Equal(t, Default, lang, code)
+	}
+}
+
+func TestBundleT(t *testing.T) {
+	assert.Equal(t, "Hello Bob!", testBundle.T(English, "hello", Params{"name": "Bob"}))
+	assert.Equal(t, "你好 Bob！", testBundle.T(ChineseSimplified, "hello", Params{"name": "Bob"}))
+	assert.Equal(t, "Hello {name}!", testBundle.T(English, "hello"))
+	assert.Equal(t, "English only", testBundle.T(ChineseSimplified, "only"))
+	assert.Equal(t, "missing", testBundle.T(English, "missing"))
+}
+
+func TestBundleN(t *testing.T) {
+	assert.Equal(t, "1 day", testBundle.N(English, "days", 1))
+	assert.Equal(t, "2 days", testBundle.N(English, "days", 2))
+	assert.Equal(t, "0 days", testBundle.N(English, "days", 0))
+	assert.Equal(t, "1 天", testBundle.N(ChineseSimplified, "days", 1))
+}
+
+func TestPlaceholders(t *testing.T) {
+	assert.Equal(t, []string{"a", "count"}, Placeholders("{a} and {count} {unclosed"))
+	assert.Empty(t, Placeholders("none"))
+}
@@ -2,13 +2,13 @@ package memobird
 
 import (
 	"bytes"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,11 +22,13 @@ const (
 	APIPrefix        = "https://open.memobird.cn/home"
 	apiFnPrintPaper  = "printpaper"
 	apiFnSetUserBind = "setuserbind"
+	apiFnPrintStatus = "getprintstatus"
 )
 
 var fnMethods = map[string]string{
 	apiFnPrintPaper:  http.MethodPost,
 	apiFnSetUserBind: http.MethodPost,
+	apiFnPrintStatus: http.MethodPost,
 }
 
 // App is an memobird application.
@@ -42,6 +44,9 @@ type AppConfig struct {
 
 	// CustomizedAPIPrefix is an optional configuration where an alternative APIPrefix should be used.
 	CustomizedAPIPrefix string
+
+	// Observer is optionally called after every API request with the function name, duration and error.
+	Observer func(fn string, duration time.Duration, err error)
 }
 
 // APIPrefix returns the APIPrefix.
@@ -98,7 +103,14 @@ func (a *App) do(fn string, formMap map[string]string) (*http.Response, error) {
 	return a.cli.Do(req)
 }
 
-func (a *App) doWithReply(fn string, formMap map[string]string, reply interface{}) error {
+func (a *App) doWithReply(fn string, formMap map[string]string, reply interface{}) (err error) {
+	if a.Observer != nil {
+		start := time.Now()
+		defer func() {
+			a.Observer(fn, time.Since(start), err)
+		}()
+	}
+
 	resp, err := a.do(fn, formMap)
 	if err != nil {
 		return fmt.Errorf("sending request: %w", err)
@@ -161,25 +173,21 @@ func (a *App) BindDevice(deviceID string) (*BindResult, error) {
 
 // PrintText prints txt to membird of given deviceID.
 func (a *App) PrintText(txt string, deviceID string) (*PrintResult, error) {
-	gbkTxt, err := UTF8ToGBK([]byte(txt))
-	if err != nil {
-		return nil, fmt.Errorf("encoding text: %w", err)
-	}
-	resp, err := a.do(apiFnPrintPaper, map[string]string{
-		"printcontent": "T:" + base64.StdEncoding.EncodeToString(gbkTxt),
-		"memobirdID":   deviceID,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("sending request: %w", err)
+	doc := new(Document)
+	if err := doc.AddText(txt); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return a.Print(doc, deviceID)
+}
 
+// Print prints doc to memobird of given deviceID.
+func (a *App) Print(doc *Document, deviceID string) (*PrintResult, error) {
 	reply := new(printContentReply)
-	buf, err := ioutil.ReadAll(resp.Body)
-	log.Debugf("API response: %s", buf)
-	err = json.Unmarshal(buf, reply)
-	if err != nil {
-		return nil, fmt.Errorf("marshalling response JSON: %w", err)
+	if err := a.doWithReply(apiFnPrintPaper, map[string]string{
+		"printcontent": doc.String(),
+		"memobirdID":   deviceID,
+	}, reply); err != nil {
+		return nil, err
 	}
 
 	result := &PrintResult{
@@ -193,3 +201,30 @@ func (a *App) PrintText(txt string, deviceID string) (*PrintResult, error) {
 	}
 	return result, nil
 }
+
+type printStatusReply struct {
+	ReturnCode     int    `json:"showapi_res_code"` // 1: success, others: failed
+	ReturnErr      string `json:"showapi_res_error"`
+	PrintFlag      int    `json:"printflag"` // 1: printed, others: not printed
+	PrintContentID int64  `json:"printcontentid"`
+}
+
+// PrintStatus queries whether the content of contentID is printed.
+func (a *App) PrintStatus(contentID int64) (*PrintResult, error) {
+	reply := new(printStatusReply)
+	if err := a.doWithReply(apiFnPrintStatus, map[string]string{
+		"printcontentid": strconv.FormatInt(contentID, 10),
+	}, reply); err != nil {
+		return nil, err
+	}
+
+	result := &PrintResult{
+		IsPrinted: reply.PrintFlag == 1,
+		ContentID: reply.PrintContentID,
+	}
+	result.IsSuccess = reply.ReturnCode == 1
+	if reply.ReturnErr != "" {
+		result.Err = errors.New(reply.ReturnErr)
+	}
+	return result, nil
+}
@@ -0,0 +1,46 @@
+package memobird
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// PaperWidth is the printable width of memobird in dots.
+const PaperWidth = 384
+
+// Document is a print content made of text and pictures, printed in order.
+type Document struct {
+	parts []string
+}
+
+// AddText appends txt to the document.
+func (d *Document) AddText(txt string) error {
+	gbkTxt, err := UTF8ToGBK([]byte(txt))
+	if err != nil {
+		return fmt.Errorf("encoding text: %w", err)
+	}
+	d.parts = append(d.parts, "T:"+base64.StdEncoding.EncodeToString(gbkTxt))
+	return nil
+}
+
+// AddImage appends img to the document, it's scaled down to PaperWidth if wider and dithered to black and white.
+func (d *Document) AddImage(img image.Image) error {
+	bmp, err := EncodeBMP(Dither(ScaleToWidth(img, PaperWidth)))
+	if err != nil {
+		return fmt.Errorf("encoding image: %w", err)
+	}
+	d.parts = append(d.parts, "P:"+base64.StdEncoding.EncodeToString(bmp))
+	return nil
+}
+
+// IsEmpty returns true if nothing is added to the document.
+func (d *Document) IsEmpty() bool {
+	return len(d.parts) == 0
+}
+
+// String returns the document encoded as printcontent of memobird API.
+func (d *Document) String() string {
+	return strings.Join(d.parts, "|")
+}
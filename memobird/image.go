@@ -0,0 +1,137 @@
+package memobird
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// ScaleToWidth scales img down to width with nearest neighbour sampling, img is returned as-is if narrower.
+func ScaleToWidth(img image.Image, width int) image.Image {
+	if img.Bounds().Dx() <= width {
+		return img
+	}
+	return scale(img, width)
+}
+
+// Flatten scales img to width and draws it centered on a white image as wide as the paper, transparent
+// pixels become white.
+func Flatten(img image.Image, width int) image.Image {
+	scaled := scale(img, width)
+	r := scaled.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, PaperWidth, r.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	x := (PaperWidth - r.Dx()) / 2
+	draw.Draw(flat, image.Rect(x, 0, x+r.Dx(), r.Dy()), scaled, r.Min, draw.Over)
+	return flat
+}
+
+// scale scales img to width with nearest neighbour sampling, keeping the aspect ratio.
+func scale(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY := bounds.Min.Y + y*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			srcX := bounds.Min.X + x*bounds.Dx()/width
+			scaled.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return scaled
+}
+
+// luminance returns the gray level of c in [0, 255], transparent pixels are treated as white.
+func luminance(c color.Color) int {
+	r, g, b, a := c.RGBA()
+	// composite on white background
+	white := 0xffff - a
+	r, g, b = r+white, g+white, b+white
+	return int((299*r + 587*g + 114*b) / 1000 >> 8)
+}
+
+// Dither converts img to black and white with Floyd-Steinberg dithering.
+func Dither(img image.Image) *image.Paletted {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	levels := make([]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			levels[y*w+x] = luminance(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	out := image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.Black, color.White})
+	spread := func(x, y, e, weight int) {
+		if x >= 0 && x < w && y < h {
+			levels[y*w+x] += e * weight / 16
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			old := levels[y*w+x]
+			v := 0
+			if old >= 128 {
+				v = 255
+				out.SetColorIndex(x, y, 1)
+			}
+			e := old - v
+			spread(x+1, y, e, 7)
+			spread(x-1, y+1, e, 3)
+			spread(x, y+1, e, 5)
+			spread(x+1, y+1, e, 1)
+		}
+	}
+	return out
+}
+
+// EncodeBMP encodes img as a 1-bit monochrome BMP, pixels of color index 0 are black.
+func EncodeBMP(img *image.Paletted) ([]byte, error) {
+	const (
+		fileHeaderSize = 14
+		infoHeaderSize = 40
+		paletteSize    = 2 * 4
+		offset         = fileHeaderSize + infoHeaderSize + paletteSize
+	)
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	rowSize := (w + 31) / 32 * 4
+	imageSize := rowSize * h
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("BM")
+	header := []interface{}{
+		// file header
+		uint32(offset + imageSize), uint16(0), uint16(0), uint32(offset),
+		// info header
+		uint32(infoHeaderSize), int32(w), int32(h), uint16(1), uint16(1),
+		uint32(0), uint32(imageSize), int32(2835), int32(2835), uint32(2), uint32(2),
+		// palette: black, white
+		[4]byte{0, 0, 0, 0}, [4]byte{0xff, 0xff, 0xff, 0},
+	}
+	for _, v := range header {
+		if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
+			return nil, err
+		}
+	}
+
+	// rows are stored bottom-up
+	row := make([]byte, rowSize)
+	for y := h - 1; y >= 0; y-- {
+		for i := range row {
+			row[i] = 0
+		}
+		for x := 0; x < w; x++ {
+			if img.ColorIndexAt(bounds.Min.X+x, bounds.Min.Y+y) != 0 {
+				row[x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+		buf.Write(row)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,83 @@
+package memobird
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleToWidth(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 800, 400))
+	assert.Equal(t, image.Rect(0, 0, PaperWidth, 192), ScaleToWidth(img, PaperWidth).Bounds())
+
+	small := image.NewGray(image.Rect(0, 0, 100, 50))
+	assert.Equal(t, small, ScaleToWidth(small, PaperWidth))
+}
+
+func TestFlatten(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.Black)
+	img.Set(1, 0, color.NRGBA{R: 0xff, A: 0x80})
+
+	flat := Flatten(img, 4)
+	assert.Equal(t, image.Rect(0, 0, PaperWidth, 4), flat.Bounds())
+	x := PaperWidth/2 - 2
+	assert.Equal(t, color.RGBA{A: 0xff}, flat.At(x, 0), "scaled up")
+	assert.Equal(t, color.RGBA{A: 0xff}, flat.At(x+1, 1))
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0x7f, B: 0x7f, A: 0xff}, flat.At(x+2, 0), "blended onto white")
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, flat.At(x, 2), "transparent")
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, flat.At(0, 0), "margin")
+}
+
+func TestDither(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.White)
+	img.Set(1, 0, color.Black)
+	// transparent pixels are printed as white
+	img.Set(2, 0, color.NRGBA{})
+
+	out := Dither(img)
+	assert.Equal(t, uint8(1), out.ColorIndexAt(0, 0))
+	assert.Equal(t, uint8(0), out.ColorIndexAt(1, 0))
+	assert.Equal(t, uint8(1), out.ColorIndexAt(2, 0))
+}
+
+func TestEncodeBMP(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 10, 2), color.Palette{color.Black, color.White})
+	img.SetColorIndex(0, 0, 1)
+	img.SetColorIndex(9, 1, 1)
+
+	buf, err := EncodeBMP(img)
+	assert.NoError(t, err)
+	assert.Equal(t, "BM", string(buf[:2]))
+	assert.Equal(t, uint32(len(buf)), binary.LittleEndian.Uint32(buf[2:]))
+	assert.Equal(t, int32(10), int32(binary.LittleEndian.Uint32(buf[18:])))
+	assert.Equal(t, uint16(1), binary.LittleEndian.Uint16(buf[28:]))
+
+	offset := binary.LittleEndian.Uint32(buf[10:])
+	// rows are padded to 4 bytes and stored bottom-up
+	assert.Equal(t, []byte{0x00, 0x40, 0, 0}, buf[offset:offset+4])
+	assert.Equal(t, []byte{0x80, 0x00, 0, 0}, buf[offset+4:offset+8])
+}
+
+func TestQRCode(t *testing.T) {
+	img, err := QRCode("https://example.com/")
+	assert.NoError(t, err)
+	bounds := img.Bounds()
+	assert.Equal(t, PaperWidth, bounds.Dx())
+	// centered with white margins
+	assert.Equal(t, color.Gray{Y: 0xff}, img.At(0, bounds.Dy()/2))
+	assert.Equal(t, color.Gray{Y: 0xff}, img.At(PaperWidth-1, bounds.Dy()/2))
+
+	long := make([]byte, 2000)
+	for i := range long {
+		long[i] = 'a'
+	}
+	img, err = QRCode(string(long))
+	assert.NoError(t, err)
+	assert.Equal(t, PaperWidth, img.Bounds().Dx())
+	assert.LessOrEqual(t, img.Bounds().Dy(), PaperWidth)
+}
@@ -0,0 +1,110 @@
+// Package memobirdtest provides a fake memobird application for tests, what's printed is rendered to PNG.
+package memobirdtest
+
+import (
+	"sync"
+
+	"github.com/awesome-memobird/the-memobird-bot/memobird"
+)
+
+// Print is a document printed to the fake.
+type Print struct {
+	DeviceID  string
+	ContentID int64
+	// PNG is the strip rendered by memobird.RenderPNG.
+	PNG []byte
+}
+
+// App is a fake memobird application which prints to memory, it is safe for concurrent use.
+type App struct {
+	// Err is returned by every request if not nil, as if memobird were unreachable, it's set before
+	// the app is used.
+	Err error
+
+	mu     sync.Mutex
+	bound  map[string]bool
+	prints []Print
+}
+
+// NewApp creates a fake application.
+func NewApp() *App {
+	return &App{bound: make(map[string]bool)}
+}
+
+// BindDevice binds the device.
+func (a *App) BindDevice(deviceID string) (*memobird.BindResult, error) {
+	if a.Err != nil {
+		return nil, a.Err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bound[deviceID] = true
+	result := &memobird.BindResult{UserID: uint64(len(a.bound))}
+	result.IsSuccess = true
+	return result, nil
+}
+
+// PrintText prints txt to the device.
+func (a *App) PrintText(txt string, deviceID string) (*memobird.PrintResult, error) {
+	doc := new(memobird.Document)
+	if err := doc.AddText(txt); err != nil {
+		return nil, err
+	}
+	return a.Print(doc, deviceID)
+}
+
+// Print renders doc to PNG and records it as printed to the device.
+func (a *App) Print(doc *memobird.Document, deviceID string) (*memobird.PrintResult, error) {
+	if a.Err != nil {
+		return nil, a.Err
+	}
+	png, err := memobird.RenderPNG(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p := Print{
+		DeviceID:  deviceID,
+		ContentID: int64(len(a.prints) + 1),
+		PNG:       png,
+	}
+	a.prints = append(a.prints, p)
+	result := &memobird.PrintResult{
+		IsPrinted: true,
+		ContentID: p.ContentID,
+		DeviceID:  deviceID,
+	}
+	result.IsSuccess = true
+	return result, nil
+}
+
+// PrintStatus returns whether the content of contentID is printed.
+func (a *App) PrintStatus(contentID int64) (*memobird.PrintResult, error) {
+	if a.Err != nil {
+		return nil, a.Err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result := &memobird.PrintResult{
+		IsPrinted: contentID > 0 && contentID <= int64(len(a.prints)),
+		ContentID: contentID,
+	}
+	result.IsSuccess = true
+	return result, nil
+}
+
+// IsBound returns whether the device is bound.
+func (a *App) IsBound(deviceID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.bound[deviceID]
+}
+
+// Prints returns what's printed in order.
+func (a *App) Prints() []Print {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Print(nil), a.prints...)
+}
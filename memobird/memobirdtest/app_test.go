@@ -0,0 +1,37 @@
+package memobirdtest
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp(t *testing.T) {
+	app := NewApp()
+	bind, err := app.BindDevice("bird")
+	assert.NoError(t, err)
+	assert.True(t, bind.IsSuccess)
+	assert.True(t, app.IsBound("bird"))
+
+	result, err := app.PrintText("Hello", "bird")
+	assert.NoError(t, err)
+	assert.True(t, result.IsSuccess)
+	status, err := app.PrintStatus(result.ContentID)
+	assert.NoError(t, err)
+	assert.True(t, status.IsPrinted)
+
+	prints := app.Prints()
+	if assert.Len(t, prints, 1) {
+		assert.Equal(t, "bird", prints[0].DeviceID)
+		img, err := png.Decode(bytes.NewReader(prints[0].PNG))
+		assert.NoError(t, err)
+		assert.Equal(t, 384, img.Bounds().Dx())
+	}
+
+	app.Err = errors.New("unreachable")
+	_, err = app.PrintText("Hello", "bird")
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package memobird
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// qrModuleSize is the size of a QR code module in dots, large enough to be scanned from paper.
+const qrModuleSize = 4
+
+// QRCode returns a QR code of content centered on an image as wide as the paper.
+func QRCode(content string) (image.Image, error) {
+	qr, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("encoding QR code: %w", err)
+	}
+	code := qr.Image(-qrModuleSize)
+	if code.Bounds().Dx() > PaperWidth {
+		// dense codes take one dot per module or more
+		code = qr.Image(PaperWidth)
+	}
+
+	size := code.Bounds().Dx()
+	img := image.NewGray(image.Rect(0, 0, PaperWidth, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect((PaperWidth-size)/2, 0, (PaperWidth+size)/2, size), code, image.Point{}, draw.Src)
+	return img, nil
+}
+
+// AddQRCode appends a QR code of content to the document.
+func (d *Document) AddQRCode(content string) error {
+	img, err := QRCode(content)
+	if err != nil {
+		return err
+	}
+	return d.AddImage(img)
+}
@@ -0,0 +1,235 @@
+package memobird
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/width"
+)
+
+// dimensions of text printed by memobird in dots, wide characters take two cells.
+const (
+	CellWidth  = 12
+	LineHeight = 24
+)
+
+// LineCells is the number of cells in a line of the paper.
+const LineCells = PaperWidth / CellWidth
+
+// DotsPerMM is the resolution of memobird, the paper is 48mm wide.
+const DotsPerMM = PaperWidth / 48
+
+// Render renders doc as the strip printed by memobird, PaperWidth wide with black and white pixels.
+// Characters are drawn with a built-in ASCII bitmap font, wide ones such as Chinese are drawn as boxes.
+func Render(doc *Document) (*image.Paletted, error) {
+	var blocks []*image.Paletted
+	height := 0
+	for i, part := range doc.parts {
+		block, err := renderPart(part)
+		if err != nil {
+			return nil, fmt.Errorf("rendering part %d: %w", i, err)
+		}
+		blocks = append(blocks, block)
+		height += block.Bounds().Dy()
+	}
+
+	strip := newStrip(PaperWidth, height)
+	y := 0
+	for _, block := range blocks {
+		r := block.Bounds()
+		draw.Draw(strip, image.Rect(0, y, r.Dx(), y+r.Dy()), block, r.Min, draw.Src)
+		y += r.Dy()
+	}
+	return strip, nil
+}
+
+// RenderPNG renders doc as a PNG image.
+func RenderPNG(doc *Document) ([]byte, error) {
+	strip, err := Render(doc)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, strip); err != nil {
+		return nil, fmt.Errorf("encoding PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newStrip returns a white image of the size.
+func newStrip(w, h int) *image.Paletted {
+	strip := image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.Black, color.White})
+	for i := range strip.Pix {
+		strip.Pix[i] = 1
+	}
+	return strip
+}
+
+func renderPart(part string) (*image.Paletted, error) {
+	if len(part) < 2 || part[1] != ':' {
+		return nil, fmt.Errorf("unknown part: %.10q", part)
+	}
+	data, err := base64.StdEncoding.DecodeString(part[2:])
+	if err != nil {
+		return nil, fmt.Errorf("decoding base64: %w", err)
+	}
+	switch part[0] {
+	case 'T':
+		txt, err := simplifiedchinese.GBK.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("decoding GBK: %w", err)
+		}
+		return renderText(string(txt)), nil
+	case 'P':
+		return DecodeBMP(data)
+	}
+	return nil, fmt.Errorf("unknown part type: %c", part[0])
+}
+
+// runeCells returns the number of cells r takes.
+func runeCells(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// TextCells returns the number of cells txt takes in a line.
+func TextCells(txt string) int {
+	n := 0
+	for _, r := range txt {
+		n += runeCells(r)
+	}
+	return n
+}
+
+// HasWideRunes returns whether txt has runes taking two cells, e.g. CJK characters, which the font of
+// Render can't draw but as boxes.
+func HasWideRunes(txt string) bool {
+	for _, r := range txt {
+		if runeCells(r) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// WrapText breaks txt into lines of at most cells cells.
+func WrapText(txt string, cells int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimRight(txt, "\n"), "\n") {
+		var line []rune
+		used := 0
+		for _, r := range paragraph {
+			n := runeCells(r)
+			if used+n > cells {
+				lines = append(lines, string(line))
+				line, used = nil, 0
+			}
+			line = append(line, r)
+			used += n
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+func renderText(txt string) *image.Paletted {
+	lines := WrapText(txt, LineCells)
+	strip := newStrip(PaperWidth, len(lines)*LineHeight)
+	for i, line := range lines {
+		x := 0
+		for _, r := range line {
+			w := runeCells(r) * CellWidth
+			drawGlyph(strip, image.Rect(x, i*LineHeight, x+w, (i+1)*LineHeight), r)
+			x += w
+		}
+	}
+	return strip
+}
+
+// drawGlyph draws r scaled to fill cell, or a box if the font doesn't have it.
+func drawGlyph(strip *image.Paletted, cell image.Rectangle, r rune) {
+	if r == ' ' || r == '　' {
+		return
+	}
+	face := basicfont.Face7x13
+	// runes out of the font are replaced by U+FFFD
+	_, mask, maskp, _, ok := face.Glyph(fixed.Point26_6{}, r)
+	if !ok || cell.Dx() > CellWidth {
+		// a box with margins
+		box := cell.Inset(cell.Dx() / 6)
+		for x := box.Min.X; x < box.Max.X; x++ {
+			strip.SetColorIndex(x, box.Min.Y, 0)
+			strip.SetColorIndex(x, box.Max.Y-1, 0)
+		}
+		for y := box.Min.Y; y < box.Max.Y; y++ {
+			strip.SetColorIndex(box.Min.X, y, 0)
+			strip.SetColorIndex(box.Max.X-1, y, 0)
+		}
+		return
+	}
+	for y := 0; y < cell.Dy(); y++ {
+		srcY := maskp.Y + y*face.Height/cell.Dy()
+		for x := 0; x < cell.Dx(); x++ {
+			srcX := maskp.X + x*face.Advance/cell.Dx()
+			if _, _, _, a := mask.At(srcX, srcY).RGBA(); a >= 0x8000 {
+				strip.SetColorIndex(cell.Min.X+x, cell.Min.Y+y, 0)
+			}
+		}
+	}
+}
+
+// DecodeBMP decodes a 1-bit monochrome BMP as encoded by EncodeBMP.
+func DecodeBMP(data []byte) (*image.Paletted, error) {
+	var header struct {
+		Magic       [2]byte
+		FileSize    uint32
+		_           [2]uint16
+		Offset      uint32
+		InfoSize    uint32
+		Width       int32
+		Height      int32
+		Planes      uint16
+		BitCount    uint16
+		Compression uint32
+	}
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("reading BMP header: %w", err)
+	}
+	if header.Magic != [2]byte{'B', 'M'} || header.BitCount != 1 || header.Compression != 0 {
+		return nil, errors.New("not a 1-bit BMP")
+	}
+	w, h := int(header.Width), int(header.Height)
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("invalid BMP size: %dx%d", w, h)
+	}
+	rowSize := (w + 31) / 32 * 4
+	if int(header.Offset)+rowSize*h > len(data) {
+		return nil, errors.New("truncated BMP")
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.Black, color.White})
+	for y := 0; y < h; y++ {
+		// rows are stored bottom-up
+		row := data[int(header.Offset)+(h-1-y)*rowSize:]
+		for x := 0; x < w; x++ {
+			if row[x/8]&(0x80>>uint(x%8)) != 0 {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img, nil
+}
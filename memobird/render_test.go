@@ -0,0 +1,77 @@
+package memobird
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestDecodeBMP(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 10, 2), color.Palette{color.Black, color.White})
+	img.SetColorIndex(0, 0, 1)
+	img.SetColorIndex(9, 1, 1)
+	buf, err := EncodeBMP(img)
+	assert.NoError(t, err)
+
+	decoded, err := DecodeBMP(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, img.Pix, decoded.Pix)
+
+	_, err = DecodeBMP(buf[:len(buf)-1])
+	assert.Error(t, err)
+	_, err = DecodeBMP([]byte("GIF89a"))
+	assert.Error(t, err)
+}
+
+func TestWrapText(t *testing.T) {
+	assert.Equal(t, []string{"abc", "de", "", "f"}, WrapText("abcde\n\nf\n", 3))
+	// wide characters take two cells
+	assert.Equal(t, []string{"a你", "好"}, WrapText("a你好", 3))
+}
+
+func TestTextCells(t *testing.T) {
+	assert.Equal(t, 0, TextCells(""))
+	assert.Equal(t, 7, TextCells("abc你好"))
+}
+
+func TestHasWideRunes(t *testing.T) {
+	assert.False(t, HasWideRunes("hello, café"))
+	assert.True(t, HasWideRunes("hello 你好"))
+	assert.True(t, HasWideRunes("ＡＢＣ"), "fullwidth")
+}
+
+func TestRender(t *testing.T) {
+	doc := new(Document)
+	assert.NoError(t, doc.AddText("Hello, Memobird!\n你好"))
+	square := image.NewGray(image.Rect(0, 0, 48, 48))
+	assert.NoError(t, doc.AddImage(square))
+
+	strip, err := Render(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, PaperWidth, 2*LineHeight+48), strip.Bounds())
+	// the square is black on the left
+	assert.Equal(t, uint8(0), strip.ColorIndexAt(0, 2*LineHeight))
+	assert.Equal(t, uint8(1), strip.ColorIndexAt(48, 2*LineHeight))
+
+	buf, err := RenderPNG(doc)
+	assert.NoError(t, err)
+	golden := filepath.Join("testdata", "render.golden.png")
+	if *update {
+		assert.NoError(t, ioutil.WriteFile(golden, buf, 0644))
+	}
+	want, err := ioutil.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(want, buf), "rendered differently from %s, run with -update if intended", golden)
+
+	_, err = png.Decode(bytes.NewReader(buf))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	updates := r.NewCounterVec("updates_total", "Updates handled.", "command")
+	updates.Inc("/start")
+	updates.Add(2, "/send")
+	updates.Inc(`say "hi"`)
+	r.NewGaugeFunc("queue_depth", "Jobs in queue.", func() float64 { return 3 })
+	latency := r.NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "fn")
+	latency.Observe(0.05, "print")
+	latency.Observe(0.1, "print")
+	latency.Observe(5, "print")
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Write(&buf))
+	assert.Equal(t, `# HELP updates_total Updates handled.
+# TYPE updates_total counter
+updates_total{command="/send"} 2
+updates_total{command="/start"} 1
+updates_total{command="say \"hi\""} 1
+# HELP queue_depth Jobs in queue.
+# TYPE queue_depth gauge
+queue_depth 3
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{fn="print",le="0.1"} 2
+latency_seconds_bucket{fn="print",le="1"} 2
+latency_seconds_bucket{fn="print",le="+Inf"} 3
+latency_seconds_sum{fn="print"} 5.15
+latency_seconds_count{fn="print"} 3
+`, buf.String())
+	assert.Equal(t, float64(2), updates.Value("/send"))
+}
+
+func TestLabelValuesMismatch(t *testing.T) {
+	c := NewRegistry().NewCounterVec("c", "", "a", "b")
+	assert.Panics(t, func() { c.Inc("only one") })
+	assert.Panics(t, func() { c.Add(-1, "a", "b") })
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Archive contains everything a user created, including rows soft-deleted. Rows of others on the devices
+// of the user are not included.
+type Archive struct {
+	ExportedAt time.Time
+	User       User
+	Devices    []Device
+	// Shares are the shares of devices with the user.
+	Shares   []Share
+	Contents []Content
+	Usages   []Usage
+	// PrintAttempts are kept for stats even if the contents are deleted.
+	PrintAttempts []PrintAttempt
+	Webhooks      []Webhook
+	// ChannelSubscriptions are the ones requested by the user, along with their posts.
+	ChannelSubscriptions []ChannelSubscription
+	ChannelPosts         []ChannelPost
+	FeedSubscriptions    []FeedSubscription
+	TodoLists            []TodoList
+	TodoItems            []TodoItem
+	Drafts               []Draft
+	DraftParts           []DraftPart
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ChannelSubscription links a telegram channel to a device, posts of the channel are printed on the device
+// once the owner of the device approves.
+type ChannelSubscription struct {
+	gorm.Model
+	ChannelID    int64 `gorm:"index"`
+	ChannelTitle string
+	DeviceID     uint `gorm:"index"`
+	// RequesterID is the user who linked the channel, an admin of it.
+	RequesterID uint
+	ApprovedAt  *time.Time
+	// Hashtags are separated by spaces, posts with any of them are printed, all posts are if empty.
+	Hashtags      string
+	IncludePhotos bool
+	// DigestHours is the interval of printing posts in batches, posts are printed as they come if 0.
+	DigestHours  int
+	LastDigestAt *time.Time
+}
+
+// IsApproved returns true if the owner of the device approved the subscription.
+func (s *ChannelSubscription) IsApproved() bool {
+	return s.ApprovedAt != nil
+}
+
+// MatchesHashtags returns true if text contains any of the hashtags, case-insensitively.
+func (s *ChannelSubscription) MatchesHashtags(text string) bool {
+	wanted := strings.Fields(strings.ToLower(s.Hashtags))
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isHashtagSeparator) {
+		for _, tag := range wanted {
+			if word == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isHashtagSeparator(r rune) bool {
+	return r != '#' && r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// IsDigestDue returns true if the posts of a digest subscription should be printed at now.
+func (s *ChannelSubscription) IsDigestDue(now time.Time) bool {
+	if s.DigestHours <= 0 || !s.IsApproved() {
+		return false
+	}
+	last := *s.ApprovedAt
+	if s.LastDigestAt != nil {
+		last = *s.LastDigestAt
+	}
+	return !now.Before(last.Add(time.Duration(s.DigestHours) * time.Hour))
+}
+
+// ChannelPost is a post waiting for the digest of a channel subscription.
+type ChannelPost struct {
+	gorm.Model
+	SubscriptionID uint `gorm:"index"`
+	MessageID      int
+	Text           string `gorm:"type:text"`
+	PostedAt       time.Time
+	// PhotoFileID is the telegram file of the photo of the post, empty if none.
+	PhotoFileID string
+	Edited      bool
+
+	// KeyID is the master key SealedText is sealed by, empty if Text is stored in plaintext.
+	KeyID      string
+	SealedText []byte
+}
+
+// HasPhoto returns true if the post has a photo.
+func (p ChannelPost) HasPhoto() bool {
+	return p.PhotoFileID != ""
+}
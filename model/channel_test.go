@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelSubscriptionMatchesHashtags(t *testing.T) {
+	s := &ChannelSubscription{}
+	assert.True(t, s.MatchesHashtags("anything"))
+
+	s.Hashtags = "#family #生日"
+	assert.True(t, s.MatchesHashtags("Dinner on Sunday #Family"))
+	assert.True(t, s.MatchesHashtags("奶奶 #生日，快乐"))
+	assert.False(t, s.MatchesHashtags("#familyday is not #family_day"))
+	assert.False(t, s.MatchesHashtags("family"))
+}
+
+func TestChannelSubscriptionIsDigestDue(t *testing.T) {
+	approvedAt := time.Date(2019, 10, 1, 8, 0, 0, 0, time.UTC)
+	s := &ChannelSubscription{DigestHours: 24}
+	assert.False(t, s.IsDigestDue(approvedAt.Add(48*time.Hour)), "not approved")
+
+	s.ApprovedAt = &approvedAt
+	assert.False(t, s.IsDigestDue(approvedAt.Add(23*time.Hour)))
+	assert.True(t, s.IsDigestDue(approvedAt.Add(24*time.Hour)))
+
+	last := approvedAt.Add(30 * time.Hour)
+	s.LastDigestAt = &last
+	assert.False(t, s.IsDigestDue(approvedAt.Add(48*time.Hour)))
+	assert.True(t, s.IsDigestDue(approvedAt.Add(54*time.Hour)))
+
+	s.DigestHours = 0
+	assert.False(t, s.IsDigestDue(approvedAt.Add(100*time.Hour)))
+}
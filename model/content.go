@@ -1,10 +1,49 @@
 package model
 
-import "github.com/jinzhu/gorm"
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
 
 // Content stores the contents sent to print.
 type Content struct {
 	gorm.Model
-	ContentID int64
-	IsPrinted bool
+	UserID      uint
+	DeviceID    uint
+	Text        string
+	ImageFileID string
+	ContentID   int64
+	IsPrinted   bool
+	PrintedAt   *time.Time
+	// Error describes why the content failed to print, empty if it didn't.
+	Error string
+
+	// KeyID is the master key SealedText is sealed by, empty if Text is stored in plaintext.
+	KeyID      string
+	SealedText []byte
+}
+
+// HasImage returns true if the content refers to an image.
+func (c Content) HasImage() bool {
+	return c.ImageFileID != ""
+}
+
+// DailyPrintStat contains the count of print attempts in a day.
+type DailyPrintStat struct {
+	Day    time.Time
+	Total  int
+	Failed int
+}
+
+// PrintAttempt records a content sent to print apart from the history, which users can delete from.
+type PrintAttempt struct {
+	gorm.Model
+	UserID   uint `gorm:"index"`
+	DeviceID uint
+	// Error describes why the content failed to print, empty if it didn't.
+	Error string
 }
+
+// PrintAttemptWindow is how long print attempts are kept for stats.
+const PrintAttemptWindow = 30 * 24 * time.Hour
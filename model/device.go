@@ -13,11 +13,27 @@ type Device struct {
 	MemobirdID       string
 	UserID           uint
 	VerificationCode int64
+	Quota            Quota `gorm:"embedded;embedded_prefix:quota_"`
+	// Nickname is the name of device chosen by the owner.
+	Nickname string
+	// Timezone is the IANA name of the time zone where the device is, empty means the one of memobird.
+	Timezone string
+	// PrintTemplate wraps what is printed to the device, either a preset name or a text/template.
+	PrintTemplate string `gorm:"type:text"`
+
+	// KeyID is the master key SealedVerificationCode is sealed by, empty if VerificationCode is stored
+	// in plaintext. VerificationCode is stored as DeviceUnverified while sealed.
+	KeyID                  string
+	SealedVerificationCode []byte
 }
 
 // DeviceVerified indicates the device was verified.
 const DeviceVerified int64 = -1
 
+// DeviceUnverified is stored in place of the verification code of a device waiting to be verified if the
+// code is sealed.
+const DeviceUnverified int64 = 0
+
 func randomIntFixedLength(len int) int64 {
 	if len == 0 {
 		return -1
@@ -34,6 +50,14 @@ func (d *Device) GenerateVerificationCode() *Device {
 	return d
 }
 
+// Name returns the nickname of device, or the memobird ID if not named.
+func (d Device) Name() string {
+	if d.Nickname != "" {
+		return d.Nickname
+	}
+	return d.MemobirdID
+}
+
 // IsVerified returns true if the device was verified.
 func (d Device) IsVerified() bool {
 	return d.VerificationCode == DeviceVerified && d.UserID > 0
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Draft collects what a user sends over several messages to be printed at once, a user has at most one.
+type Draft struct {
+	gorm.Model
+	UserID uint `gorm:"unique_index"`
+}
+
+// DraftPart is a text or a photo in a draft.
+type DraftPart struct {
+	gorm.Model
+	DraftID uint `gorm:"index"`
+	// Text is the text, or the caption of the photo.
+	Text string `gorm:"type:text"`
+	// ImageFileID is the telegram file of the photo, empty for text.
+	ImageFileID string
+
+	// KeyID is the master key SealedText is sealed by, empty if Text is stored in plaintext.
+	KeyID      string
+	SealedText []byte
+}
+
+// HasImage returns true if the part is a photo.
+func (p DraftPart) HasImage() bool {
+	return p.ImageFileID != ""
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Feed stores an RSS or Atom feed polled for its subscriptions.
+type Feed struct {
+	gorm.Model
+	URL   string `gorm:"unique_index"`
+	Title string
+	// ETag and LastModified are from the last response, for conditional GET.
+	ETag         string
+	LastModified string
+	CheckedAt    *time.Time
+	// Error describes why the last check failed, empty if it didn't.
+	Error string
+}
+
+// Name returns the title of the feed, or its URL if untitled.
+func (f *Feed) Name() string {
+	if f.Title != "" {
+		return f.Title
+	}
+	return f.URL
+}
+
+// FeedItem is an item seen in a feed, items are never printed twice to a subscription.
+type FeedItem struct {
+	gorm.Model
+	FeedID      uint   `gorm:"unique_index:idx_feed_item_guid"`
+	GUID        string `gorm:"unique_index:idx_feed_item_guid"`
+	Title       string
+	Summary     string `gorm:"type:text"`
+	Link        string
+	PublishedAt *time.Time
+}
+
+// FeedSubscription prints new items of a feed on the device of a user.
+type FeedSubscription struct {
+	gorm.Model
+	FeedID uint `gorm:"index"`
+	UserID uint `gorm:"index"`
+	// LastItemID is the last item printed, or seen when subscribed.
+	LastItemID uint
+	// DigestTime is the time of day as "15:04" in the time zone of the device to print new items in a digest,
+	// they are printed as they come if empty.
+	DigestTime   string
+	LastDigestAt *time.Time
+}
+
+// DigestTimeLayout is the layout of FeedSubscription.DigestTime.
+const DigestTimeLayout = "15:04"
+
+// IsDigestDue returns true if the daily digest at loc should be printed at now.
+func (s *FeedSubscription) IsDigestDue(now time.Time, loc *time.Location) bool {
+	return isDailyDue(s.DigestTime, s.CreatedAt, s.LastDigestAt, now, loc)
+}
+
+// isDailyDue returns true if what happens daily at the time of day as DigestTimeLayout in loc is due at now,
+// it last happened at last, or never since created if nil.
+func isDailyDue(timeOfDay string, created time.Time, last *time.Time, now time.Time, loc *time.Location) bool {
+	at, err := time.Parse(DigestTimeLayout, timeOfDay)
+	if err != nil {
+		return false
+	}
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+	if now.Before(today) {
+		return false
+	}
+	if last == nil {
+		last = &created
+	}
+	return last.Before(today)
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedSubscriptionIsDigestDue(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	created := time.Date(2019, 10, 1, 6, 0, 0, 0, loc)
+	s := &FeedSubscription{}
+	s.CreatedAt = created
+	assert.False(t, s.IsDigestDue(created.Add(48*time.Hour), loc), "no digest")
+
+	s.DigestTime = "08:00"
+	assert.False(t, s.IsDigestDue(created.Add(time.Hour), loc))
+	assert.True(t, s.IsDigestDue(created.Add(2*time.Hour), loc))
+	// the time of day is in loc
+	assert.False(t, s.IsDigestDue(time.Date(2019, 10, 1, 7, 59, 0, 0, loc).UTC(), loc))
+
+	last := time.Date(2019, 10, 1, 8, 1, 0, 0, loc)
+	s.LastDigestAt = &last
+	assert.False(t, s.IsDigestDue(time.Date(2019, 10, 1, 23, 0, 0, 0, loc), loc))
+	assert.False(t, s.IsDigestDue(time.Date(2019, 10, 2, 7, 0, 0, 0, loc), loc))
+	assert.True(t, s.IsDigestDue(time.Date(2019, 10, 2, 8, 0, 0, 0, loc), loc))
+}
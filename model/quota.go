@@ -0,0 +1,103 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// QuotaUnlimited is a limit of quotas overriding a default one, which lifts it as a zero limit keeps the
+// default.
+const QuotaUnlimited = -1
+
+// Quota limits how much can be printed, a zero or negative limit means unlimited.
+type Quota struct {
+	MessagesPerHour int
+	CharsPerDay     int
+}
+
+// Usage stores a print counted against quotas.
+type Usage struct {
+	gorm.Model
+	UserID   uint
+	DeviceID uint
+	Chars    int
+}
+
+// UsageWindow is the longest period a quota looks back on.
+const UsageWindow = 24 * time.Hour
+
+// IsUnlimited returns true if nothing is limited by the quota.
+func (q Quota) IsUnlimited() bool {
+	return q.MessagesPerHour <= 0 && q.CharsPerDay <= 0
+}
+
+// Override returns a copy of q with limits replaced by the non-zero ones of o, including QuotaUnlimited.
+func (q Quota) Override(o Quota) Quota {
+	if o.MessagesPerHour != 0 {
+		q.MessagesPerHour = o.MessagesPerHour
+	}
+	if o.CharsPerDay != 0 {
+		q.CharsPerDay = o.CharsPerDay
+	}
+	return q
+}
+
+// Check returns true if printing given chars at now exceeds the quota with the usages,
+// along with the time when the quota resets, which is zero if it never allows the print.
+func (q Quota) Check(usages []Usage, chars int, now time.Time) (bool, time.Time) {
+	var (
+		exceeded bool
+		resetAt  time.Time
+	)
+	later := func(t time.Time) {
+		if t.After(resetAt) {
+			resetAt = t
+		}
+	}
+
+	if q.MessagesPerHour > 0 {
+		recent := usagesSince(usages, now.Add(-time.Hour))
+		if len(recent) >= q.MessagesPerHour {
+			exceeded = true
+			later(recent[len(recent)-q.MessagesPerHour].CreatedAt.Add(time.Hour))
+		}
+	}
+
+	if q.CharsPerDay > 0 {
+		if chars > q.CharsPerDay {
+			return true, time.Time{}
+		}
+		recent := usagesSince(usages, now.Add(-UsageWindow))
+		var used int
+		for _, u := range recent {
+			used += u.Chars
+		}
+		if excess := used + chars - q.CharsPerDay; excess > 0 {
+			exceeded = true
+			for _, u := range recent {
+				excess -= u.Chars
+				if excess <= 0 {
+					later(u.CreatedAt.Add(UsageWindow))
+					break
+				}
+			}
+		}
+	}
+	return exceeded, resetAt
+}
+
+// usagesSince returns usages created after given time from the oldest.
+func usagesSince(usages []Usage, since time.Time) []Usage {
+	var recent []Usage
+	for _, u := range usages {
+		if u.CreatedAt.After(since) {
+			recent = append(recent, u)
+		}
+	}
+	sort.Slice(recent, func(i, j int) bool {
+		return recent[i].CreatedAt.Before(recent[j].CreatedAt)
+	})
+	return recent
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func usagesAgo(now time.Time, chars int, agos ...time.Duration) []Usage {
+	var usages []Usage
+	for _, ago := range agos {
+		u := Usage{Chars: chars}
+		u.CreatedAt = now.Add(-ago)
+		usages = append(usages, u)
+	}
+	return usages
+}
+
+func TestQuotaOverride(t *testing.T) {
+	q := Quota{MessagesPerHour: 10, CharsPerDay: 1000}
+	assert.Equal(t, q, q.Override(Quota{}))
+	assert.Equal(t, Quota{MessagesPerHour: 3, CharsPerDay: 1000}, q.Override(Quota{MessagesPerHour: 3}))
+	unlimited := q.Override(Quota{MessagesPerHour: QuotaUnlimited, CharsPerDay: QuotaUnlimited})
+	assert.True(t, unlimited.IsUnlimited(), "the default is lifted")
+	assert.True(t, Quota{}.IsUnlimited())
+	assert.False(t, q.IsUnlimited())
+}
+
+func TestQuotaCheckUnlimited(t *testing.T) {
+	now := time.Now()
+	exceeded, _ := Quota{}.Check(usagesAgo(now, 1000, 0, time.Minute), 1000, now)
+	assert.False(t, exceeded)
+}
+
+func TestQuotaCheckMessagesPerHour(t *testing.T) {
+	now := time.Now()
+	q := Quota{MessagesPerHour: 2}
+
+	exceeded, _ := q.Check(usagesAgo(now, 1, 10*time.Minute, 2*time.Hour), 1, now)
+	assert.False(t, exceeded)
+
+	exceeded, resetAt := q.Check(usagesAgo(now, 1, 10*time.Minute, 50*time.Minute, 30*time.Minute), 1, now)
+	assert.True(t, exceeded)
+	assert.Equal(t, now.Add(30*time.Minute), resetAt)
+}
+
+func TestQuotaCheckCharsPerDay(t *testing.T) {
+	now := time.Now()
+	q := Quota{CharsPerDay: 100}
+
+	exceeded, _ := q.Check(usagesAgo(now, 40, time.Hour, 25*time.Hour), 60, now)
+	assert.False(t, exceeded)
+
+	exceeded, resetAt := q.Check(usagesAgo(now, 40, time.Hour, 2*time.Hour), 30, now)
+	assert.True(t, exceeded)
+	assert.Equal(t, now.Add(22*time.Hour), resetAt)
+
+	exceeded, resetAt = q.Check(nil, 101, now)
+	assert.True(t, exceeded)
+	assert.True(t, resetAt.IsZero())
+}
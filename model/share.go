@@ -0,0 +1,11 @@
+package model
+
+import "github.com/jinzhu/gorm"
+
+// Share stores the users a device is shared with.
+type Share struct {
+	gorm.Model
+	DeviceID uint
+	UserID   uint
+	Quota    Quota `gorm:"embedded;embedded_prefix:quota_"`
+}
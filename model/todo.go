@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// TodoList is a named to-do list of a user.
+type TodoList struct {
+	gorm.Model
+	UserID uint `gorm:"index"`
+	Name   string
+	// MorningTime is the time of day as "15:04" in the time zone of the device to print the list daily,
+	// it's not printed automatically if empty.
+	MorningTime   string
+	LastMorningAt *time.Time
+}
+
+// IsMorningDue returns true if the list should be printed automatically at now in loc.
+func (l *TodoList) IsMorningDue(now time.Time, loc *time.Location) bool {
+	return isDailyDue(l.MorningTime, l.CreatedAt, l.LastMorningAt, now, loc)
+}
+
+// TodoItem is an item of a to-do list.
+type TodoItem struct {
+	gorm.Model
+	ListID uint `gorm:"index"`
+	Text   string
+	// DoneAt is when the item was checked off, nil if not yet.
+	DoneAt *time.Time
+
+	// KeyID is the master key SealedText is sealed by, empty if Text is stored in plaintext.
+	KeyID      string
+	SealedText []byte
+}
+
+// IsDone returns true if the item was checked off.
+func (i *TodoItem) IsDone() bool {
+	return i.DoneAt != nil
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoListIsMorningDue(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	l := &TodoList{}
+	l.CreatedAt = time.Date(2019, 10, 1, 9, 0, 0, 0, loc)
+	assert.False(t, l.IsMorningDue(time.Date(2019, 10, 2, 7, 0, 0, 0, loc), loc), "not automatic")
+
+	l.MorningTime = "07:00"
+	assert.False(t, l.IsMorningDue(time.Date(2019, 10, 1, 10, 0, 0, 0, loc), loc), "created after the time")
+	assert.False(t, l.IsMorningDue(time.Date(2019, 10, 2, 6, 59, 0, 0, loc), loc))
+	assert.True(t, l.IsMorningDue(time.Date(2019, 10, 2, 7, 0, 0, 0, loc), loc))
+
+	last := time.Date(2019, 10, 2, 7, 0, 0, 0, loc)
+	l.LastMorningAt = &last
+	assert.False(t, l.IsMorningDue(time.Date(2019, 10, 2, 8, 0, 0, 0, loc), loc))
+}
@@ -1,13 +1,47 @@
 package model
 
 import (
+	"time"
+
 	"github.com/jinzhu/gorm"
 )
 
 // User stores the users of bot.
 type User struct {
 	gorm.Model
-	TelegramID       int64
+	TelegramID       int64 `gorm:"unique_index"`
 	TelegramUserName string
 	TelegramFullName string
+	// TelegramLanguageCode is the IETF language tag of the telegram app.
+	TelegramLanguageCode string
+	// Language is the language chosen by the user, empty to follow TelegramLanguageCode.
+	Language string
+
+	// HistoryRetentionDays is how long the printed contents are kept, 0 means forever.
+	HistoryRetentionDays int
+	// PrintTemplate wraps what the user prints, either a preset name or a text/template, empty to follow the device.
+	PrintTemplate string `gorm:"type:text"`
+	// ContactLayout is the layout of the contact cards printed, empty means ContactLayoutFull.
+	ContactLayout string
+	// StickerCaption prints the emoji of stickers under them by their short names.
+	StickerCaption bool
+
+	// LastSeenAt is when the user last interacted with the bot, updated about once a minute.
+	LastSeenAt *time.Time
+
+	// BannedAt is when the user was banned, nil if not banned.
+	BannedAt *time.Time
+}
+
+// layouts of contact cards.
+const (
+	// ContactLayoutFull prints the name, phone and telegram username on separate lines.
+	ContactLayoutFull = "full"
+	// ContactLayoutCompact prints the name and phone on one line.
+	ContactLayoutCompact = "compact"
+)
+
+// IsBanned returns true if the user was banned.
+func (u User) IsBanned() bool {
+	return u.BannedAt != nil
 }
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Webhook stores the incoming webhooks printing to a device.
+type Webhook struct {
+	gorm.Model
+	UserID   uint
+	DeviceID uint
+	// Secret is the unguessable part of the URL of the webhook. The SHA-256 of it in hex is stored instead
+	// while sealed so that webhooks can still be found by secrets.
+	Secret string `gorm:"unique_index"`
+	Kind   string
+	// Template renders payloads of the generic JSON kind.
+	Template string `gorm:"type:text"`
+	// SigningSecret is what payloads are signed by if the kind supports signatures, it's empty while
+	// sealed as it's sealed along with Secret.
+	SigningSecret string
+
+	// KeyID is the master key SealedSecret is sealed by, empty if Secret is stored in plaintext.
+	KeyID        string
+	SealedSecret []byte
+}
+
+// GenerateSecret sets the Secret and the SigningSecret to random strings.
+func (w *Webhook) GenerateSecret() (*Webhook, error) {
+	var err error
+	if w.Secret, err = randomHex(16); err != nil {
+		return nil, err
+	}
+	if w.SigningSecret, err = randomHex(16); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
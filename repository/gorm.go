@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+// GormUsers persists users in a database.
+type GormUsers struct {
+	DB *gorm.DB
+}
+
+// Create creates the user.
+func (r *GormUsers) Create(user *model.User) error {
+	return r.DB.Create(user).Error
+}
+
+// Get returns the user of id.
+func (r *GormUsers) Get(id uint) (*model.User, error) {
+	var user model.User
+	return &user, r.DB.First(&user, id).Error
+}
+
+// GetByTelegramID returns the user of the telegram ID.
+func (r *GormUsers) GetByTelegramID(telegramID int64) (*model.User, error) {
+	var user model.User
+	return &user, r.DB.First(&user, "telegram_id = ?", telegramID).Error
+}
+
+// GetByTelegramUserName returns the user of the username case-insensitively.
+func (r *GormUsers) GetByTelegramUserName(userName string) (*model.User, error) {
+	var user model.User
+	return &user, r.DB.First(&user, "lower(telegram_user_name) = lower(?)", userName).Error
+}
+
+// Update updates the columns of the user.
+func (r *GormUsers) Update(id uint, columns Columns) error {
+	return r.DB.Model(&model.User{}).Where("id = ?", id).Updates(columns).Error
+}
+
+// List returns all users.
+func (r *GormUsers) List() ([]model.User, error) {
+	var users []model.User
+	return users, r.DB.Order("id").Find(&users).Error
+}
+
+// ListNotBanned returns the users not banned.
+func (r *GormUsers) ListNotBanned() ([]model.User, error) {
+	var users []model.User
+	return users, r.DB.Order("id").Find(&users, "banned_at is null").Error
+}
+
+// Count returns the number of users.
+func (r *GormUsers) Count() (int, error) {
+	var count int
+	return count, r.DB.Model(&model.User{}).Count(&count).Error
+}
+
+// CountSeenSince returns the number of users seen since t.
+func (r *GormUsers) CountSeenSince(t time.Time) (int, error) {
+	var count int
+	return count, r.DB.Model(&model.User{}).Where("last_seen_at >= ?", t).Count(&count).Error
+}
+
+// GormDevices persists devices in a database.
+type GormDevices struct {
+	DB *gorm.DB
+}
+
+// Create creates the device.
+func (r *GormDevices) Create(device *model.Device) error {
+	return r.DB.Create(device).Error
+}
+
+// Get returns the device of id.
+func (r *GormDevices) Get(id uint) (*model.Device, error) {
+	var device model.Device
+	return &device, r.DB.First(&device, id).Error
+}
+
+// GetByUserID returns the first device of the user.
+func (r *GormDevices) GetByUserID(userID uint) (*model.Device, error) {
+	var device model.Device
+	return &device, r.DB.First(&device, "user_id = ?", userID).Error
+}
+
+// GetByMemobirdID returns the first device of the memobird ID.
+func (r *GormDevices) GetByMemobirdID(memobirdID string) (*model.Device, error) {
+	var device model.Device
+	return &device, r.DB.First(&device, "memobird_id = ?", memobirdID).Error
+}
+
+// Update updates the columns of the device.
+func (r *GormDevices) Update(id uint, columns Columns) error {
+	return r.DB.Unscoped().Model(&model.Device{}).Where("id = ?", id).Updates(columns).Error
+}
+
+// UpdateAll updates the columns of devices in a transaction.
+func (r *GormDevices) UpdateAll(updates []RowUpdate) error {
+	return updateAll(r.DB, &model.Device{}, updates)
+}
+
+// Verify marks the device of the user verified.
+func (r *GormDevices) Verify(id, userID uint) (bool, error) {
+	q := r.DB.Model(&model.Device{}).
+		Where("id = ? and user_id = ? and verification_code <> ?", id, userID, model.DeviceVerified).
+		Updates(verifiedColumns)
+	return q.RowsAffected == 1, q.Error
+}
+
+// verifiedColumns are the columns of devices verified.
+var verifiedColumns = Columns{
+	"verification_code":        model.DeviceVerified,
+	"key_id":                   "",
+	"sealed_verification_code": nil,
+}
+
+// updateAll updates the rows of the table of value in a transaction, including the soft-deleted ones.
+func updateAll(db *gorm.DB, value interface{}, updates []RowUpdate) error {
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	for _, u := range updates {
+		if err := tx.Unscoped().Model(value).Where("id = ?", u.ID).Updates(u.Columns).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// List returns all devices.
+func (r *GormDevices) List() ([]model.Device, error) {
+	var devices []model.Device
+	return devices, r.DB.Order("id").Find(&devices).Error
+}
+
+// ListByUserID returns the devices of the user.
+func (r *GormDevices) ListByUserID(userID uint) ([]model.Device, error) {
+	var devices []model.Device
+	return devices, r.DB.Order("id").Find(&devices, "user_id = ?", userID).Error
+}
+
+// CountVerified returns the number of verified devices.
+func (r *GormDevices) CountVerified() (int, error) {
+	var count int
+	err := r.DB.Model(&model.Device{}).
+		Where("user_id > 0 and verification_code = ?", model.DeviceVerified).
+		Count(&count).Error
+	return count, err
+}
+
+// ListNotSealedBy returns devices not sealed by keyID.
+func (r *GormDevices) ListNotSealedBy(keyID string, afterID uint, limit int) ([]model.Device, error) {
+	var devices []model.Device
+	return devices, notSealedBy(r.DB, keyID, afterID, limit).Find(&devices).Error
+}
+
+// notSealedBy returns the query of up to limit rows after afterID not sealed by keyID, including the ones
+// in plaintext and soft-deleted.
+func notSealedBy(db *gorm.DB, keyID string, afterID uint, limit int) *gorm.DB {
+	return db.Unscoped().Where("id > ? and (key_id is null or key_id <> ?)", afterID, keyID).Order("id").Limit(limit)
+}
+
+// GormContents persists contents in a database.
+type GormContents struct {
+	DB *gorm.DB
+}
+
+// Create creates the content.
+func (r *GormContents) Create(content *model.Content) error {
+	return r.DB.Create(content).Error
+}
+
+// GetByIDAndUserID returns the content of the user.
+func (r *GormContents) GetByIDAndUserID(id, userID uint) (*model.Content, error) {
+	var content model.Content
+	return &content, r.DB.First(&content, "id = ? and user_id = ?", id, userID).Error
+}
+
+// Update updates the columns of the content.
+func (r *GormContents) Update(id uint, columns Columns) error {
+	return r.DB.Unscoped().Model(&model.Content{}).Where("id = ?", id).Updates(columns).Error
+}
+
+// UpdateAll updates the columns of contents in a transaction.
+func (r *GormContents) UpdateAll(updates []RowUpdate) error {
+	return updateAll(r.DB, &model.Content{}, updates)
+}
+
+// ListByUserID returns the contents of the user from the newest.
+func (r *GormContents) ListByUserID(userID uint, offset, limit int) ([]model.Content, int, error) {
+	var (
+		contents []model.Content
+		total    int
+	)
+	q := r.DB.Model(&model.Content{}).Where("user_id = ?", userID)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	return contents, total, q.Order("id desc").Offset(offset).Limit(limit).Find(&contents).Error
+}
+
+// ListNotSealedBy returns contents not sealed by keyID.
+func (r *GormContents) ListNotSealedBy(keyID string, afterID uint, limit int) ([]model.Content, error) {
+	var contents []model.Content
+	return contents, notSealedBy(r.DB, keyID, afterID, limit).Find(&contents).Error
+}
+
+// DeleteByIDAndUserID permanently deletes the content of the user.
+func (r *GormContents) DeleteByIDAndUserID(id, userID uint) (bool, error) {
+	q := r.DB.Unscoped().Where("id = ? and user_id = ?", id, userID).Delete(&model.Content{})
+	return q.RowsAffected == 1, q.Error
+}
+
+// DeleteByUserIDBefore permanently deletes the contents of the user created before t.
+func (r *GormContents) DeleteByUserIDBefore(userID uint, t time.Time) (int64, error) {
+	q := r.DB.Unscoped().Where("user_id = ? and created_at < ?", userID, t).Delete(&model.Content{})
+	return q.RowsAffected, q.Error
+}
@@ -0,0 +1,404 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+// setColumns sets the fields of the columns of row, which is a pointer to a model, as gorm would. Unknown
+// columns are ignored.
+func setColumns(row interface{}, columns Columns, now time.Time) error {
+	scope := &gorm.Scope{Value: row}
+	for name, value := range columns {
+		field, ok := scope.FieldByName(name)
+		if !ok {
+			continue
+		}
+		if err := field.Set(value); err != nil {
+			return fmt.Errorf("setting column %s: %w", name, err)
+		}
+	}
+	if field, ok := scope.FieldByName("UpdatedAt"); ok {
+		return field.Set(now)
+	}
+	return nil
+}
+
+// created sets the ID and timestamps of a new row as gorm would, the ID is assigned from lastID if zero.
+func created(m *gorm.Model, lastID *uint, now time.Time) {
+	if m.ID == 0 {
+		m.ID = *lastID + 1
+	}
+	if m.ID > *lastID {
+		*lastID = m.ID
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+	if m.UpdatedAt.IsZero() {
+		m.UpdatedAt = now
+	}
+}
+
+// MemoryUsers persists users in memory, it is safe for concurrent use.
+type MemoryUsers struct {
+	mu     sync.Mutex
+	lastID uint
+	// users are in order of ID.
+	users []model.User
+}
+
+// Create creates the user.
+func (r *MemoryUsers) Create(user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.TelegramID == user.TelegramID {
+			return fmt.Errorf("user of telegram ID %d exists", user.TelegramID)
+		}
+		if user.ID != 0 && u.ID == user.ID {
+			return fmt.Errorf("user[%d] exists", user.ID)
+		}
+	}
+	created(&user.Model, &r.lastID, time.Now())
+	r.users = append(r.users, *user)
+	sort.Slice(r.users, func(i, j int) bool { return r.users[i].ID < r.users[j].ID })
+	return nil
+}
+
+// find returns a copy of the first user matching, gorm.ErrRecordNotFound if none.
+func (r *MemoryUsers) find(match func(*model.User) bool) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.users {
+		if match(&r.users[i]) {
+			user := r.users[i]
+			return &user, nil
+		}
+	}
+	return &model.User{}, gorm.ErrRecordNotFound
+}
+
+// Get returns the user of id.
+func (r *MemoryUsers) Get(id uint) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return u.ID == id })
+}
+
+// GetByTelegramID returns the user of the telegram ID.
+func (r *MemoryUsers) GetByTelegramID(telegramID int64) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return u.TelegramID == telegramID })
+}
+
+// GetByTelegramUserName returns the user of the username case-insensitively.
+func (r *MemoryUsers) GetByTelegramUserName(userName string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return strings.EqualFold(u.TelegramUserName, userName) })
+}
+
+// Update updates the columns of the user.
+func (r *MemoryUsers) Update(id uint, columns Columns) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.users {
+		if r.users[i].ID == id {
+			user := r.users[i]
+			if err := setColumns(&user, columns, time.Now()); err != nil {
+				return err
+			}
+			r.users[i] = user
+		}
+	}
+	return nil
+}
+
+// List returns all users.
+func (r *MemoryUsers) List() ([]model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]model.User(nil), r.users...), nil
+}
+
+// ListNotBanned returns the users not banned.
+func (r *MemoryUsers) ListNotBanned() ([]model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []model.User
+	for _, u := range r.users {
+		if !u.IsBanned() {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// Count returns the number of users.
+func (r *MemoryUsers) Count() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.users), nil
+}
+
+// CountSeenSince returns the number of users seen since t.
+func (r *MemoryUsers) CountSeenSince(t time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, u := range r.users {
+		if u.LastSeenAt != nil && !u.LastSeenAt.Before(t) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MemoryDevices persists devices in memory, it is safe for concurrent use.
+type MemoryDevices struct {
+	mu     sync.Mutex
+	lastID uint
+	// devices are in order of ID.
+	devices []model.Device
+}
+
+// Create creates the device.
+func (r *MemoryDevices) Create(device *model.Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.devices {
+		if device.ID != 0 && d.ID == device.ID {
+			return fmt.Errorf("device[%d] exists", device.ID)
+		}
+	}
+	created(&device.Model, &r.lastID, time.Now())
+	r.devices = append(r.devices, *device)
+	sort.Slice(r.devices, func(i, j int) bool { return r.devices[i].ID < r.devices[j].ID })
+	return nil
+}
+
+// filter returns copies of up to limit devices matching in order of ID, limit is ignored if negative.
+func (r *MemoryDevices) filter(match func(*model.Device) bool, limit int) []model.Device {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var devices []model.Device
+	for i := range r.devices {
+		if limit >= 0 && len(devices) == limit {
+			break
+		}
+		if match(&r.devices[i]) {
+			devices = append(devices, r.devices[i])
+		}
+	}
+	return devices
+}
+
+// first returns the first device matching, gorm.ErrRecordNotFound if none.
+func (r *MemoryDevices) first(match func(*model.Device) bool) (*model.Device, error) {
+	devices := r.filter(match, 1)
+	if len(devices) == 0 {
+		return &model.Device{}, gorm.ErrRecordNotFound
+	}
+	return &devices[0], nil
+}
+
+// Get returns the device of id.
+func (r *MemoryDevices) Get(id uint) (*model.Device, error) {
+	return r.first(func(d *model.Device) bool { return d.ID == id })
+}
+
+// GetByUserID returns the first device of the user.
+func (r *MemoryDevices) GetByUserID(userID uint) (*model.Device, error) {
+	return r.first(func(d *model.Device) bool { return d.UserID == userID })
+}
+
+// GetByMemobirdID returns the first device of the memobird ID.
+func (r *MemoryDevices) GetByMemobirdID(memobirdID string) (*model.Device, error) {
+	return r.first(func(d *model.Device) bool { return d.MemobirdID == memobirdID })
+}
+
+// Update updates the columns of the device.
+func (r *MemoryDevices) Update(id uint, columns Columns) error {
+	return r.UpdateAll([]RowUpdate{{ID: id, Columns: columns}})
+}
+
+// UpdateAll updates the columns of devices, none is updated if any fails.
+func (r *MemoryDevices) UpdateAll(updates []RowUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	devices := append([]model.Device(nil), r.devices...)
+	now := time.Now()
+	for _, u := range updates {
+		for i := range devices {
+			if devices[i].ID == u.ID {
+				if err := setColumns(&devices[i], u.Columns, now); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	r.devices = devices
+	return nil
+}
+
+// Verify marks the device of the user verified.
+func (r *MemoryDevices) Verify(id, userID uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.devices {
+		d := &r.devices[i]
+		if d.ID == id && d.UserID == userID && d.VerificationCode != model.DeviceVerified {
+			return true, setColumns(d, verifiedColumns, time.Now())
+		}
+	}
+	return false, nil
+}
+
+// List returns all devices.
+func (r *MemoryDevices) List() ([]model.Device, error) {
+	return r.filter(func(*model.Device) bool { return true }, -1), nil
+}
+
+// ListByUserID returns the devices of the user.
+func (r *MemoryDevices) ListByUserID(userID uint) ([]model.Device, error) {
+	return r.filter(func(d *model.Device) bool { return d.UserID == userID }, -1), nil
+}
+
+// CountVerified returns the number of verified devices.
+func (r *MemoryDevices) CountVerified() (int, error) {
+	return len(r.filter(func(d *model.Device) bool { return d.IsVerified() }, -1)), nil
+}
+
+// ListNotSealedBy returns devices not sealed by keyID.
+func (r *MemoryDevices) ListNotSealedBy(keyID string, afterID uint, limit int) ([]model.Device, error) {
+	return r.filter(func(d *model.Device) bool { return d.ID > afterID && d.KeyID != keyID }, limit), nil
+}
+
+// MemoryContents persists contents in memory, it is safe for concurrent use.
+type MemoryContents struct {
+	mu     sync.Mutex
+	lastID uint
+	// contents are in order of ID.
+	contents []model.Content
+}
+
+// Create creates the content.
+func (r *MemoryContents) Create(content *model.Content) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.contents {
+		if content.ID != 0 && c.ID == content.ID {
+			return fmt.Errorf("content[%d] exists", content.ID)
+		}
+	}
+	created(&content.Model, &r.lastID, time.Now())
+	r.contents = append(r.contents, *content)
+	sort.Slice(r.contents, func(i, j int) bool { return r.contents[i].ID < r.contents[j].ID })
+	return nil
+}
+
+// filter returns copies of the contents matching in order of ID.
+func (r *MemoryContents) filter(match func(*model.Content) bool) []model.Content {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var contents []model.Content
+	for i := range r.contents {
+		if match(&r.contents[i]) {
+			contents = append(contents, r.contents[i])
+		}
+	}
+	return contents
+}
+
+// newest returns the contents from the newest.
+func newest(contents []model.Content) []model.Content {
+	for i, j := 0, len(contents)-1; i < j; i, j = i+1, j-1 {
+		contents[i], contents[j] = contents[j], contents[i]
+	}
+	return contents
+}
+
+// page returns up to limit contents after offset, limit is ignored if negative.
+func page(contents []model.Content, offset, limit int) []model.Content {
+	if offset > len(contents) {
+		offset = len(contents)
+	}
+	contents = contents[offset:]
+	if limit >= 0 && limit < len(contents) {
+		contents = contents[:limit]
+	}
+	return contents
+}
+
+// GetByIDAndUserID returns the content of the user.
+func (r *MemoryContents) GetByIDAndUserID(id, userID uint) (*model.Content, error) {
+	contents := r.filter(func(c *model.Content) bool { return c.ID == id && c.UserID == userID })
+	if len(contents) == 0 {
+		return &model.Content{}, gorm.ErrRecordNotFound
+	}
+	return &contents[0], nil
+}
+
+// Update updates the columns of the content.
+func (r *MemoryContents) Update(id uint, columns Columns) error {
+	return r.UpdateAll([]RowUpdate{{ID: id, Columns: columns}})
+}
+
+// UpdateAll updates the columns of contents, none is updated if any fails.
+func (r *MemoryContents) UpdateAll(updates []RowUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	contents := append([]model.Content(nil), r.contents...)
+	now := time.Now()
+	for _, u := range updates {
+		for i := range contents {
+			if contents[i].ID == u.ID {
+				if err := setColumns(&contents[i], u.Columns, now); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	r.contents = contents
+	return nil
+}
+
+// ListByUserID returns the contents of the user from the newest.
+func (r *MemoryContents) ListByUserID(userID uint, offset, limit int) ([]model.Content, int, error) {
+	contents := r.filter(func(c *model.Content) bool { return c.UserID == userID })
+	return page(newest(contents), offset, limit), len(contents), nil
+}
+
+// ListNotSealedBy returns contents not sealed by keyID.
+func (r *MemoryContents) ListNotSealedBy(keyID string, afterID uint, limit int) ([]model.Content, error) {
+	contents := r.filter(func(c *model.Content) bool { return c.ID > afterID && c.KeyID != keyID })
+	return page(contents, 0, limit), nil
+}
+
+// deleteWhere deletes the contents matching, the number deleted is returned.
+func (r *MemoryContents) deleteWhere(match func(*model.Content) bool) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.contents[:0]
+	for i := range r.contents {
+		if !match(&r.contents[i]) {
+			kept = append(kept, r.contents[i])
+		}
+	}
+	n := int64(len(r.contents) - len(kept))
+	r.contents = kept
+	return n
+}
+
+// DeleteByIDAndUserID permanently deletes the content of the user.
+func (r *MemoryContents) DeleteByIDAndUserID(id, userID uint) (bool, error) {
+	return r.deleteWhere(func(c *model.Content) bool { return c.ID == id && c.UserID == userID }) == 1, nil
+}
+
+// DeleteByUserIDBefore permanently deletes the contents of the user created before t.
+func (r *MemoryContents) DeleteByUserIDBefore(userID uint, t time.Time) (int64, error) {
+	return r.deleteWhere(func(c *model.Content) bool { return c.UserID == userID && c.CreatedAt.Before(t) }), nil
+}
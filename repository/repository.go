@@ -0,0 +1,82 @@
+// Package repository persists users, devices and contents, either in a database by gorm or in memory for
+// tests. Missing records are reported by gorm.ErrRecordNotFound by both.
+package repository
+
+import (
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+// Columns are the values of columns to update by name, e.g. "nickname".
+type Columns = map[string]interface{}
+
+// RowUpdate is an update of the columns of the row of ID.
+type RowUpdate struct {
+	ID      uint
+	Columns Columns
+}
+
+// Users persists users.
+type Users interface {
+	// Create creates the user, it fails if the telegram ID exists.
+	Create(*model.User) error
+	Get(id uint) (*model.User, error)
+	GetByTelegramID(telegramID int64) (*model.User, error)
+	// GetByTelegramUserName returns the user of the username case-insensitively.
+	GetByTelegramUserName(userName string) (*model.User, error)
+	// Update updates the columns of the user of id, nothing is updated if not found.
+	Update(id uint, columns Columns) error
+	// List returns all users in order of ID.
+	List() ([]model.User, error)
+	// ListNotBanned returns the users not banned in order of ID.
+	ListNotBanned() ([]model.User, error)
+	Count() (int, error)
+	// CountSeenSince returns the number of users whose LastSeenAt is not before t.
+	CountSeenSince(t time.Time) (int, error)
+}
+
+// Devices persists devices.
+type Devices interface {
+	Create(*model.Device) error
+	Get(id uint) (*model.Device, error)
+	// GetByUserID returns the first device of the user.
+	GetByUserID(userID uint) (*model.Device, error)
+	// GetByMemobirdID returns the first device of the memobird ID.
+	GetByMemobirdID(memobirdID string) (*model.Device, error)
+	// Update updates the columns of the device of id, including a soft-deleted one.
+	Update(id uint, columns Columns) error
+	// UpdateAll is Update of every device in a transaction, none is updated if any fails.
+	UpdateAll(updates []RowUpdate) error
+	// Verify marks the device of id owned by the user verified and clears its code, false is returned if
+	// no such device is waiting to be verified.
+	Verify(id, userID uint) (bool, error)
+	// List returns all devices in order of ID.
+	List() ([]model.Device, error)
+	// ListByUserID returns the devices of the user in order of ID.
+	ListByUserID(userID uint) ([]model.Device, error)
+	CountVerified() (int, error)
+	// ListNotSealedBy returns up to limit devices after afterID in order of ID whose KeyID is not keyID,
+	// including soft-deleted ones.
+	ListNotSealedBy(keyID string, afterID uint, limit int) ([]model.Device, error)
+}
+
+// Contents persists contents sent to print.
+type Contents interface {
+	Create(*model.Content) error
+	GetByIDAndUserID(id, userID uint) (*model.Content, error)
+	// Update updates the columns of the content of id, including a soft-deleted one.
+	Update(id uint, columns Columns) error
+	// UpdateAll is Update of every content in a transaction, none is updated if any fails.
+	UpdateAll(updates []RowUpdate) error
+	// ListByUserID returns the contents of the user from the newest, along with the total count.
+	ListByUserID(userID uint, offset, limit int) ([]model.Content, int, error)
+	// ListNotSealedBy returns up to limit contents after afterID in order of ID whose KeyID is not keyID,
+	// including soft-deleted ones.
+	ListNotSealedBy(keyID string, afterID uint, limit int) ([]model.Content, error)
+	// DeleteByIDAndUserID permanently deletes the content of the user, false is returned if not found.
+	DeleteByIDAndUserID(id, userID uint) (bool, error)
+	// DeleteByUserIDBefore permanently deletes the contents of the user created before t, the number
+	// deleted is returned.
+	DeleteByUserIDBefore(userID uint, t time.Time) (int64, error)
+}
@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+// repositories are the implementations conforming to the same behaviors.
+type repositories struct {
+	users    Users
+	devices  Devices
+	contents Contents
+}
+
+func forEach(t *testing.T, test func(t *testing.T, r repositories)) {
+	t.Run("gorm", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "repository")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer os.RemoveAll(dir)
+		db, err := gorm.Open("sqlite3", filepath.Join(dir, "test.db"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer db.Close()
+		db.LogMode(false)
+		assert.NoError(t, db.AutoMigrate(&model.User{}, &model.Device{}, &model.Content{}).Error)
+		test(t, repositories{&GormUsers{DB: db}, &GormDevices{DB: db}, &GormContents{DB: db}})
+	})
+	t.Run("memory", func(t *testing.T) {
+		test(t, repositories{&MemoryUsers{}, &MemoryDevices{}, &MemoryContents{}})
+	})
+}
+
+func TestUsers(t *testing.T) {
+	forEach(t, func(t *testing.T, r repositories) {
+		alice := &model.User{TelegramID: 1, TelegramUserName: "Alice"}
+		assert.NoError(t, r.users.Create(alice))
+		assert.NotZero(t, alice.ID)
+		assert.False(t, alice.CreatedAt.IsZero())
+		assert.Error(t, r.users.Create(&model.User{TelegramID: 1}))
+		bob := &model.User{TelegramID: 2, TelegramUserName: "bob"}
+		assert.NoError(t, r.users.Create(bob))
+
+		user, err := r.users.Get(alice.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), user.TelegramID)
+		}
+		_, err = r.users.Get(bob.ID + 1)
+		assert.True(t, gorm.IsRecordNotFoundError(err))
+		user, err = r.users.GetByTelegramID(2)
+		if assert.NoError(t, err) {
+			assert.Equal(t, bob.ID, user.ID)
+		}
+		_, err = r.users.GetByTelegramID(3)
+		assert.True(t, gorm.IsRecordNotFoundError(err))
+		user, err = r.users.GetByTelegramUserName("alice")
+		if assert.NoError(t, err) {
+			assert.Equal(t, alice.ID, user.ID)
+		}
+
+		now := time.Now()
+		assert.NoError(t, r.users.Update(alice.ID, Columns{"language": "zh", "last_seen_at": now}))
+		user, err = r.users.Get(alice.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "zh", user.Language)
+			assert.True(t, now.Equal(*user.LastSeenAt))
+			// the user returned is a copy
+			user.Language = "en"
+		}
+		assert.NoError(t, r.users.Update(alice.ID, Columns{"last_seen_at": nil}))
+		user, err = r.users.Get(alice.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "zh", user.Language)
+			assert.Nil(t, user.LastSeenAt)
+		}
+
+		assert.NoError(t, r.users.Update(bob.ID, Columns{"last_seen_at": now}))
+		count, err := r.users.CountSeenSince(now.Add(-time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		count, err = r.users.Count()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		users, err := r.users.List()
+		if assert.NoError(t, err) && assert.Len(t, users, 2) {
+			assert.Equal(t, alice.ID, users[0].ID)
+			assert.Equal(t, bob.ID, users[1].ID)
+		}
+		assert.NoError(t, r.users.Update(alice.ID, Columns{"banned_at": &now}))
+		users, err = r.users.ListNotBanned()
+		if assert.NoError(t, err) && assert.Len(t, users, 1) {
+			assert.Equal(t, bob.ID, users[0].ID)
+		}
+	})
+}
+
+func TestDevices(t *testing.T) {
+	forEach(t, func(t *testing.T, r repositories) {
+		first := &model.Device{MemobirdID: "a", UserID: 1, VerificationCode: model.DeviceVerified}
+		second := &model.Device{MemobirdID: "b", UserID: 1, VerificationCode: 123456}
+		other := &model.Device{MemobirdID: "c", UserID: 2, KeyID: "k1", SealedVerificationCode: []byte{1}}
+		for _, device := range []*model.Device{first, second, other} {
+			assert.NoError(t, r.devices.Create(device))
+		}
+
+		device, err := r.devices.Get(second.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "b", device.MemobirdID)
+		}
+		device, err = r.devices.GetByUserID(1)
+		if assert.NoError(t, err) {
+			assert.Equal(t, first.ID, device.ID)
+		}
+		device, err = r.devices.GetByMemobirdID("c")
+		if assert.NoError(t, err) {
+			assert.Equal(t, other.ID, device.ID)
+			assert.Equal(t, []byte{1}, device.SealedVerificationCode)
+		}
+		_, err = r.devices.GetByMemobirdID("d")
+		assert.True(t, gorm.IsRecordNotFoundError(err))
+
+		devices, err := r.devices.ListByUserID(1)
+		if assert.NoError(t, err) && assert.Len(t, devices, 2) {
+			assert.Equal(t, first.ID, devices[0].ID)
+			assert.Equal(t, second.ID, devices[1].ID)
+		}
+		devices, err = r.devices.List()
+		assert.NoError(t, err)
+		assert.Len(t, devices, 3)
+		count, err := r.devices.CountVerified()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		devices, err = r.devices.ListNotSealedBy("k1", 0, 10)
+		if assert.NoError(t, err) && assert.Len(t, devices, 2) {
+			assert.Equal(t, first.ID, devices[0].ID)
+			assert.Equal(t, second.ID, devices[1].ID)
+		}
+		devices, err = r.devices.ListNotSealedBy("k2", first.ID, 1)
+		if assert.NoError(t, err) && assert.Len(t, devices, 1) {
+			assert.Equal(t, second.ID, devices[0].ID)
+		}
+
+		verified, err := r.devices.Verify(other.ID, 1)
+		assert.NoError(t, err)
+		assert.False(t, verified, "owned by another user")
+		verified, err = r.devices.Verify(first.ID, 1)
+		assert.NoError(t, err)
+		assert.False(t, verified, "verified already")
+		verified, err = r.devices.Verify(other.ID, 2)
+		assert.NoError(t, err)
+		assert.True(t, verified)
+		device, err = r.devices.Get(other.ID)
+		if assert.NoError(t, err) {
+			assert.True(t, device.IsVerified())
+			assert.Empty(t, device.KeyID)
+			assert.Empty(t, device.SealedVerificationCode)
+		}
+
+		assert.NoError(t, r.devices.Update(second.ID, Columns{
+			"verification_code":       model.DeviceVerified,
+			"quota_messages_per_hour": 5,
+		}))
+		device, err = r.devices.Get(second.ID)
+		if assert.NoError(t, err) {
+			assert.True(t, device.IsVerified())
+			assert.Equal(t, 5, device.Quota.MessagesPerHour)
+		}
+	})
+}
+
+func TestContents(t *testing.T) {
+	forEach(t, func(t *testing.T, r repositories) {
+		now := time.Now()
+		var contents []*model.Content
+		for i, text := range []string{"a", "b", "c", "d"} {
+			content := &model.Content{UserID: 1, Text: text}
+			content.CreatedAt = now.Add(time.Duration(i-3) * time.Hour)
+			if i == 1 {
+				content.Error = "jammed"
+			}
+			assert.NoError(t, r.contents.Create(content))
+			contents = append(contents, content)
+		}
+		assert.NoError(t, r.contents.Create(&model.Content{UserID: 2, Text: "e", KeyID: "k1"}))
+
+		content, err := r.contents.GetByIDAndUserID(contents[0].ID, 1)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "a", content.Text)
+		}
+		_, err = r.contents.GetByIDAndUserID(contents[0].ID, 2)
+		assert.True(t, gorm.IsRecordNotFoundError(err))
+
+		page, total, err := r.contents.ListByUserID(1, 1, 2)
+		if assert.NoError(t, err) && assert.Len(t, page, 2) {
+			assert.Equal(t, 4, total)
+			assert.Equal(t, "c", page[0].Text)
+			assert.Equal(t, "b", page[1].Text)
+		}
+		unsealed, err := r.contents.ListNotSealedBy("k1", contents[1].ID, 5)
+		if assert.NoError(t, err) && assert.Len(t, unsealed, 2) {
+			assert.Equal(t, "c", unsealed[0].Text)
+			assert.Equal(t, "d", unsealed[1].Text)
+		}
+
+		printedAt := now.Add(time.Minute)
+		assert.NoError(t, r.contents.Update(contents[3].ID, Columns{"is_printed": true, "printed_at": &printedAt}))
+		content, err = r.contents.GetByIDAndUserID(contents[3].ID, 1)
+		if assert.NoError(t, err) {
+			assert.True(t, content.IsPrinted)
+			assert.True(t, printedAt.Equal(*content.PrintedAt))
+		}
+
+		assert.NoError(t, r.contents.UpdateAll([]RowUpdate{
+			{ID: contents[0].ID, Columns: Columns{"key_id": "k2", "sealed_text": []byte{2}}},
+			{ID: contents[1].ID, Columns: Columns{"key_id": "k2"}},
+		}))
+		unsealed, err = r.contents.ListNotSealedBy("k2", 0, 5)
+		if assert.NoError(t, err) && assert.Len(t, unsealed, 3) {
+			assert.Equal(t, contents[2].ID, unsealed[0].ID)
+		}
+
+		deleted, err := r.contents.DeleteByIDAndUserID(contents[3].ID, 2)
+		assert.NoError(t, err)
+		assert.False(t, deleted)
+		deleted, err = r.contents.DeleteByIDAndUserID(contents[3].ID, 1)
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+		n, err := r.contents.DeleteByUserIDBefore(1, now.Add(-90*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		_, total, err = r.contents.ListByUserID(1, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+	})
+}
+
+func TestMemoryUpdateAll(t *testing.T) {
+	devices := &MemoryDevices{}
+	device := &model.Device{MemobirdID: "a"}
+	assert.NoError(t, devices.Create(device))
+	err := devices.UpdateAll([]RowUpdate{
+		{ID: device.ID, Columns: Columns{"nickname": "kitchen"}},
+		{ID: device.ID, Columns: Columns{"user_id": "not a number"}},
+	})
+	assert.Error(t, err)
+	stored, err := devices.Get(device.ID)
+	if assert.NoError(t, err) {
+		// nothing is updated if any fails
+		assert.Empty(t, stored.Nickname)
+	}
+}
+
+func TestMemoryConcurrency(t *testing.T) {
+	users := &MemoryUsers{}
+	done := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			user := &model.User{TelegramID: int64(i)}
+			assert.NoError(t, users.Create(user))
+			assert.NoError(t, users.Update(user.ID, Columns{"language": "zh"}))
+		}(i)
+	}
+	for i := 0; i < 10; i++ {
+		<-done
+	}
+	list, err := users.List()
+	if assert.NoError(t, err) && assert.Len(t, list, 10) {
+		for i, user := range list {
+			assert.Equal(t, uint(i+1), user.ID)
+			assert.Equal(t, "zh", user.Language)
+		}
+	}
+}
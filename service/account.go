@@ -0,0 +1,103 @@
+package service
+
+import (
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// Account provides the data of a user as a whole, including rows soft-deleted.
+type Account struct {
+	DB *gorm.DB
+	// Keyring opens the texts, verification codes and webhook secrets sealed.
+	Keyring *envelope.Keyring
+}
+
+// accountRows selects the rows of a table stored about a user into rows.
+type accountRows struct {
+	rows  interface{}
+	where string
+	args  []interface{}
+}
+
+// rowsOf returns the rows created by the user other than the user itself into archive. If others, the rows
+// of other users on the devices of the user are included as well, e.g. shares with them. Rows referring to
+// others come first so that they can be deleted in order.
+func rowsOf(db *gorm.DB, userID uint, archive *model.Archive, others bool) []accountRows {
+	devices := db.Table("devices").Select("id").Where("user_id = ?", userID).QueryExpr()
+	ownedBy := func(column string) (string, []interface{}) {
+		if others {
+			return column + " = ? or device_id in (?)", []interface{}{userID, devices}
+		}
+		return column + " = ?", []interface{}{userID}
+	}
+	subsWhere, subsArgs := ownedBy("requester_id")
+	subs := db.Table("channel_subscriptions").Select("id").Where(subsWhere, subsArgs...).QueryExpr()
+	usersWhere, usersArgs := ownedBy("user_id")
+	lists := db.Table("todo_lists").Select("id").Where("user_id = ?", userID).QueryExpr()
+	drafts := db.Table("drafts").Select("id").Where("user_id = ?", userID).QueryExpr()
+	return []accountRows{
+		{&archive.ChannelPosts, "subscription_id in (?)", []interface{}{subs}},
+		{&archive.ChannelSubscriptions, "id in (?)", []interface{}{subs}},
+		{&archive.Shares, usersWhere, usersArgs},
+		{&archive.Webhooks, usersWhere, usersArgs},
+		{&archive.Usages, "user_id = ?", []interface{}{userID}},
+		{&archive.PrintAttempts, "user_id = ?", []interface{}{userID}},
+		{&archive.Contents, "user_id = ?", []interface{}{userID}},
+		{&archive.FeedSubscriptions, "user_id = ?", []interface{}{userID}},
+		{&archive.TodoItems, "list_id in (?)", []interface{}{lists}},
+		{&archive.TodoLists, "user_id = ?", []interface{}{userID}},
+		{&archive.DraftParts, "draft_id in (?)", []interface{}{drafts}},
+		{&archive.Drafts, "user_id = ?", []interface{}{userID}},
+		{&archive.Devices, "user_id = ?", []interface{}{userID}},
+	}
+}
+
+// Export returns everything the user created, rows of others on the devices of the user are not included.
+func (a *Account) Export(userID uint, now time.Time) (*model.Archive, error) {
+	db := a.DB.Unscoped()
+	archive := &model.Archive{ExportedAt: now}
+	if err := db.First(&archive.User, userID).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rowsOf(db, userID, archive, false) {
+		if err := db.Order("id").Where(r.where, r.args...).Find(r.rows).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := openTexts(a.Keyring, archive.Contents); err != nil {
+		return nil, err
+	}
+	if err := openDevices(a.Keyring, archive.Devices); err != nil {
+		return nil, err
+	}
+	for _, rows := range []interface{}{archive.DraftParts, archive.TodoItems, archive.ChannelPosts} {
+		if err := openTexts(a.Keyring, rows); err != nil {
+			return nil, err
+		}
+	}
+	if err := openWebhooks(a.Keyring, archive.Webhooks); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// Delete permanently deletes everything stored about the user. Devices of the user are unbound, and shares,
+// webhooks and channel subscriptions of them are revoked.
+func (a *Account) Delete(userID uint) error {
+	tx := a.DB.Begin()
+	db := tx.Unscoped()
+	for _, r := range rowsOf(db, userID, new(model.Archive), true) {
+		if err := db.Where(r.where, r.args...).Delete(r.rows).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := db.Where("id = ?", userID).Delete(&model.User{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
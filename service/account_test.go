@@ -0,0 +1,140 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestAccount(t *testing.T) {
+	withDB(t, func(db *gorm.DB) {
+		create := func(rows ...interface{}) {
+			for _, row := range rows {
+				assert.NoError(t, db.Create(row).Error)
+			}
+		}
+		alice := &model.User{TelegramID: 1}
+		bob := &model.User{TelegramID: 2}
+		create(alice, bob)
+		aliceBird := &model.Device{UserID: alice.ID, MemobirdID: "a"}
+		unbound := &model.Device{UserID: alice.ID, MemobirdID: "old"}
+		bobBird := &model.Device{UserID: bob.ID, MemobirdID: "b"}
+		create(aliceBird, unbound, bobBird)
+		assert.NoError(t, db.Delete(unbound).Error)
+
+		// created by alice
+		forgotten := &model.Content{UserID: alice.ID, DeviceID: aliceBird.ID, Text: "forgotten"}
+		aliceSub := &model.ChannelSubscription{RequesterID: alice.ID, DeviceID: bobBird.ID}
+		aliceList := &model.TodoList{UserID: alice.ID}
+		aliceDraft := &model.Draft{UserID: alice.ID}
+		create(
+			&model.Content{UserID: alice.ID, DeviceID: aliceBird.ID, Text: "hello"}, forgotten,
+			&model.Share{DeviceID: bobBird.ID, UserID: alice.ID},
+			&model.Webhook{UserID: alice.ID, DeviceID: aliceBird.ID, Secret: "a"},
+			&model.Usage{UserID: alice.ID, DeviceID: aliceBird.ID},
+			&model.PrintAttempt{UserID: alice.ID, DeviceID: aliceBird.ID},
+			&model.FeedSubscription{UserID: alice.ID, FeedID: 1},
+			aliceSub, aliceList, aliceDraft,
+		)
+		create(
+			&model.ChannelPost{SubscriptionID: aliceSub.ID, Text: "post"},
+			&model.TodoItem{ListID: aliceList.ID, Text: "milk"},
+			&model.DraftPart{DraftID: aliceDraft.ID, Text: "part"},
+		)
+		assert.NoError(t, db.Delete(forgotten).Error)
+
+		// created by bob on the device of alice
+		bobSub := &model.ChannelSubscription{RequesterID: bob.ID, DeviceID: aliceBird.ID}
+		create(
+			&model.Share{DeviceID: aliceBird.ID, UserID: bob.ID},
+			&model.Webhook{UserID: bob.ID, DeviceID: aliceBird.ID, Secret: "b on a"},
+			bobSub,
+		)
+		create(&model.ChannelPost{SubscriptionID: bobSub.ID, Text: "bob's post"})
+
+		// created by bob elsewhere
+		bobSubOfBird := &model.ChannelSubscription{RequesterID: bob.ID, DeviceID: bobBird.ID}
+		bobList := &model.TodoList{UserID: bob.ID}
+		bobDraft := &model.Draft{UserID: bob.ID}
+		create(
+			&model.Content{UserID: bob.ID, DeviceID: bobBird.ID, Text: "bob"},
+			&model.Webhook{UserID: bob.ID, DeviceID: bobBird.ID, Secret: "b"},
+			&model.Usage{UserID: bob.ID, DeviceID: bobBird.ID},
+			&model.PrintAttempt{UserID: bob.ID, DeviceID: bobBird.ID},
+			&model.FeedSubscription{UserID: bob.ID, FeedID: 1},
+			bobSubOfBird, bobList, bobDraft,
+		)
+		create(
+			&model.ChannelPost{SubscriptionID: bobSubOfBird.ID, Text: "bob's other post"},
+			&model.TodoItem{ListID: bobList.ID, Text: "eggs"},
+			&model.DraftPart{DraftID: bobDraft.ID, Text: "bob's part"},
+		)
+
+		account := &Account{DB: db}
+		now := time.Now()
+		archive, err := account.Export(alice.ID, now)
+		if assert.NoError(t, err) {
+			assert.Equal(t, alice.ID, archive.User.ID)
+			assert.Len(t, archive.Devices, 2)
+			if assert.Len(t, archive.Contents, 2) {
+				assert.Equal(t, "forgotten", archive.Contents[1].Text)
+				assert.NotNil(t, archive.Contents[1].DeletedAt)
+			}
+			for name, n := range map[string]int{
+				"shares":                len(archive.Shares),
+				"webhooks":              len(archive.Webhooks),
+				"usages":                len(archive.Usages),
+				"print attempts":        len(archive.PrintAttempts),
+				"feed subscriptions":    len(archive.FeedSubscriptions),
+				"channel subscriptions": len(archive.ChannelSubscriptions),
+				"channel posts":         len(archive.ChannelPosts),
+				"to-do lists":           len(archive.TodoLists),
+				"to-do items":           len(archive.TodoItems),
+				"drafts":                len(archive.Drafts),
+				"draft parts":           len(archive.DraftParts),
+			} {
+				assert.Equal(t, 1, n, "only the ones of alice are exported: %s", name)
+			}
+			assert.Equal(t, alice.ID, archive.Shares[0].UserID)
+			assert.Equal(t, "a", archive.Webhooks[0].Secret)
+			assert.Equal(t, "post", archive.ChannelPosts[0].Text)
+		}
+
+		assert.NoError(t, account.Delete(alice.ID))
+		_, err = account.Export(alice.ID, now)
+		assert.True(t, gorm.IsRecordNotFoundError(err))
+		remaining := func(value interface{}) int {
+			var count int
+			assert.NoError(t, db.Unscoped().Model(value).Count(&count).Error)
+			return count
+		}
+		for name, n := range map[string]int{
+			"users":                 remaining(&model.User{}),
+			"devices":               remaining(&model.Device{}),
+			"contents":              remaining(&model.Content{}),
+			"usages":                remaining(&model.Usage{}),
+			"print attempts":        remaining(&model.PrintAttempt{}),
+			"feed subscriptions":    remaining(&model.FeedSubscription{}),
+			"to-do lists":           remaining(&model.TodoList{}),
+			"to-do items":           remaining(&model.TodoItem{}),
+			"drafts":                remaining(&model.Draft{}),
+			"draft parts":           remaining(&model.DraftPart{}),
+			"webhooks":              remaining(&model.Webhook{}),
+			"channel subscriptions": remaining(&model.ChannelSubscription{}),
+			"channel posts":         remaining(&model.ChannelPost{}),
+		} {
+			assert.Equal(t, 1, n, "only the one of bob elsewhere is left: %s", name)
+		}
+		// the share with alice is revoked along with the one of her device
+		assert.Zero(t, remaining(&model.Share{}))
+		bobArchive, err := account.Export(bob.ID, now)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "b", bobArchive.Webhooks[0].Secret)
+			assert.Equal(t, "bob's other post", bobArchive.ChannelPosts[0].Text)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// PrintAttempt provides core functionalities of print attempts, which stats are counted from.
+type PrintAttempt struct {
+	DB *gorm.DB
+}
+
+// New records an attempt and drops the ones older than model.PrintAttemptWindow.
+func (p *PrintAttempt) New(attempt *model.PrintAttempt) error {
+	if err := p.DB.Create(attempt).Error; err != nil {
+		return err
+	}
+	before := time.Now().Add(-model.PrintAttemptWindow)
+	return p.DB.Unscoped().Where("created_at < ?", before).Delete(&model.PrintAttempt{}).Error
+}
+
+// ListFailedByUserID returns the latest attempts of the user that failed.
+func (p *PrintAttempt) ListFailedByUserID(userID uint, limit int) ([]model.PrintAttempt, error) {
+	var attempts []model.PrintAttempt
+	return attempts, p.DB.Order("id desc").Limit(limit).
+		Find(&attempts, "user_id = ? and error <> ''", userID).Error
+}
+
+// DailyStats returns counts of attempts per day from the day of since till today, days are in loc.
+func (p *PrintAttempt) DailyStats(since time.Time, loc *time.Location) ([]model.DailyPrintStat, error) {
+	since = startOfDay(since.In(loc))
+	var attempts []model.PrintAttempt
+	if err := p.DB.Select("created_at, error").Find(&attempts, "created_at >= ?", since).Error; err != nil {
+		return nil, err
+	}
+
+	var stats []model.DailyPrintStat
+	for day := since; day.Before(time.Now()); day = day.AddDate(0, 0, 1) {
+		stats = append(stats, model.DailyPrintStat{Day: day})
+	}
+	for _, attempt := range attempts {
+		day := startOfDay(attempt.CreatedAt.In(loc))
+		for i := range stats {
+			if stats[i].Day.Equal(day) {
+				stats[i].Total++
+				if attempt.Error != "" {
+					stats[i].Failed++
+				}
+				break
+			}
+		}
+	}
+	return stats, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+func TestPrintAttempt(t *testing.T) {
+	withDB(t, func(db *gorm.DB) {
+		attempts := &PrintAttempt{DB: db}
+		now := time.Now()
+		yesterday := now.AddDate(0, 0, -1)
+		expired := now.Add(-model.PrintAttemptWindow - time.Hour)
+		for _, a := range []*model.PrintAttempt{
+			{Model: gorm.Model{CreatedAt: expired}, UserID: 1, Error: "expired"},
+			{Model: gorm.Model{CreatedAt: yesterday}, UserID: 1, Error: "jammed"},
+			{Model: gorm.Model{CreatedAt: yesterday}, UserID: 2},
+			{UserID: 1},
+			{UserID: 1, Error: "offline"},
+		} {
+			assert.NoError(t, attempts.New(a))
+		}
+
+		failed, err := attempts.ListFailedByUserID(1, 5)
+		if assert.NoError(t, err) && assert.Len(t, failed, 2, "the expired one is pruned") {
+			assert.Equal(t, "offline", failed[0].Error)
+			assert.Equal(t, "jammed", failed[1].Error)
+		}
+
+		stats, err := attempts.DailyStats(yesterday, time.Local)
+		if assert.NoError(t, err) && assert.Len(t, stats, 2) {
+			assert.Equal(t, startOfDay(yesterday), stats[0].Day)
+			assert.Equal(t, 2, stats[0].Total)
+			assert.Equal(t, 1, stats[0].Failed)
+			assert.Equal(t, 2, stats[1].Total)
+			assert.Equal(t, 1, stats[1].Failed)
+		}
+
+		// deleting the history doesn't change the stats
+		assert.NoError(t, db.Unscoped().Delete(&model.Content{}).Error)
+		stats, err = attempts.DailyStats(now, time.Local)
+		if assert.NoError(t, err) && assert.Len(t, stats, 1) {
+			assert.Equal(t, 2, stats[0].Total)
+		}
+	})
+}
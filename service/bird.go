@@ -9,6 +9,7 @@ import (
 // BirdApp represents the ability to interact with a memobird.
 type BirdApp interface {
 	PrintText(text string, birdID string) (*memobird.PrintResult, error)
+	Print(doc *memobird.Document, birdID string) (*memobird.PrintResult, error)
 	BindDevice(deviceID string) (*memobird.BindResult, error)
 }
 
@@ -22,6 +23,11 @@ func (b *Bird) PrintTextToBird(birdID, text string) (*memobird.PrintResult, erro
 	return b.BirdApp.PrintText(text, birdID)
 }
 
+// PrintDocumentToBird sends given document to memobird of birdID for printing.
+func (b *Bird) PrintDocumentToBird(birdID string, doc *memobird.Document) (*memobird.PrintResult, error) {
+	return b.BirdApp.Print(doc, birdID)
+}
+
 // BindBirdWithMessage binds birdID and sends given message to bird.
 func (b *Bird) BindBirdWithMessage(birdID, msg string) (*memobird.PrintResult, error) {
 	result, err := b.BirdApp.BindDevice(birdID)
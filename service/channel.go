@@ -0,0 +1,133 @@
+package service
+
+import (
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// Channel provides core functionalities of printing channel posts.
+type Channel struct {
+	DB *gorm.DB
+	// Keyring seals the texts of posts stored, they are stored in plaintext if nil.
+	Keyring *envelope.Keyring
+}
+
+// New links the channel to the device, an existing subscription is returned untouched.
+func (c *Channel) New(sub *model.ChannelSubscription) (*model.ChannelSubscription, error) {
+	var existing model.ChannelSubscription
+	r := c.DB.Where(model.ChannelSubscription{ChannelID: sub.ChannelID, DeviceID: sub.DeviceID}).
+		Attrs(*sub).
+		FirstOrCreate(&existing)
+	return &existing, r.Error
+}
+
+// GetByID returns the subscription of id.
+func (c *Channel) GetByID(id uint) (*model.ChannelSubscription, error) {
+	var sub model.ChannelSubscription
+	return &sub, c.DB.First(&sub, id).Error
+}
+
+// ListApprovedByChannelID returns the approved subscriptions of the channel.
+func (c *Channel) ListApprovedByChannelID(channelID int64) ([]model.ChannelSubscription, error) {
+	var subs []model.ChannelSubscription
+	return subs, c.DB.Order("id").
+		Find(&subs, "channel_id = ? and approved_at is not null", channelID).Error
+}
+
+// ListByUserID returns the subscriptions requested by the user or on the devices of the user.
+func (c *Channel) ListByUserID(userID uint) ([]model.ChannelSubscription, error) {
+	var subs []model.ChannelSubscription
+	return subs, c.DB.Order("id").
+		Where("requester_id = ?", userID).
+		Or("device_id in (?)", c.DB.Table("devices").Select("id").Where("user_id = ? and deleted_at is null", userID).QueryExpr()).
+		Find(&subs).Error
+}
+
+// ListDigests returns the approved subscriptions printing in digests.
+func (c *Channel) ListDigests() ([]model.ChannelSubscription, error) {
+	var subs []model.ChannelSubscription
+	return subs, c.DB.Order("id").
+		Find(&subs, "digest_hours > 0 and approved_at is not null").Error
+}
+
+// Approve marks the subscription approved at now.
+func (c *Channel) Approve(id uint, now time.Time) error {
+	return c.DB.Model(&model.ChannelSubscription{}).Where("id = ?", id).Update("approved_at", now).Error
+}
+
+// Delete deletes the subscription with its pending posts.
+func (c *Channel) Delete(id uint) (bool, error) {
+	if err := c.DB.Unscoped().Where("subscription_id = ?", id).Delete(&model.ChannelPost{}).Error; err != nil {
+		return false, err
+	}
+	r := c.DB.Unscoped().Where("id = ?", id).Delete(&model.ChannelSubscription{})
+	return r.RowsAffected > 0, r.Error
+}
+
+// SetFilter updates which posts of the subscription are printed.
+func (c *Channel) SetFilter(id uint, hashtags string, includePhotos bool) error {
+	return c.DB.Model(&model.ChannelSubscription{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"hashtags":       hashtags,
+		"include_photos": includePhotos,
+	}).Error
+}
+
+// SetDigestHours updates the digest interval of the subscription, 0 disables the digest.
+func (c *Channel) SetDigestHours(id uint, hours int) error {
+	return c.DB.Model(&model.ChannelSubscription{}).Where("id = ?", id).Update("digest_hours", hours).Error
+}
+
+// SavePost queues the post for the digest, the pending one of the same message is replaced. The text is
+// sealed in the database if a keyring is configured.
+func (c *Channel) SavePost(post *model.ChannelPost) error {
+	var existing model.ChannelPost
+	r := c.DB.Where(model.ChannelPost{SubscriptionID: post.SubscriptionID, MessageID: post.MessageID}).
+		FirstOrInit(&existing)
+	if r.Error != nil {
+		return r.Error
+	}
+	existing.Text, existing.KeyID, existing.SealedText = post.Text, "", nil
+	existing.PostedAt = post.PostedAt
+	existing.PhotoFileID = post.PhotoFileID
+	existing.Edited = post.Edited
+	if err := sealText(c.Keyring, &existing); err != nil {
+		return err
+	}
+	if err := c.DB.Save(&existing).Error; err != nil {
+		return err
+	}
+	existing.Text, existing.SealedText = post.Text, nil
+	*post = existing
+	return nil
+}
+
+// ListPosts returns the posts waiting for the digest of the subscription.
+func (c *Channel) ListPosts(subscriptionID uint) ([]model.ChannelPost, error) {
+	var posts []model.ChannelPost
+	if err := c.DB.Order("message_id").Find(&posts, "subscription_id = ?", subscriptionID).Error; err != nil {
+		return nil, err
+	}
+	return posts, openTexts(c.Keyring, posts)
+}
+
+// Reseal seals up to limit texts of posts after afterID by the current key, see Content.Reseal.
+func (c *Channel) Reseal(afterID uint, limit int) (uint, int, error) {
+	return resealTexts(c.DB.Unscoped(), c.Keyring, "channel_posts", afterID, limit)
+}
+
+// FinishDigest records that the digest of the subscription is done at now, posts up to lastPostID are deleted.
+func (c *Channel) FinishDigest(id uint, lastPostID uint, now time.Time) error {
+	tx := c.DB.Begin()
+	if err := tx.Unscoped().Where("subscription_id = ? and id <= ?", id, lastPostID).Delete(&model.ChannelPost{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&model.ChannelSubscription{}).Where("id = ?", id).Update("last_digest_at", now).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
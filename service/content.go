@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/repository"
+)
+
+// Content provides core functionalities of content.
+type Content struct {
+	Repo repository.Contents
+	// Keyring seals the texts of contents stored, they are stored in plaintext if nil.
+	Keyring *envelope.Keyring
+}
+
+var errNoKeyring = errors.New("no encryption keys configured")
+
+// New creates a content, the text is sealed in the database if a keyring is configured.
+func (c *Content) New(content *model.Content) error {
+	text := content.Text
+	if err := sealText(c.Keyring, content); err != nil {
+		return err
+	}
+	err := c.Repo.Create(content)
+	content.Text, content.SealedText = text, nil
+	return err
+}
+
+// GetByIDAndUserID returns the content of given ID sent by the user.
+func (c *Content) GetByIDAndUserID(id, userID uint) (*model.Content, error) {
+	content, err := c.Repo.GetByIDAndUserID(id, userID)
+	if err != nil {
+		return content, err
+	}
+	return content, openText(c.Keyring, content)
+}
+
+// ListByUserID returns contents sent by the user from the newest, along with the total count.
+func (c *Content) ListByUserID(userID uint, offset, limit int) ([]model.Content, int, error) {
+	contents, total, err := c.Repo.ListByUserID(userID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("querying contents: %w", err)
+	}
+	return contents, total, openTexts(c.Keyring, contents)
+}
+
+// DeleteByIDAndUserID permanently deletes the content of given ID sent by the user.
+func (c *Content) DeleteByIDAndUserID(id, userID uint) (bool, error) {
+	return c.Repo.DeleteByIDAndUserID(id, userID)
+}
+
+// PruneByUserID permanently deletes contents sent by the user before given time.
+func (c *Content) PruneByUserID(userID uint, before time.Time) (int64, error) {
+	return c.Repo.DeleteByUserIDBefore(userID, before)
+}
+
+// Reseal seals up to limit contents after afterID by the current key in a transaction, including the ones
+// in plaintext or soft-deleted. The ID of the last one resealed is returned along with the number of them,
+// the ID is 0 if none is left.
+func (c *Content) Reseal(afterID uint, limit int) (uint, int, error) {
+	if c.Keyring == nil {
+		return 0, 0, errNoKeyring
+	}
+	contents, err := c.Repo.ListNotSealedBy(c.Keyring.Current(), afterID, limit)
+	if err != nil || len(contents) == 0 {
+		return 0, 0, err
+	}
+
+	updates := make([]repository.RowUpdate, len(contents))
+	for i := range contents {
+		content := &contents[i]
+		if err := openText(c.Keyring, content); err != nil {
+			return 0, 0, err
+		}
+		if err := sealText(c.Keyring, content); err != nil {
+			return 0, 0, err
+		}
+		updates[i] = repository.RowUpdate{ID: content.ID, Columns: repository.Columns{
+			"text":        content.Text,
+			"key_id":      content.KeyID,
+			"sealed_text": content.SealedText,
+		}}
+	}
+	if err := c.Repo.UpdateAll(updates); err != nil {
+		return 0, 0, err
+	}
+	return contents[len(contents)-1].ID, len(contents), nil
+}
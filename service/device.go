@@ -2,46 +2,164 @@ package service
 
 import (
 	"fmt"
+	"strconv"
 
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
 	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/repository"
 	"github.com/jinzhu/gorm"
 )
 
 // Device provides core functionalities of device.
 type Device struct {
-	DB *gorm.DB
+	Repo repository.Devices
+	// Keyring seals the verification codes stored, they are stored in plaintext if nil.
+	Keyring *envelope.Keyring
 }
 
 // IsFree returns true if given deviceID doesn't exist or not owned by other users.
 func (d *Device) IsFree(memobirdID string) (bool, error) {
-	var device model.Device
-	r := d.DB.First(&device, "memobird_id = ?", memobirdID)
-	if r.RecordNotFound() {
+	device, err := d.Repo.GetByMemobirdID(memobirdID)
+	if gorm.IsRecordNotFoundError(err) {
 		return true, nil
 	}
-	return !device.IsVerified(), r.Error
+	return !device.IsVerified(), err
 }
 
-// New creates a device with verification code generated.
+// New creates a device with verification code generated, the code is sealed in the database if a
+// keyring is configured.
 func (d *Device) New(device *model.Device) (*model.Device, error) {
-	device.GenerateVerificationCode()
-	return device, d.DB.Create(device).Error
+	code := device.GenerateVerificationCode().VerificationCode
+	if err := sealDevice(d.Keyring, device); err != nil {
+		return device, err
+	}
+	err := d.Repo.Create(device)
+	device.VerificationCode, device.SealedVerificationCode = code, nil
+	return device, err
 }
 
 // VerifyCodeByUserID checks if given verification code matches to the user.
 func (d *Device) VerifyCodeByUserID(code string, userID uint) (bool, error) {
-	var err error
-	r := d.DB.Model(&model.Device{}).
-		Where("user_id = ? and verification_code = ?", userID, code).
-		Update("verification_code", model.DeviceVerified)
-	if r.Error != nil {
-		err = fmt.Errorf("updating device: %w", r.Error)
+	// sealed codes can't be compared in the database
+	devices, err := d.Repo.ListByUserID(userID)
+	if err != nil {
+		return false, fmt.Errorf("querying devices: %w", err)
+	}
+	if err := openDevices(d.Keyring, devices); err != nil {
+		return false, err
+	}
+	var matched []uint
+	for _, device := range devices {
+		if device.VerificationCode != model.DeviceVerified && strconv.FormatInt(device.VerificationCode, 10) == code {
+			matched = append(matched, device.ID)
+		}
 	}
-	return r.RowsAffected == 1, err
+	if len(matched) != 1 {
+		return false, nil
+	}
+
+	// the device may be verified or deleted meanwhile
+	verified, err := d.Repo.Verify(matched[0], userID)
+	if err != nil {
+		return false, fmt.Errorf("updating device: %w", err)
+	}
+	return verified, nil
 }
 
 // GetByUserID returns Device with given userID.
 func (d *Device) GetByUserID(userID uint) (*model.Device, error) {
-	var device model.Device
-	return &device, d.DB.First(&device, "user_id = ?", userID).Error
+	device, err := d.Repo.GetByUserID(userID)
+	if err != nil {
+		return device, err
+	}
+	return device, openDevice(d.Keyring, device)
+}
+
+// GetByID returns Device with given ID.
+func (d *Device) GetByID(id uint) (*model.Device, error) {
+	device, err := d.Repo.Get(id)
+	if err != nil {
+		return device, err
+	}
+	return device, openDevice(d.Keyring, device)
+}
+
+// SetQuota updates the quota of device.
+func (d *Device) SetQuota(deviceID uint, quota model.Quota) error {
+	return d.Repo.Update(deviceID, quotaColumns(quota))
+}
+
+// SetNickname updates the nickname of device.
+func (d *Device) SetNickname(deviceID uint, nickname string) error {
+	return d.Repo.Update(deviceID, repository.Columns{"nickname": nickname})
+}
+
+// SetTimezone updates the time zone of device.
+func (d *Device) SetTimezone(deviceID uint, timezone string) error {
+	return d.Repo.Update(deviceID, repository.Columns{"timezone": timezone})
+}
+
+// SetPrintTemplate updates the print template of device.
+func (d *Device) SetPrintTemplate(deviceID uint, tmpl string) error {
+	return d.Repo.Update(deviceID, repository.Columns{"print_template": tmpl})
+}
+
+// List returns all devices.
+func (d *Device) List() ([]model.Device, error) {
+	devices, err := d.Repo.List()
+	if err != nil {
+		return nil, err
+	}
+	return devices, openDevices(d.Keyring, devices)
+}
+
+// ListByUserID returns all devices of the user.
+func (d *Device) ListByUserID(userID uint) ([]model.Device, error) {
+	devices, err := d.Repo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return devices, openDevices(d.Keyring, devices)
+}
+
+// CountVerified returns the number of verified devices.
+func (d *Device) CountVerified() (int, error) {
+	return d.Repo.CountVerified()
+}
+
+// Reseal seals up to limit verification codes of devices after afterID by the current key in a
+// transaction, including the ones in plaintext or soft-deleted. The ID of the last device scanned is
+// returned along with the number resealed, the ID is 0 if none is left.
+func (d *Device) Reseal(afterID uint, limit int) (uint, int, error) {
+	if d.Keyring == nil {
+		return 0, 0, errNoKeyring
+	}
+	devices, err := d.Repo.ListNotSealedBy(d.Keyring.Current(), afterID, limit)
+	if err != nil || len(devices) == 0 {
+		return 0, 0, err
+	}
+
+	var updates []repository.RowUpdate
+	for i := range devices {
+		device := &devices[i]
+		// verified devices have no codes to seal
+		if device.VerificationCode == model.DeviceVerified {
+			continue
+		}
+		if err := openDevice(d.Keyring, device); err != nil {
+			return 0, 0, err
+		}
+		if err := sealDevice(d.Keyring, device); err != nil {
+			return 0, 0, err
+		}
+		updates = append(updates, repository.RowUpdate{ID: device.ID, Columns: repository.Columns{
+			"verification_code":        device.VerificationCode,
+			"key_id":                   device.KeyID,
+			"sealed_verification_code": device.SealedVerificationCode,
+		}})
+	}
+	if err := d.Repo.UpdateAll(updates); err != nil {
+		return 0, 0, err
+	}
+	return devices[len(devices)-1].ID, len(updates), nil
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// Draft provides core functionalities of drafts.
+type Draft struct {
+	DB *gorm.DB
+	// Keyring seals the texts of parts stored, they are stored in plaintext if nil.
+	Keyring *envelope.Keyring
+}
+
+// GetByUserID returns the draft of the user.
+func (d *Draft) GetByUserID(userID uint) (*model.Draft, error) {
+	var draft model.Draft
+	return &draft, d.DB.First(&draft, "user_id = ?", userID).Error
+}
+
+// GetOrNew returns the draft of the user, which is created if not found.
+func (d *Draft) GetOrNew(userID uint) (*model.Draft, error) {
+	var draft model.Draft
+	return &draft, d.DB.Where(model.Draft{UserID: userID}).FirstOrCreate(&draft).Error
+}
+
+// AddPart adds part to its draft, which is no longer idle. The text is sealed in the database if a keyring
+// is configured.
+func (d *Draft) AddPart(part *model.DraftPart) error {
+	text := part.Text
+	if err := sealText(d.Keyring, part); err != nil {
+		return err
+	}
+	tx := d.DB.Begin()
+	err := tx.Create(part).Error
+	part.Text, part.SealedText = text, nil
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&model.Draft{}).Where("id = ?", part.DraftID).Update("updated_at", time.Now()).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// CountParts returns the number of parts in the draft.
+func (d *Draft) CountParts(draftID uint) (int, error) {
+	var count int
+	return count, d.DB.Model(&model.DraftPart{}).Where("draft_id = ?", draftID).Count(&count).Error
+}
+
+// ListParts returns the parts of the draft in the order added.
+func (d *Draft) ListParts(draftID uint) ([]model.DraftPart, error) {
+	var parts []model.DraftPart
+	if err := d.DB.Order("id").Find(&parts, "draft_id = ?", draftID).Error; err != nil {
+		return nil, err
+	}
+	return parts, openTexts(d.Keyring, parts)
+}
+
+// Reseal seals up to limit texts of parts after afterID by the current key, see Content.Reseal.
+func (d *Draft) Reseal(afterID uint, limit int) (uint, int, error) {
+	return resealTexts(d.DB.Unscoped(), d.Keyring, "draft_parts", afterID, limit)
+}
+
+// ListIdle returns the drafts not added to since before.
+func (d *Draft) ListIdle(before time.Time) ([]model.Draft, error) {
+	var drafts []model.Draft
+	return drafts, d.DB.Order("id").Find(&drafts, "updated_at < ?", before).Error
+}
+
+// Delete deletes the draft with its parts.
+func (d *Draft) Delete(draftID uint) error {
+	tx := d.DB.Begin()
+	if err := tx.Unscoped().Where("draft_id = ?", draftID).Delete(&model.DraftPart{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Unscoped().Where("id = ?", draftID).Delete(&model.Draft{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
@@ -4,3 +4,6 @@ import "github.com/jinzhu/gorm"
 
 // IsRecordNotFoundError returns true if the given error is caused by a missing record.
 var IsRecordNotFoundError = gorm.IsRecordNotFoundError
+
+// ErrRecordNotFound is returned when no record is found.
+var ErrRecordNotFound = gorm.ErrRecordNotFound
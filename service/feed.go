@@ -0,0 +1,142 @@
+package service
+
+import (
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// Feed provides core functionalities of feed subscriptions.
+type Feed struct {
+	DB *gorm.DB
+}
+
+// GetOrNew returns the feed of url, which is created if not found.
+func (f *Feed) GetOrNew(url string) (*model.Feed, error) {
+	var feed model.Feed
+	return &feed, f.DB.Where(model.Feed{URL: url}).FirstOrCreate(&feed).Error
+}
+
+// GetByID returns the feed of id.
+func (f *Feed) GetByID(id uint) (*model.Feed, error) {
+	var feed model.Feed
+	return &feed, f.DB.First(&feed, id).Error
+}
+
+// ListDue returns the subscribed feeds that are not checked since before.
+func (f *Feed) ListDue(before time.Time) ([]model.Feed, error) {
+	var feeds []model.Feed
+	return feeds, f.DB.Order("checked_at").
+		Where("checked_at is null or checked_at < ?", before).
+		Where("id in (?)", f.DB.Model(&model.FeedSubscription{}).Select("feed_id").QueryExpr()).
+		Find(&feeds).Error
+}
+
+// SaveChecked saves the outcome of checking the feed.
+func (f *Feed) SaveChecked(feed *model.Feed) error {
+	return f.DB.Model(feed).Updates(map[string]interface{}{
+		"title":         feed.Title,
+		"e_tag":         feed.ETag,
+		"last_modified": feed.LastModified,
+		"checked_at":    feed.CheckedAt,
+		"error":         feed.Error,
+	}).Error
+}
+
+// AddItems saves items of the feed that are not seen before, the number of new ones is returned.
+func (f *Feed) AddItems(feedID uint, items []model.FeedItem) (int, error) {
+	var added int
+	for _, item := range items {
+		var count int
+		if err := f.DB.Model(&model.FeedItem{}).Where("feed_id = ? and guid = ?", feedID, item.GUID).Count(&count).Error; err != nil {
+			return added, err
+		}
+		if count > 0 {
+			continue
+		}
+		item.FeedID = feedID
+		if err := f.DB.Create(&item).Error; err != nil {
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+// LastItemID returns the ID of the latest item of the feed, 0 if none.
+func (f *Feed) LastItemID(feedID uint) (uint, error) {
+	var item model.FeedItem
+	err := f.DB.Order("id desc").First(&item, "feed_id = ?", feedID).Error
+	if IsRecordNotFoundError(err) {
+		return 0, nil
+	}
+	return item.ID, err
+}
+
+// ListItemsAfter returns at most limit latest items of the feed after the item of afterID, in the order seen.
+func (f *Feed) ListItemsAfter(feedID, afterID uint, limit int) ([]model.FeedItem, error) {
+	var items []model.FeedItem
+	if err := f.DB.Order("id desc").Limit(limit).Find(&items, "feed_id = ? and id > ?", feedID, afterID).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return items, nil
+}
+
+// Subscribe subscribes the user to the feed, the digest time of an existing subscription is updated.
+func (f *Feed) Subscribe(sub *model.FeedSubscription) (*model.FeedSubscription, error) {
+	var existing model.FeedSubscription
+	r := f.DB.Where(model.FeedSubscription{FeedID: sub.FeedID, UserID: sub.UserID}).Attrs(*sub).FirstOrCreate(&existing)
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	if existing.DigestTime != sub.DigestTime {
+		existing.DigestTime = sub.DigestTime
+		if err := f.DB.Model(&existing).Update("digest_time", sub.DigestTime).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &existing, nil
+}
+
+// CountSubscriptionsByUserID returns the number of feeds the user subscribes to.
+func (f *Feed) CountSubscriptionsByUserID(userID uint) (int, error) {
+	var count int
+	return count, f.DB.Model(&model.FeedSubscription{}).Where("user_id = ?", userID).Count(&count).Error
+}
+
+// ListSubscriptionsByUserID returns the subscriptions of the user.
+func (f *Feed) ListSubscriptionsByUserID(userID uint) ([]model.FeedSubscription, error) {
+	var subs []model.FeedSubscription
+	return subs, f.DB.Order("id").Find(&subs, "user_id = ?", userID).Error
+}
+
+// ListSubscriptionsByFeedID returns the subscriptions of the feed.
+func (f *Feed) ListSubscriptionsByFeedID(feedID uint) ([]model.FeedSubscription, error) {
+	var subs []model.FeedSubscription
+	return subs, f.DB.Order("id").Find(&subs, "feed_id = ?", feedID).Error
+}
+
+// ListDigestSubscriptions returns the subscriptions printing in daily digests.
+func (f *Feed) ListDigestSubscriptions() ([]model.FeedSubscription, error) {
+	var subs []model.FeedSubscription
+	return subs, f.DB.Order("id").Find(&subs, "digest_time <> ''").Error
+}
+
+// DeleteSubscription unsubscribes the user.
+func (f *Feed) DeleteSubscription(id, userID uint) (bool, error) {
+	r := f.DB.Unscoped().Where("id = ? and user_id = ?", id, userID).Delete(&model.FeedSubscription{})
+	return r.RowsAffected > 0, r.Error
+}
+
+// MarkPrinted records the items printed to the subscription up to lastItemID, digestAt is set for digests.
+func (f *Feed) MarkPrinted(id, lastItemID uint, digestAt *time.Time) error {
+	columns := map[string]interface{}{"last_item_id": lastItemID}
+	if digestAt != nil {
+		columns["last_digest_at"] = digestAt
+	}
+	return f.DB.Model(&model.FeedSubscription{}).Where("id = ?", id).Updates(columns).Error
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// openPayload decrypts the payload sealed by keyID with keyring.
+func openPayload(keyring *envelope.Keyring, keyID string, sealed []byte) ([]byte, error) {
+	if keyring == nil {
+		return nil, fmt.Errorf("sealed by key %q while no keys are configured", keyID)
+	}
+	return keyring.Open(keyID, sealed)
+}
+
+// sealedText is a text which may be sealed, the fields point to the ones of a row.
+type sealedText struct {
+	// name names the row in errors, e.g. "content[1]".
+	name   string
+	text   *string
+	keyID  *string
+	sealed *[]byte
+}
+
+// textOf returns the text of row which may be sealed, row is a pointer to a model.
+func textOf(row interface{}) sealedText {
+	switch r := row.(type) {
+	case *model.Content:
+		return sealedText{fmt.Sprintf("content[%d]", r.ID), &r.Text, &r.KeyID, &r.SealedText}
+	case *model.DraftPart:
+		return sealedText{fmt.Sprintf("draft part[%d]", r.ID), &r.Text, &r.KeyID, &r.SealedText}
+	case *model.TodoItem:
+		return sealedText{fmt.Sprintf("to-do item[%d]", r.ID), &r.Text, &r.KeyID, &r.SealedText}
+	case *model.ChannelPost:
+		return sealedText{fmt.Sprintf("channel post[%d]", r.ID), &r.Text, &r.KeyID, &r.SealedText}
+	case *textRow:
+		return sealedText{fmt.Sprintf("row[%d]", r.ID), &r.Text, &r.KeyID, &r.SealedText}
+	}
+	panic(fmt.Sprintf("no sealed text in %T", row))
+}
+
+// sealText moves the text of row into SealedText encrypted by keyring, it's kept in plaintext if keyring
+// is nil.
+func sealText(keyring *envelope.Keyring, row interface{}) error {
+	if keyring == nil {
+		return nil
+	}
+	t := textOf(row)
+	keyID, sealed, err := keyring.Seal([]byte(*t.text))
+	if err != nil {
+		return fmt.Errorf("sealing %s: %w", t.name, err)
+	}
+	*t.text, *t.keyID, *t.sealed = "", keyID, sealed
+	return nil
+}
+
+// openText decrypts SealedText of row into Text if sealed.
+func openText(keyring *envelope.Keyring, row interface{}) error {
+	t := textOf(row)
+	if *t.keyID == "" {
+		return nil
+	}
+	text, err := openPayload(keyring, *t.keyID, *t.sealed)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", t.name, err)
+	}
+	*t.text, *t.sealed = string(text), nil
+	return nil
+}
+
+// openTexts decrypts the texts sealed of rows, which is a slice of models.
+func openTexts(keyring *envelope.Keyring, rows interface{}) error {
+	v := reflect.ValueOf(rows)
+	for i := 0; i < v.Len(); i++ {
+		if err := openText(keyring, v.Index(i).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textRow is a row of any table of which text may be sealed.
+type textRow struct {
+	ID         uint
+	Text       string
+	KeyID      string
+	SealedText []byte
+}
+
+// resealTexts seals up to limit texts of table after afterID by the current key of keyring in a
+// transaction, including the ones in plaintext or soft-deleted. The ID of the last row resealed is
+// returned along with the number of them, the ID is 0 if none is left.
+func resealTexts(db *gorm.DB, keyring *envelope.Keyring, table string, afterID uint, limit int) (uint, int, error) {
+	if keyring == nil {
+		return 0, 0, errNoKeyring
+	}
+	var rows []textRow
+	err := db.Table(table).Select("id, text, key_id, sealed_text").
+		Where("id > ? and (key_id is null or key_id <> ?)", afterID, keyring.Current()).
+		Order("id").Limit(limit).Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return 0, 0, err
+	}
+
+	tx := db.Begin()
+	for i := range rows {
+		row := &rows[i]
+		err := openText(keyring, row)
+		if err == nil {
+			err = sealText(keyring, row)
+		}
+		if err == nil {
+			err = tx.Table(table).Where("id = ?", row.ID).UpdateColumns(map[string]interface{}{
+				"text":        row.Text,
+				"key_id":      row.KeyID,
+				"sealed_text": row.SealedText,
+			}).Error
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, 0, err
+		}
+	}
+	return rows[len(rows)-1].ID, len(rows), tx.Commit().Error
+}
+
+// sealDevice moves the verification code of device into SealedVerificationCode encrypted by keyring, it's
+// kept in plaintext if keyring is nil or the device is verified.
+func sealDevice(keyring *envelope.Keyring, device *model.Device) error {
+	if keyring == nil || device.VerificationCode == model.DeviceVerified {
+		return nil
+	}
+	keyID, sealed, err := keyring.Seal([]byte(strconv.FormatInt(device.VerificationCode, 10)))
+	if err != nil {
+		return fmt.Errorf("sealing device: %w", err)
+	}
+	device.VerificationCode, device.KeyID, device.SealedVerificationCode = model.DeviceUnverified, keyID, sealed
+	return nil
+}
+
+// openDevice decrypts SealedVerificationCode of device into VerificationCode if sealed.
+func openDevice(keyring *envelope.Keyring, device *model.Device) error {
+	if device.KeyID == "" {
+		return nil
+	}
+	code, err := openPayload(keyring, device.KeyID, device.SealedVerificationCode)
+	if err != nil {
+		return fmt.Errorf("opening device[%d]: %w", device.ID, err)
+	}
+	device.VerificationCode, err = strconv.ParseInt(string(code), 10, 64)
+	if err != nil {
+		return fmt.Errorf("opening device[%d]: %w", device.ID, err)
+	}
+	device.SealedVerificationCode = nil
+	return nil
+}
+
+// openDevices decrypts the verification codes of devices sealed.
+func openDevices(keyring *envelope.Keyring, devices []model.Device) error {
+	for i := range devices {
+		if err := openDevice(keyring, &devices[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secretHash returns what the webhook of secret is found by while sealed.
+func secretHash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// sealWebhook moves the secret and the signing secret of webhook into SealedSecret encrypted by keyring,
+// separated by a newline, and replaces the secret with its hash. They are kept in plaintext if keyring is nil.
+func sealWebhook(keyring *envelope.Keyring, webhook *model.Webhook) error {
+	if keyring == nil {
+		return nil
+	}
+	keyID, sealed, err := keyring.Seal([]byte(webhook.Secret + "\n" + webhook.SigningSecret))
+	if err != nil {
+		return fmt.Errorf("sealing webhook: %w", err)
+	}
+	webhook.Secret, webhook.KeyID, webhook.SealedSecret = secretHash(webhook.Secret), keyID, sealed
+	webhook.SigningSecret = ""
+	return nil
+}
+
+// openWebhook decrypts SealedSecret of webhook into Secret and SigningSecret if sealed.
+func openWebhook(keyring *envelope.Keyring, webhook *model.Webhook) error {
+	if webhook.KeyID == "" {
+		return nil
+	}
+	secrets, err := openPayload(keyring, webhook.KeyID, webhook.SealedSecret)
+	if err != nil {
+		return fmt.Errorf("opening webhook[%d]: %w", webhook.ID, err)
+	}
+	// ones sealed before signing secrets have the secret only
+	parts := strings.SplitN(string(secrets), "\n", 2)
+	webhook.Secret, webhook.SealedSecret = parts[0], nil
+	if len(parts) == 2 {
+		webhook.SigningSecret = parts[1]
+	}
+	return nil
+}
+
+// openWebhooks decrypts the secrets of webhooks sealed.
+func openWebhooks(keyring *envelope.Keyring, webhooks []model.Webhook) error {
+	for i := range webhooks {
+		if err := openWebhook(keyring, &webhooks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
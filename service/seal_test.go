@@ -0,0 +1,223 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/repository"
+)
+
+var (
+	oldKey = make([]byte, 32)
+	newKey = append(make([]byte, 31), 1)
+)
+
+func keyring(t *testing.T, current string, keys map[string][]byte) *envelope.Keyring {
+	k, err := envelope.NewKeyring(current, keys)
+	assert.NoError(t, err)
+	return k
+}
+
+func TestContentSealing(t *testing.T) {
+	withDB(t, func(db *gorm.DB) {
+		contents := &Content{Repo: &repository.GormContents{DB: db}}
+		plain := &model.Content{UserID: 1, Text: "in plaintext"}
+		assert.NoError(t, contents.New(plain))
+
+		contents.Keyring = keyring(t, "old", map[string][]byte{"old": oldKey})
+		sealed := &model.Content{UserID: 1, Text: "sealed"}
+		assert.NoError(t, contents.New(sealed))
+		assert.Equal(t, "sealed", sealed.Text)
+		var stored model.Content
+		assert.NoError(t, db.First(&stored, sealed.ID).Error)
+		assert.Empty(t, stored.Text)
+		assert.Equal(t, "old", stored.KeyID)
+		assert.NotContains(t, string(stored.SealedText), "sealed")
+		content, err := contents.GetByIDAndUserID(sealed.ID, 1)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "sealed", content.Text)
+		}
+
+		// rotate with the old key still present
+		contents.Keyring = keyring(t, "new", map[string][]byte{"old": oldKey, "new": newKey})
+		assert.NoError(t, contents.New(&model.Content{UserID: 1, Text: "by the new key"}))
+		list, _, err := contents.ListByUserID(1, 0, 10)
+		if assert.NoError(t, err) && assert.Len(t, list, 3) {
+			assert.Equal(t, "by the new key", list[0].Text)
+			assert.Equal(t, "sealed", list[1].Text)
+			assert.Equal(t, "in plaintext", list[2].Text)
+		}
+		lastID, n, err := contents.Reseal(0, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, plain.ID, lastID)
+		assert.Equal(t, 1, n)
+		lastID, n, err = contents.Reseal(lastID, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, sealed.ID, lastID)
+		assert.Equal(t, 1, n)
+		lastID, _, err = contents.Reseal(lastID, 5)
+		assert.NoError(t, err)
+		assert.Zero(t, lastID)
+
+		// readable without the old key once resealed
+		contents.Keyring = keyring(t, "new", map[string][]byte{"new": newKey})
+		list, _, err = contents.ListByUserID(1, 0, 10)
+		if assert.NoError(t, err) && assert.Len(t, list, 3) {
+			assert.Equal(t, "sealed", list[1].Text)
+			assert.Equal(t, "in plaintext", list[2].Text)
+		}
+		var resealed model.Content
+		assert.NoError(t, db.First(&resealed, plain.ID).Error)
+		assert.Empty(t, resealed.Text)
+		assert.Equal(t, "new", resealed.KeyID)
+	})
+}
+
+func TestSealingMissingKey(t *testing.T) {
+	withDB(t, func(db *gorm.DB) {
+		old := keyring(t, "old", map[string][]byte{"old": oldKey})
+		contents := &Content{Repo: &repository.GormContents{DB: db}, Keyring: old}
+		content := &model.Content{UserID: 1, Text: "sealed"}
+		assert.NoError(t, contents.New(content))
+		devices := &Device{Repo: &repository.GormDevices{DB: db}, Keyring: old}
+		device, err := devices.New(&model.Device{UserID: 1, MemobirdID: "bird"})
+		assert.NoError(t, err)
+		todos := &Todo{DB: db, Keyring: old}
+		_, err = todos.AddItem(1, "milk")
+		assert.NoError(t, err)
+
+		for name, k := range map[string]*envelope.Keyring{
+			"retired": keyring(t, "new", map[string][]byte{"new": newKey}),
+			"none":    nil,
+		} {
+			contents.Keyring, devices.Keyring, todos.Keyring = k, k, k
+			_, err := contents.GetByIDAndUserID(content.ID, 1)
+			assert.Error(t, err, name)
+			_, _, err = contents.ListByUserID(1, 0, 10)
+			assert.Error(t, err, name)
+			_, err = devices.GetByID(device.ID)
+			assert.Error(t, err, name)
+			_, err = devices.VerifyCodeByUserID(strconv.FormatInt(device.VerificationCode, 10), 1)
+			assert.Error(t, err, name)
+			_, err = todos.ListItems(1)
+			assert.Error(t, err, name)
+		}
+		// not resealed either
+		contents.Keyring = keyring(t, "new", map[string][]byte{"new": newKey})
+		_, _, err = contents.Reseal(0, 10)
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifySealedCode(t *testing.T) {
+	withDB(t, func(db *gorm.DB) {
+		devices := &Device{
+			Repo:    &repository.GormDevices{DB: db},
+			Keyring: keyring(t, "old", map[string][]byte{"old": oldKey}),
+		}
+		device, err := devices.New(&model.Device{UserID: 1, MemobirdID: "bird"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		code := strconv.FormatInt(device.VerificationCode, 10)
+		var stored model.Device
+		assert.NoError(t, db.First(&stored, device.ID).Error)
+		assert.Equal(t, model.DeviceUnverified, stored.VerificationCode)
+		assert.NotContains(t, string(stored.SealedVerificationCode), code)
+
+		// the code survives rotation
+		devices.Keyring = keyring(t, "new", map[string][]byte{"old": oldKey, "new": newKey})
+		_, n, err := devices.Reseal(0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		devices.Keyring = keyring(t, "new", map[string][]byte{"new": newKey})
+
+		verified, err := devices.VerifyCodeByUserID(code, 2)
+		assert.NoError(t, err)
+		assert.False(t, verified, "another user")
+		verified, err = devices.VerifyCodeByUserID("0", 1)
+		assert.NoError(t, err)
+		assert.False(t, verified, "wrong code")
+		verified, err = devices.VerifyCodeByUserID(code, 1)
+		assert.NoError(t, err)
+		assert.True(t, verified)
+		verified, err = devices.VerifyCodeByUserID(code, 1)
+		assert.NoError(t, err)
+		assert.False(t, verified, "verified already")
+
+		var verifiedDevice model.Device
+		assert.NoError(t, db.First(&verifiedDevice, device.ID).Error)
+		assert.True(t, verifiedDevice.IsVerified())
+		assert.Empty(t, verifiedDevice.KeyID)
+		assert.Empty(t, verifiedDevice.SealedVerificationCode)
+		// verified devices are left alone
+		_, n, err = devices.Reseal(0, 10)
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func TestTextsSealing(t *testing.T) {
+	withDB(t, func(db *gorm.DB) {
+		old := keyring(t, "old", map[string][]byte{"old": oldKey})
+		drafts := &Draft{DB: db}
+		assert.NoError(t, drafts.AddPart(&model.DraftPart{DraftID: 1, Text: "in plaintext"}))
+		drafts.Keyring = old
+		assert.NoError(t, drafts.AddPart(&model.DraftPart{DraftID: 1, Text: "sealed"}))
+		channels := &Channel{DB: db, Keyring: old}
+		post := &model.ChannelPost{SubscriptionID: 1, MessageID: 7, Text: "posted"}
+		assert.NoError(t, channels.SavePost(post))
+		assert.Equal(t, "posted", post.Text)
+		assert.NoError(t, channels.SavePost(&model.ChannelPost{SubscriptionID: 1, MessageID: 7, Text: "edited"}))
+		webhooks := &Webhook{DB: db}
+		plainHook, err := webhooks.New(&model.Webhook{UserID: 1})
+		assert.NoError(t, err)
+		webhooks.Keyring = old
+		sealedHook, err := webhooks.New(&model.Webhook{UserID: 1})
+		assert.NoError(t, err)
+		var stored model.Webhook
+		assert.NoError(t, db.First(&stored, sealedHook.ID).Error)
+		assert.NotEqual(t, sealedHook.Secret, stored.Secret)
+		assert.NotEmpty(t, sealedHook.SigningSecret)
+		assert.Empty(t, stored.SigningSecret, "the signing secret is sealed along")
+
+		rotated := keyring(t, "new", map[string][]byte{"old": oldKey, "new": newKey})
+		drafts.Keyring, channels.Keyring, webhooks.Keyring = rotated, rotated, rotated
+		for name, reseal := range map[string]func(uint, int) (uint, int, error){
+			"draft parts":   drafts.Reseal,
+			"channel posts": channels.Reseal,
+			"webhooks":      webhooks.Reseal,
+		} {
+			_, n, err := reseal(0, 10)
+			assert.NoError(t, err, name)
+			assert.NotZero(t, n, name)
+		}
+
+		retired := keyring(t, "new", map[string][]byte{"new": newKey})
+		drafts.Keyring, channels.Keyring, webhooks.Keyring = retired, retired, retired
+		parts, err := drafts.ListParts(1)
+		if assert.NoError(t, err) && assert.Len(t, parts, 2) {
+			assert.Equal(t, "in plaintext", parts[0].Text)
+			assert.Equal(t, "sealed", parts[1].Text)
+		}
+		posts, err := channels.ListPosts(1)
+		if assert.NoError(t, err) && assert.Len(t, posts, 1) {
+			assert.Equal(t, "edited", posts[0].Text)
+		}
+		for _, hook := range []*model.Webhook{plainHook, sealedHook} {
+			found, err := webhooks.GetBySecret(hook.Secret)
+			if assert.NoError(t, err) {
+				assert.Equal(t, hook.ID, found.ID)
+				assert.Equal(t, hook.Secret, found.Secret)
+				assert.Equal(t, hook.SigningSecret, found.SigningSecret)
+			}
+		}
+		_, err = webhooks.GetBySecret(stored.Secret)
+		assert.True(t, gorm.IsRecordNotFoundError(err), "the hash is not a secret")
+	})
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+)
+
+// withDB runs test with a sqlite database of every table migrated, which is removed afterwards.
+func withDB(t *testing.T, test func(db *gorm.DB)) {
+	dir, err := ioutil.TempDir("", "service")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	db, err := gorm.Open("sqlite3", filepath.Join(dir, "test.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+	db.LogMode(false)
+	err = db.AutoMigrate(&model.User{}, &model.Device{}, &model.Share{}, &model.Content{}, &model.Usage{},
+		&model.PrintAttempt{}, &model.Webhook{}, &model.ChannelSubscription{}, &model.ChannelPost{}, &model.FeedSubscription{},
+		&model.TodoList{}, &model.TodoItem{}, &model.Draft{}, &model.DraftPart{}).Error
+	if assert.NoError(t, err) {
+		test(db)
+	}
+}
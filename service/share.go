@@ -0,0 +1,57 @@
+package service
+
+import (
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// Share provides core functionalities of device sharing.
+type Share struct {
+	DB *gorm.DB
+}
+
+// New shares the device with the user, an existing share is kept untouched.
+func (s *Share) New(deviceID, userID uint) (*model.Share, error) {
+	var share model.Share
+	r := s.DB.Where(model.Share{DeviceID: deviceID, UserID: userID}).FirstOrCreate(&share)
+	return &share, r.Error
+}
+
+// Get returns the share of device with user.
+func (s *Share) Get(deviceID, userID uint) (*model.Share, error) {
+	var share model.Share
+	return &share, s.DB.First(&share, "device_id = ? and user_id = ?", deviceID, userID).Error
+}
+
+// ListByDeviceID returns all shares of the device.
+func (s *Share) ListByDeviceID(deviceID uint) ([]model.Share, error) {
+	var shares []model.Share
+	return shares, s.DB.Order("id").Find(&shares, "device_id = ?", deviceID).Error
+}
+
+// ListByUserID returns all shares with the user.
+func (s *Share) ListByUserID(userID uint) ([]model.Share, error) {
+	var shares []model.Share
+	return shares, s.DB.Order("id").Find(&shares, "user_id = ?", userID).Error
+}
+
+// Delete stops sharing the device with user.
+func (s *Share) Delete(deviceID, userID uint) (bool, error) {
+	r := s.DB.Unscoped().Where("device_id = ? and user_id = ?", deviceID, userID).Delete(&model.Share{})
+	return r.RowsAffected > 0, r.Error
+}
+
+// SetQuota updates the quota of user on the shared device.
+func (s *Share) SetQuota(deviceID, userID uint, quota model.Quota) (bool, error) {
+	r := s.DB.Model(&model.Share{}).
+		Where("device_id = ? and user_id = ?", deviceID, userID).
+		Updates(quotaColumns(quota))
+	return r.RowsAffected > 0, r.Error
+}
+
+func quotaColumns(quota model.Quota) map[string]interface{} {
+	return map[string]interface{}{
+		"quota_messages_per_hour": quota.MessagesPerHour,
+		"quota_chars_per_day":     quota.CharsPerDay,
+	}
+}
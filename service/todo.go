@@ -0,0 +1,101 @@
+package service
+
+import (
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// Todo provides core functionalities of to-do lists.
+type Todo struct {
+	DB *gorm.DB
+	// Keyring seals the texts of items stored, they are stored in plaintext if nil.
+	Keyring *envelope.Keyring
+}
+
+// GetOrNewList returns the list of the user named name, which is created if not found.
+func (t *Todo) GetOrNewList(userID uint, name string) (*model.TodoList, error) {
+	var list model.TodoList
+	return &list, t.DB.Where(model.TodoList{UserID: userID, Name: name}).FirstOrCreate(&list).Error
+}
+
+// GetList returns the list of the user named name.
+func (t *Todo) GetList(userID uint, name string) (*model.TodoList, error) {
+	var list model.TodoList
+	return &list, t.DB.First(&list, "user_id = ? and name = ?", userID, name).Error
+}
+
+// ListLists returns the lists of the user.
+func (t *Todo) ListLists(userID uint) ([]model.TodoList, error) {
+	var lists []model.TodoList
+	return lists, t.DB.Order("name").Find(&lists, "user_id = ?", userID).Error
+}
+
+// ListMorningLists returns the lists printed automatically every day.
+func (t *Todo) ListMorningLists() ([]model.TodoList, error) {
+	var lists []model.TodoList
+	return lists, t.DB.Order("id").Find(&lists, "morning_time <> ''").Error
+}
+
+// SetMorningTime sets the time of day to print the list, empty to stop.
+func (t *Todo) SetMorningTime(listID uint, morningTime string) error {
+	return t.DB.Model(&model.TodoList{}).Where("id = ?", listID).Update("morning_time", morningTime).Error
+}
+
+// MarkMorningPrinted records the list is printed automatically at at.
+func (t *Todo) MarkMorningPrinted(listID uint, at time.Time) error {
+	return t.DB.Model(&model.TodoList{}).Where("id = ?", listID).Update("last_morning_at", at).Error
+}
+
+// DeleteList deletes the list with its items.
+func (t *Todo) DeleteList(listID uint) error {
+	tx := t.DB.Begin()
+	if err := tx.Unscoped().Where("list_id = ?", listID).Delete(&model.TodoItem{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Unscoped().Where("id = ?", listID).Delete(&model.TodoList{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// AddItem adds an item with text to the list, the text is sealed in the database if a keyring is
+// configured.
+func (t *Todo) AddItem(listID uint, text string) (*model.TodoItem, error) {
+	item := &model.TodoItem{ListID: listID, Text: text}
+	if err := sealText(t.Keyring, item); err != nil {
+		return nil, err
+	}
+	err := t.DB.Create(item).Error
+	item.Text, item.SealedText = text, nil
+	return item, err
+}
+
+// ListItems returns the items of the list in the order added.
+func (t *Todo) ListItems(listID uint) ([]model.TodoItem, error) {
+	var items []model.TodoItem
+	if err := t.DB.Order("id").Find(&items, "list_id = ?", listID).Error; err != nil {
+		return nil, err
+	}
+	return items, openTexts(t.Keyring, items)
+}
+
+// Reseal seals up to limit texts of items after afterID by the current key, see Content.Reseal.
+func (t *Todo) Reseal(afterID uint, limit int) (uint, int, error) {
+	return resealTexts(t.DB.Unscoped(), t.Keyring, "todo_items", afterID, limit)
+}
+
+// SetDone checks off the item at at, or unchecks it if at is nil.
+func (t *Todo) SetDone(itemID uint, at *time.Time) error {
+	return t.DB.Model(&model.TodoItem{}).Where("id = ?", itemID).Update("done_at", at).Error
+}
+
+// DeleteDone deletes the checked off items of the list, the number of deleted ones is returned.
+func (t *Todo) DeleteDone(listID uint) (int64, error) {
+	r := t.DB.Unscoped().Where("list_id = ? and done_at is not null", listID).Delete(&model.TodoItem{})
+	return r.RowsAffected, r.Error
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"time"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// Usage provides core functionalities of quota usage.
+type Usage struct {
+	DB *gorm.DB
+}
+
+// New records a usage and drops the ones no quota looks back on.
+func (u *Usage) New(usage *model.Usage) error {
+	if err := u.DB.Create(usage).Error; err != nil {
+		return err
+	}
+	before := time.Now().Add(-model.UsageWindow)
+	return u.DB.Unscoped().Where("created_at < ?", before).Delete(&model.Usage{}).Error
+}
+
+// ListByDeviceID returns usages of the device created after given time.
+func (u *Usage) ListByDeviceID(deviceID uint, since time.Time) ([]model.Usage, error) {
+	var usages []model.Usage
+	return usages, u.DB.Find(&usages, "device_id = ? and created_at > ?", deviceID, since).Error
+}
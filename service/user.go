@@ -1,30 +1,135 @@
 package service
 
 import (
+	"time"
+
 	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/repository"
 	"github.com/jinzhu/gorm"
 )
 
 // User provides core functionalities of user.
 type User struct {
-	DB *gorm.DB
-}
-
-// IsExistsByTelegramID returns true if given telegramID already exists.
-func (u *User) IsExistsByTelegramID(telegramID int) (bool, error) {
-	var count int
-	r := u.DB.Model(&model.User{}).Where("telegram_id = ?", telegramID).Count(&count)
-	return count == 1, r.Error
+	Repo repository.Users
 }
 
 // GetByTelegramID returns user of given telegram ID.
 func (u *User) GetByTelegramID(telegramID int) (*model.User, error) {
-	var user model.User
-	r := u.DB.First(&user, "telegram_id = ?", telegramID)
-	return &user, r.Error
+	return u.Repo.GetByTelegramID(int64(telegramID))
 }
 
 // New creates a user.
 func (u *User) New(user *model.User) error {
-	return u.DB.Create(user).Error
+	return u.Repo.Create(user)
+}
+
+// lastSeenPrecision is how stale LastSeenAt of users may get, it's not updated more often to save writes.
+const lastSeenPrecision = time.Minute
+
+// Upsert creates the user of profile.TelegramID, or updates its telegram username, full name and
+// language code if changed. LastSeenAt is set to now as well, the user stored is returned.
+func (u *User) Upsert(profile *model.User, now time.Time) (*model.User, error) {
+	user, err := u.Repo.GetByTelegramID(profile.TelegramID)
+	if gorm.IsRecordNotFoundError(err) {
+		created := *profile
+		created.LastSeenAt = &now
+		if err = u.Repo.Create(&created); err == nil {
+			return &created, nil
+		}
+		// the user may be created by another update at the same time, which fails the unique index
+		var findErr error
+		if user, findErr = u.Repo.GetByTelegramID(profile.TelegramID); findErr != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	changes := make(repository.Columns)
+	if user.TelegramUserName != profile.TelegramUserName {
+		changes["telegram_user_name"] = profile.TelegramUserName
+	}
+	if user.TelegramFullName != profile.TelegramFullName {
+		changes["telegram_full_name"] = profile.TelegramFullName
+	}
+	if user.TelegramLanguageCode != profile.TelegramLanguageCode {
+		changes["telegram_language_code"] = profile.TelegramLanguageCode
+	}
+	if len(changes) == 0 && user.LastSeenAt != nil && now.Sub(*user.LastSeenAt) < lastSeenPrecision {
+		return user, nil
+	}
+	changes["last_seen_at"] = now
+	if err := u.Repo.Update(user.ID, changes); err != nil {
+		return nil, err
+	}
+	user.TelegramUserName = profile.TelegramUserName
+	user.TelegramFullName = profile.TelegramFullName
+	user.TelegramLanguageCode = profile.TelegramLanguageCode
+	user.LastSeenAt = &now
+	return user, nil
+}
+
+// SetHistoryRetentionDays updates how long the printed contents of the user are kept.
+func (u *User) SetHistoryRetentionDays(userID uint, days int) error {
+	return u.Repo.Update(userID, repository.Columns{"history_retention_days": days})
+}
+
+// GetByID returns user of given ID.
+func (u *User) GetByID(id uint) (*model.User, error) {
+	return u.Repo.Get(id)
+}
+
+// GetByTelegramUserName returns user of given telegram username.
+func (u *User) GetByTelegramUserName(userName string) (*model.User, error) {
+	return u.Repo.GetByTelegramUserName(userName)
+}
+
+// SetLanguage updates the language chosen by the user.
+func (u *User) SetLanguage(userID uint, language string) error {
+	return u.Repo.Update(userID, repository.Columns{"language": language})
+}
+
+// SetPrintTemplate updates the print template of the user.
+func (u *User) SetPrintTemplate(userID uint, tmpl string) error {
+	return u.Repo.Update(userID, repository.Columns{"print_template": tmpl})
+}
+
+// SetContactLayout updates the layout of contact cards printed for the user.
+func (u *User) SetContactLayout(userID uint, layout string) error {
+	return u.Repo.Update(userID, repository.Columns{"contact_layout": layout})
+}
+
+// SetStickerCaption updates whether the emoji of stickers printed for the user are captioned.
+func (u *User) SetStickerCaption(userID uint, on bool) error {
+	return u.Repo.Update(userID, repository.Columns{"sticker_caption": on})
+}
+
+// Count returns the number of users.
+func (u *User) Count() (int, error) {
+	return u.Repo.Count()
+}
+
+// CountSeenSince returns the number of users who interacted with the bot since t.
+func (u *User) CountSeenSince(t time.Time) (int, error) {
+	return u.Repo.CountSeenSince(t)
+}
+
+// List returns all users.
+func (u *User) List() ([]model.User, error) {
+	return u.Repo.List()
+}
+
+// ListNotBanned returns all users who are not banned.
+func (u *User) ListNotBanned() ([]model.User, error) {
+	return u.Repo.ListNotBanned()
+}
+
+// SetBanned bans or unbans the user.
+func (u *User) SetBanned(userID uint, banned bool) error {
+	var bannedAt *time.Time
+	if banned {
+		now := time.Now()
+		bannedAt = &now
+	}
+	return u.Repo.Update(userID, repository.Columns{"banned_at": bannedAt})
 }
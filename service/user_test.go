@@ -0,0 +1,80 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/awesome-memobird/the-memobird-bot/repository"
+)
+
+func TestUpsert(t *testing.T) {
+	withDB(t, func(db *gorm.DB) {
+		users := &User{Repo: &repository.GormUsers{DB: db}}
+		now := time.Now()
+		user, err := users.Upsert(&model.User{TelegramID: 1, TelegramUserName: "alice"}, now)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, now.Equal(*user.LastSeenAt))
+
+		// not written again soon after
+		soon := now.Add(lastSeenPrecision / 2)
+		again, err := users.Upsert(&model.User{TelegramID: 1, TelegramUserName: "alice"}, soon)
+		if assert.NoError(t, err) {
+			assert.Equal(t, user.ID, again.ID)
+			assert.True(t, now.Equal(*again.LastSeenAt))
+		}
+		renamed, err := users.Upsert(&model.User{TelegramID: 1, TelegramUserName: "alice2"}, soon)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "alice2", renamed.TelegramUserName)
+			assert.True(t, soon.Equal(*renamed.LastSeenAt))
+		}
+		stored, err := users.GetByID(user.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "alice2", stored.TelegramUserName)
+		}
+		count, err := users.CountSeenSince(now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestUpsertConcurrently(t *testing.T) {
+	withDB(t, func(db *gorm.DB) {
+		// sqlite doesn't write concurrently, the updates still interleave between queries
+		db.DB().SetMaxOpenConns(1)
+		for name, repo := range map[string]repository.Users{
+			"gorm":   &repository.GormUsers{DB: db},
+			"memory": &repository.MemoryUsers{},
+		} {
+			users := &User{Repo: repo}
+			const n = 20
+			ids := make(chan uint, n)
+			var wg sync.WaitGroup
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					user, err := users.Upsert(&model.User{TelegramID: 42}, time.Now())
+					if assert.NoError(t, err, name) {
+						ids <- user.ID
+					}
+				}()
+			}
+			wg.Wait()
+			close(ids)
+			first := <-ids
+			for id := range ids {
+				assert.Equal(t, first, id, name)
+			}
+			count, err := users.Count()
+			assert.NoError(t, err, name)
+			assert.Equal(t, 1, count, name)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"github.com/awesome-memobird/the-memobird-bot/envelope"
+	"github.com/awesome-memobird/the-memobird-bot/model"
+	"github.com/jinzhu/gorm"
+)
+
+// Webhook provides core functionalities of incoming webhooks.
+type Webhook struct {
+	DB *gorm.DB
+	// Keyring seals the secrets of webhooks stored, they are stored in plaintext if nil.
+	Keyring *envelope.Keyring
+}
+
+// New creates a webhook with a random secret, which is sealed in the database if a keyring is configured.
+func (w *Webhook) New(webhook *model.Webhook) (*model.Webhook, error) {
+	if _, err := webhook.GenerateSecret(); err != nil {
+		return nil, err
+	}
+	secret, signingSecret := webhook.Secret, webhook.SigningSecret
+	if err := sealWebhook(w.Keyring, webhook); err != nil {
+		return nil, err
+	}
+	err := w.DB.Create(webhook).Error
+	webhook.Secret, webhook.SigningSecret, webhook.SealedSecret = secret, signingSecret, nil
+	return webhook, err
+}
+
+// GetBySecret returns the webhook of secret, either sealed or in plaintext.
+func (w *Webhook) GetBySecret(secret string) (*model.Webhook, error) {
+	var webhook model.Webhook
+	// the hashes stored are not secrets themselves
+	err := w.DB.First(&webhook, "(secret = ? and (key_id is null or key_id = '')) or (secret = ? and key_id <> '')",
+		secret, secretHash(secret)).Error
+	if err != nil {
+		return &webhook, err
+	}
+	return &webhook, openWebhook(w.Keyring, &webhook)
+}
+
+// ListByUserID returns all webhooks created by the user.
+func (w *Webhook) ListByUserID(userID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := w.DB.Order("id").Find(&webhooks, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, openWebhooks(w.Keyring, webhooks)
+}
+
+// Reseal seals up to limit secrets of webhooks after afterID by the current key, see Content.Reseal.
+func (w *Webhook) Reseal(afterID uint, limit int) (uint, int, error) {
+	if w.Keyring == nil {
+		return 0, 0, errNoKeyring
+	}
+	var webhooks []model.Webhook
+	db := w.DB.Unscoped()
+	err := db.Where("id > ? and (key_id is null or key_id <> ?)", afterID, w.Keyring.Current()).
+		Order("id").Limit(limit).Find(&webhooks).Error
+	if err != nil || len(webhooks) == 0 {
+		return 0, 0, err
+	}
+
+	tx := db.Begin()
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		err := openWebhook(w.Keyring, webhook)
+		if err == nil {
+			err = sealWebhook(w.Keyring, webhook)
+		}
+		if err == nil {
+			err = tx.Model(webhook).UpdateColumns(map[string]interface{}{
+				"secret":         webhook.Secret,
+				"signing_secret": webhook.SigningSecret,
+				"key_id":         webhook.KeyID,
+				"sealed_secret":  webhook.SealedSecret,
+			}).Error
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, 0, err
+		}
+	}
+	return webhooks[len(webhooks)-1].ID, len(webhooks), tx.Commit().Error
+}
+
+// DeleteByIDAndUserID deletes the webhook of user.
+func (w *Webhook) DeleteByIDAndUserID(id, userID uint) (bool, error) {
+	r := w.DB.Unscoped().Where("id = ? and user_id = ?", id, userID).Delete(&model.Webhook{})
+	return r.RowsAffected > 0, r.Error
+}
+
+// SetTemplate updates the template of the webhook of user.
+func (w *Webhook) SetTemplate(id, userID uint, tmpl string) (bool, error) {
+	r := w.DB.Model(&model.Webhook{}).
+		Where("id = ? and user_id = ?", id, userID).
+		Update("template", tmpl)
+	return r.RowsAffected > 0, r.Error
+}
@@ -0,0 +1,348 @@
+// Package webhook renders payloads of incoming webhooks into printable summaries.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Kinds of webhooks.
+const (
+	KindAlertmanager = "alertmanager"
+	KindGitHub       = "github"
+	KindGitea        = "gitea"
+	KindJSON         = "json"
+)
+
+// Kinds are all supported kinds.
+var Kinds = []string{KindAlertmanager, KindGitHub, KindGitea, KindJSON}
+
+// IsKind returns true if kind is supported.
+func IsKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// errors of rendering.
+var (
+	// ErrIgnored is returned for payloads that are not worth printing, like pings.
+	ErrIgnored = errors.New("payload ignored")
+	// ErrNoTemplate is returned for webhooks of KindJSON without a template.
+	ErrNoTemplate = errors.New("no template")
+	// ErrBadSignature is returned for payloads not signed by the signing secret.
+	ErrBadSignature = errors.New("bad signature")
+)
+
+const (
+	maxCommits     = 5
+	maxBodyPreview = 200
+)
+
+// Summary is a printable summary of a payload.
+type Summary struct {
+	Text string
+	// Key identifies the payload for deduplication, repeated payloads have the same key.
+	Key string
+}
+
+// Render renders body of a webhook of kind, tmpl is the template of KindJSON.
+func Render(kind string, header http.Header, body []byte, tmpl string) (*Summary, error) {
+	switch kind {
+	case KindAlertmanager:
+		return renderAlertmanager(body)
+	case KindGitHub:
+		return renderGit(header.Get("X-GitHub-Event"), header.Get("X-GitHub-Delivery"), body)
+	case KindGitea:
+		return renderGit(header.Get("X-Gitea-Event"), header.Get("X-Gitea-Delivery"), body)
+	case KindJSON:
+		return renderJSON(body, tmpl)
+	}
+	return nil, fmt.Errorf("unsupported kind: %s", kind)
+}
+
+// IsSigned returns true if payloads of kind are signed, see Verify.
+func IsSigned(kind string) bool {
+	return kind == KindGitHub || kind == KindGitea
+}
+
+// Verify returns ErrBadSignature unless body of a signed kind is signed by secret as the header says,
+// bodies of other kinds are never verified.
+func Verify(kind string, header http.Header, body []byte, secret string) error {
+	var signature string
+	switch kind {
+	case KindGitHub:
+		signature = strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+	case KindGitea:
+		signature = header.Get("X-Gitea-Signature")
+	default:
+		return nil
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil || secret == "" {
+		return ErrBadSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// ParseTemplate parses tmpl of KindJSON.
+func ParseTemplate(tmpl string) (*template.Template, error) {
+	return template.New("webhook").Option("missingkey=zero").Parse(tmpl)
+}
+
+func hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+}
+
+func preview(s string) string {
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > maxBodyPreview {
+		return string(r[:maxBodyPreview]) + "…"
+	}
+	return s
+}
+
+type alertmanagerPayload struct {
+	Status            string            `json:"status"`
+	GroupKey          string            `json:"groupKey"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	Alerts            []struct {
+		Status      string            `json:"status"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+		StartsAt    time.Time         `json:"startsAt"`
+		Fingerprint string            `json:"fingerprint"`
+	} `json:"alerts"`
+}
+
+func renderAlertmanager(body []byte) (*Summary, error) {
+	var p alertmanagerPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("decoding alertmanager payload: %w", err)
+	}
+	if len(p.Alerts) == 0 {
+		return nil, ErrIgnored
+	}
+
+	name := p.CommonLabels["alertname"]
+	if name == "" {
+		name = p.Alerts[0].Labels["alertname"]
+	}
+	lines := []string{fmt.Sprintf("[%s:%d] %s", strings.ToUpper(p.Status), len(p.Alerts), name)}
+	if s := p.CommonLabels["severity"]; s != "" {
+		lines = append(lines, "Severity: "+s)
+	}
+	var keys []string
+	for _, a := range p.Alerts {
+		desc := a.Annotations["summary"]
+		if desc == "" {
+			desc = a.Annotations["description"]
+		}
+		if desc == "" {
+			desc = p.CommonAnnotations["summary"]
+		}
+		subject := a.Labels["instance"]
+		if subject == "" {
+			subject = a.Labels["alertname"]
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s", subject, firstLine(desc)))
+		fingerprint := a.Fingerprint
+		if fingerprint == "" {
+			labels, _ := json.Marshal(a.Labels)
+			fingerprint = hash(labels)
+		}
+		keys = append(keys, a.Status+":"+fingerprint)
+	}
+	sort.Strings(keys)
+	return &Summary{
+		Text: strings.Join(lines, "\n"),
+		Key:  hash([]byte(p.GroupKey + "|" + p.Status + "|" + strings.Join(keys, ","))),
+	}, nil
+}
+
+// user is a user in payloads of GitHub and Gitea.
+type user struct {
+	Name     string `json:"name"`
+	Login    string `json:"login"`
+	UserName string `json:"username"`
+}
+
+func (u user) String() string {
+	for _, s := range []string{u.Login, u.UserName, u.Name} {
+		if s != "" {
+			return s
+		}
+	}
+	return "someone"
+}
+
+// gitPayload contains fields shared by payloads of GitHub and Gitea.
+type gitPayload struct {
+	Action     string `json:"action"`
+	Ref        string `json:"ref"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Pusher  user `json:"pusher"`
+	Sender  user `json:"sender"`
+	Commits []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		Author  user   `json:"author"`
+	} `json:"commits"`
+	Release struct {
+		TagName string `json:"tag_name"`
+		Name    string `json:"name"`
+		Body    string `json:"body"`
+	} `json:"release"`
+	Issue struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		User   user   `json:"user"`
+	} `json:"issue"`
+}
+
+func renderGit(event, delivery string, body []byte) (*Summary, error) {
+	var p gitPayload
+	switch event {
+	case "push", "release", "issues":
+	default:
+		return nil, ErrIgnored
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("decoding %s payload: %w", event, err)
+	}
+
+	repo := p.Repository.FullName
+	var lines []string
+	switch event {
+	case "push":
+		if len(p.Commits) == 0 {
+			return nil, ErrIgnored
+		}
+		branch := strings.TrimPrefix(strings.TrimPrefix(p.Ref, "refs/heads/"), "refs/tags/")
+		pusher := p.Pusher
+		if pusher.String() == "someone" {
+			pusher = p.Sender
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s pushed %d commits to %s", repo, pusher, len(p.Commits), branch))
+		for i, c := range p.Commits {
+			if i == maxCommits {
+				lines = append(lines, fmt.Sprintf("… and %d more", len(p.Commits)-maxCommits))
+				break
+			}
+			id := c.ID
+			if len(id) > 7 {
+				id = id[:7]
+			}
+			lines = append(lines, fmt.Sprintf("- %s %s", id, firstLine(c.Message)))
+		}
+	case "release":
+		if p.Action != "published" && p.Action != "released" {
+			return nil, ErrIgnored
+		}
+		lines = append(lines, fmt.Sprintf("[%s] released %s", repo, p.Release.TagName))
+		if p.Release.Name != "" && p.Release.Name != p.Release.TagName {
+			lines = append(lines, p.Release.Name)
+		}
+		if b := preview(p.Release.Body); b != "" {
+			lines = append(lines, b)
+		}
+	case "issues":
+		switch p.Action {
+		case "opened", "closed", "reopened":
+		default:
+			return nil, ErrIgnored
+		}
+		lines = append(lines, fmt.Sprintf("[%s] issue #%d %s by %s", repo, p.Issue.Number, p.Action, p.Sender),
+			p.Issue.Title)
+	}
+
+	key := delivery
+	if key == "" {
+		key = hash(body)
+	}
+	return &Summary{Text: strings.Join(lines, "\n"), Key: key}, nil
+}
+
+func renderJSON(body []byte, tmpl string) (*Summary, error) {
+	if tmpl == "" {
+		return nil, ErrNoTemplate
+	}
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("decoding JSON payload: %w", err)
+	}
+	t, err := ParseTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("executing template: %w", err)
+	}
+	text := strings.TrimSpace(buf.String())
+	if text == "" {
+		return nil, ErrIgnored
+	}
+	return &Summary{Text: text, Key: hash(body)}, nil
+}
+
+// Deduper remembers keys of payloads for a window of time.
+type Deduper struct {
+	Window time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// Seen returns true if key was seen within the window before now, otherwise key is remembered.
+func (d *Deduper) Seen(key string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen == nil {
+		d.seen = make(map[string]time.Time)
+	}
+	for k, t := range d.seen {
+		if now.Sub(t) >= d.Window {
+			delete(d.seen, k)
+		}
+	}
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	d.seen[key] = now
+	return false
+}
+
+// Forget forgets key so that it's not seen anymore.
+func (d *Deduper) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, key)
+}
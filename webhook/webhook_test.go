@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const alertmanagerBody = `{
+  "status": "firing",
+  "groupKey": "{}:{alertname=\"DiskFull\"}",
+  "commonLabels": {"alertname": "DiskFull", "severity": "critical"},
+  "alerts": [
+    {"status": "firing", "labels": {"alertname": "DiskFull", "instance": "db1"}, "annotations": {"summary": "Disk is 95% full\nmore"}, "fingerprint": "a1"},
+    {"status": "firing", "labels": {"alertname": "DiskFull", "instance": "db2"}, "annotations": {"description": "Disk is 99% full"}, "fingerprint": "b2"}
+  ]
+}`
+
+func TestRenderAlertmanager(t *testing.T) {
+	s, err := Render(KindAlertmanager, nil, []byte(alertmanagerBody), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "[FIRING:2] DiskFull\nSeverity: critical\n- db1: Disk is 95% full\n- db2: Disk is 99% full", s.Text)
+
+	again, _ := Render(KindAlertmanager, nil, []byte(alertmanagerBody), "")
+	assert.Equal(t, s.Key, again.Key)
+
+	_, err = Render(KindAlertmanager, nil, []byte(`{"status": "firing", "alerts": []}`), "")
+	assert.Equal(t, ErrIgnored, err)
+}
+
+func TestRenderGit(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "push")
+	header.Set("X-GitHub-Delivery", "d1")
+	s, err := Render(KindGitHub, header, []byte(`{
+  "ref": "refs/heads/main",
+  "repository": {"full_name": "octo/bird"},
+  "pusher": {"name": "octocat"},
+  "commits": [{"id": "0123456789abcdef", "message": "Fix printing\n\nDetails"}]
+}`), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "[octo/bird] octocat pushed 1 commits to main\n- 0123456 Fix printing", s.Text)
+	assert.Equal(t, "d1", s.Key)
+
+	header = http.Header{}
+	header.Set("X-Gitea-Event", "issues")
+	s, err = Render(KindGitea, header, []byte(`{
+  "action": "opened",
+  "repository": {"full_name": "team/bot"},
+  "sender": {"login": "alice"},
+  "issue": {"number": 12, "title": "Paper jam"}
+}`), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "[team/bot] issue #12 opened by alice\nPaper jam", s.Text)
+
+	header = http.Header{}
+	header.Set("X-GitHub-Event", "release")
+	s, err = Render(KindGitHub, header, []byte(`{
+  "action": "published",
+  "repository": {"full_name": "octo/bird"},
+  "release": {"tag_name": "v1.0", "name": "First", "body": "Notes"}
+}`), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "[octo/bird] released v1.0\nFirst\nNotes", s.Text)
+
+	header.Set("X-GitHub-Event", "ping")
+	_, err = Render(KindGitHub, header, []byte(`{}`), "")
+	assert.Equal(t, ErrIgnored, err)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"zen": "Keep it logically awesome."}`)
+	// HMAC-SHA256 of body by "secret"
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	github := http.Header{}
+	github.Set("X-Hub-Signature-256", "sha256="+signature)
+	assert.NoError(t, Verify(KindGitHub, github, body, "secret"))
+	assert.Equal(t, ErrBadSignature, Verify(KindGitHub, github, body, "other"))
+	assert.Equal(t, ErrBadSignature, Verify(KindGitHub, github, []byte(`{}`), "secret"))
+	assert.Equal(t, ErrBadSignature, Verify(KindGitHub, http.Header{}, body, "secret"))
+	assert.Equal(t, ErrBadSignature, Verify(KindGitHub, github, body, ""), "webhooks without signing secrets are rejected")
+
+	gitea := http.Header{}
+	gitea.Set("X-Gitea-Signature", signature)
+	assert.NoError(t, Verify(KindGitea, gitea, body, "secret"))
+	assert.Equal(t, ErrBadSignature, Verify(KindGitea, github, body, "secret"))
+
+	assert.NoError(t, Verify(KindAlertmanager, http.Header{}, body, ""))
+	assert.NoError(t, Verify(KindJSON, http.Header{}, body, ""))
+	assert.True(t, IsSigned(KindGitHub))
+	assert.False(t, IsSigned(KindJSON))
+}
+
+func TestRenderJSON(t *testing.T) {
+	s, err := Render(KindJSON, nil, []byte(`{"service": "api", "level": "error"}`), "{{.service}} is {{.level}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "api is error", s.Text)
+
+	_, err = Render(KindJSON, nil, []byte(`{}`), "")
+	assert.Equal(t, ErrNoTemplate, err)
+	_, err = Render(KindJSON, nil, []byte(`not json`), "{{.}}")
+	assert.Error(t, err)
+}
+
+func TestDeduper(t *testing.T) {
+	d := &Deduper{Window: time.Hour}
+	now := time.Now()
+	assert.False(t, d.Seen("a", now))
+	assert.True(t, d.Seen("a", now.Add(time.Minute)))
+	assert.False(t, d.Seen("b", now))
+	assert.False(t, d.Seen("a", now.Add(time.Hour)))
+	d.Forget("b")
+	assert.False(t, d.Seen("b", now))
+}